@@ -0,0 +1,68 @@
+package ini
+
+import (
+	"net/mail"
+	"strings"
+)
+
+// AddEmail adds a new string field of the given name to the section for an email address.  The
+// name must not be present in the section and must be syntactically valid (see package comments).
+// ParseEmail describes the accepted values.  The default value is the empty string.  The field is
+// also available as the registered type "email", see [Section.AddTyped].
+func (section *Section) AddEmail(name string) *Field {
+	return section.Add(name, TyString, "", ParseEmail)
+}
+
+// ParseEmail accepts an email address of the form `local@domain`, as defined by RFC 5322 but
+// without a display name or angle brackets, where the domain is a host name as for ParseHostname
+// or an address literal in brackets, eg `[192.0.2.1]`, returning its input and a validity flag.
+// The empty string is accepted too, for no address.
+func ParseEmail(s string) (any, bool) {
+	if s == "" {
+		return s, true
+	}
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Name != "" || strings.ContainsAny(s, "<>") {
+		return "", false
+	}
+	domain := addr.Address[strings.LastIndexByte(addr.Address, '@')+1:]
+	if !strings.HasPrefix(domain, "[") && !isHostname(domain) {
+		return "", false
+	}
+	return s, true
+}
+
+// AddHostname adds a new string field of the given name to the section for a host name.  The name
+// must not be present in the section and must be syntactically valid (see package comments).
+// ParseHostname describes the accepted values.  The default value is the empty string.  The field
+// is also available as the registered type "hostname", see [Section.AddTyped].
+func (section *Section) AddHostname(name string) *Field {
+	return section.Add(name, TyString, "", ParseHostname)
+}
+
+// ParseHostname accepts a host name as defined by RFC 1123: dot-separated labels of 1 to 63 ASCII
+// letters, digits and hyphens that do not start or end with a hyphen, at most 253 characters in all
+// and optionally followed by a dot, returning its input and a validity flag.  IPv4 addresses have
+// this syntax too, but IPv6 addresses, ports and internationalized names that have not been
+// converted to ASCII do not.  The empty string is accepted too, for no host.
+func ParseHostname(s string) (any, bool) {
+	return s, s == "" || isHostname(s)
+}
+
+func isHostname(s string) bool {
+	s = strings.TrimSuffix(s, ".")
+	if s == "" || len(s) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(s, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range []byte(label) {
+			if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
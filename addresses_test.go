@@ -0,0 +1,83 @@
+package ini
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAddresses(t *testing.T) {
+	long := strings.Repeat("a", 63)
+	for _, tc := range []struct {
+		input string
+		ok    bool
+	}{
+		{"example.com", true},
+		{"Mail-1.Example.COM.", true},
+		{"localhost", true},
+		{"192.0.2.1", true},
+		{long + ".com", true},
+		{long + "a.com", false},
+		{strings.Repeat(long+".", 4) + "com", false},
+		{"-bad.com", false},
+		{"bad-.com", false},
+		{"a..com", false},
+		{"under_score.com", false},
+		{"host:80", false},
+		{"", true},
+		{".", false},
+	} {
+		if _, ok := ParseHostname(tc.input); ok != tc.ok {
+			t.Fatal(tc.input, ok)
+		}
+	}
+
+	for _, tc := range []struct {
+		input, want string
+		ok          bool
+	}{
+		{"ops@example.com", "ops@example.com", true},
+		{"first.last+tag@mail.example.org", "first.last+tag@mail.example.org", true},
+		{`"odd name"@example.com`, `"odd name"@example.com`, true},
+		{"root@[192.0.2.1]", "root@[192.0.2.1]", true},
+		{"Ops <ops@example.com>", "", false},
+		{"<ops@example.com>", "", false},
+		{"ops@-example.com", "", false},
+		{"ops", "", false},
+		{"@example.com", "", false},
+		{"", "", true},
+	} {
+		v, ok := ParseEmail(tc.input)
+		if ok != tc.ok || ok && v != tc.want {
+			t.Fatal(tc.input, v, ok)
+		}
+	}
+
+	p := NewParser()
+	s := p.AddSection("contact")
+	email := s.AddEmail("email")
+	host := s.AddTyped("host", "hostname")
+	store, err := p.Parse(
+		strings.NewReader("[contact]\nemail = ops@example.com\nhost = smtp.example.com\n"))
+	if err != nil || email.StringVal(store) != "ops@example.com" ||
+		host.StringVal(store) != "smtp.example.com" {
+		t.Fatal(err)
+	}
+	// The defaults can be written and read back
+	store, err = p.Parse(strings.NewReader(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := store.Write(&b, &WriteOptions{Defaults: true}); err != nil {
+		t.Fatal(err)
+	}
+	if again, err := p.Parse(strings.NewReader(b.String())); err != nil ||
+		email.StringVal(again) != "" || host.StringVal(again) != "" {
+		t.Fatal(err, b.String())
+	}
+	_, err = p.Parse(strings.NewReader("[contact]\nemail = ops@\n"))
+	if err == nil ||
+		err.Error() != "Line 2: In section contact: Value 'ops@' is not valid for field email" {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,107 @@
+package ini
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Parse numbers from `go test -run '^$' -bench Parse -benchmem -count 5` with go1.27.1 on a
+// single-core Intel Xeon VM (linux/amd64), before and after the allocation work that introduced
+// this suite (presizing section maps, caching the current section during parsing, and avoiding
+// copies of lines that fit the read buffer); the "before" numbers are for this suite run against
+// the parser as it was before that work.  Times are medians of the five runs and varied by up to a
+// third between runs on that machine, while the memory and allocation counts are stable:
+//
+//	                      before                         after
+//	Parse/small           7.7µs  5.9KB    55 allocs      7.5µs  5.8KB    39 allocs
+//	Parse/large           5.2ms  1.4MB  31.8K allocs     3.7ms  0.9MB  20.8K allocs
+//	Parse/many-sections   5.1ms  1.2MB  26.1K allocs     6.3ms  1.1MB  18.1K allocs
+//	Parse/long-values     0.2ms  1.0MB    63 allocs      0.2ms  1.0MB    47 allocs
+//
+// The remaining allocations are mostly one string per nonblank line and one boxed value per
+// setting.
+
+var benchmarks = []struct {
+	name               string
+	sections, settings int
+	valueLen           int
+}{
+	{"small", 2, 5, 10},
+	{"large", 100, 100, 10},
+	{"many-sections", 2000, 2, 10},
+	{"long-values", 2, 5, 65536},
+}
+
+// makeInput returns an ini file with the given number of sections, each with the given number of
+// settings with values of the given length, for the schema returned by makeParser.
+func makeInput(sections, settings, valueLen int) string {
+	value := strings.Repeat("v", valueLen)
+	var b strings.Builder
+	for i := range sections {
+		fmt.Fprintf(&b, "# Section number %d\n[ s%d ]\n", i, i)
+		for j := range settings {
+			fmt.Fprintf(&b, "  f%d = \"%s\"\n", j, value)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func makeParser(sections, settings int) *Parser {
+	p := NewParser()
+	for i := range sections {
+		s := p.AddSection(fmt.Sprintf("s%d", i))
+		for j := range settings {
+			s.AddString(fmt.Sprintf("f%d", j))
+		}
+	}
+	return p
+}
+
+func BenchmarkScanner(b *testing.B) {
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			input := makeInput(bm.sections, bm.settings, bm.valueLen)
+			b.SetBytes(int64(len(input)))
+			b.ReportAllocs()
+			for range b.N {
+				s := NewScanner(strings.NewReader(input))
+				for s.Scan() {
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			input := makeInput(bm.sections, bm.settings, bm.valueLen)
+			p := makeParser(bm.sections, bm.settings)
+			b.SetBytes(int64(len(input)))
+			b.ReportAllocs()
+			for range b.N {
+				if _, err := p.Parse(strings.NewReader(input)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkParseString(b *testing.B) {
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			input := makeInput(bm.sections, bm.settings, bm.valueLen)
+			p := makeParser(bm.sections, bm.settings)
+			b.SetBytes(int64(len(input)))
+			b.ReportAllocs()
+			for range b.N {
+				if _, err := p.ParseString(input); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
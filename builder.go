@@ -0,0 +1,389 @@
+package ini
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// A SectionBuilder defines the fields of a section with chained calls, eg
+//
+//	p.Define("net").
+//		String("host").Default("localhost").Required().Help("The server's host name").
+//		Int64("port").Default(8080).Range(1, 65535)
+//
+// Each field method adds a field to the section and makes it the current field, and the other
+// methods apply to the current field.  [SectionBuilder.Field] and [SectionBuilder.Section] return
+// the handles for the fields and the section.  The methods panic as the underlying Add and Field
+// methods do, and the field modifiers panic if there is no current field.
+type SectionBuilder struct {
+	section *Section
+	field   *Field
+}
+
+// Define returns a SectionBuilder for the section of the given name, which is added to the parser
+// with [Parser.AddSection] if it is not already present.
+func (parser *Parser) Define(name string) *SectionBuilder {
+	section := parser.sections[name]
+	if section == nil {
+		section = parser.AddSection(name)
+	}
+	return &SectionBuilder{section: section}
+}
+
+// Section returns the section being defined.
+func (b *SectionBuilder) Section() *Section {
+	return b.section
+}
+
+// Field returns the current field, or the field of the given name if a name is passed, or nil if
+// there is no such field.
+func (b *SectionBuilder) Field(name ...string) *Field {
+	if len(name) > 0 {
+		return b.section.Field(name[0])
+	}
+	return b.field
+}
+
+// String adds a string field as for [Section.AddString] and makes it the current field.
+func (b *SectionBuilder) String(name string) *SectionBuilder {
+	return b.add(b.section.AddString(name))
+}
+
+// Bool adds a boolean field as for [Section.AddBool] and makes it the current field.
+func (b *SectionBuilder) Bool(name string) *SectionBuilder {
+	return b.add(b.section.AddBool(name))
+}
+
+// Int64 adds an int64 field as for [Section.AddInt64] and makes it the current field.
+func (b *SectionBuilder) Int64(name string) *SectionBuilder {
+	return b.add(b.section.AddInt64(name))
+}
+
+// Uint64 adds a uint64 field as for [Section.AddUint64] and makes it the current field.
+func (b *SectionBuilder) Uint64(name string) *SectionBuilder {
+	return b.add(b.section.AddUint64(name))
+}
+
+// Float64 adds a float64 field as for [Section.AddFloat64] and makes it the current field.
+func (b *SectionBuilder) Float64(name string) *SectionBuilder {
+	return b.add(b.section.AddFloat64(name))
+}
+
+// StringList adds a list field as for [Section.AddStringList] and makes it the current field.
+func (b *SectionBuilder) StringList(name string) *SectionBuilder {
+	return b.add(b.section.AddStringList(name))
+}
+
+// Typed adds a field of a registered type as for [Section.AddTyped] and makes it the current
+// field.
+func (b *SectionBuilder) Typed(name, typeName string) *SectionBuilder {
+	return b.add(b.section.AddTyped(name, typeName))
+}
+
+// Add makes a field of the section that was added by other means, eg with [AddChoice] or
+// [AddListOf], the current field.  Add panics if the field is not in the section.
+func (b *SectionBuilder) Add(field *Field) *SectionBuilder {
+	if field.section != b.section {
+		panic("Field " + field.name + " is not in section " + b.section.name)
+	}
+	return b.add(field)
+}
+
+func (b *SectionBuilder) add(field *Field) *SectionBuilder {
+	b.field = field
+	return b
+}
+
+func (b *SectionBuilder) current() *Field {
+	if b.field == nil {
+		panic("No current field in section " + b.section.name)
+	}
+	return b.field
+}
+
+// Default sets the current field's static default value.  The value must have the type of the
+// field's values, except that a number is converted to the field's numeric type, so that eg
+// Default(8080) works for an int64 field, provided that the conversion does not change its value.
+func (b *SectionBuilder) Default(v any) *SectionBuilder {
+	field := b.current()
+	v, ok := field.convert(v)
+	if !ok {
+		panic("Default value of the wrong type for field " + field.name)
+	}
+	field.defaultValue = v
+	return b
+}
+
+// convert returns v as a value of the type of the field's values, and true, converting a number to
+// the field's numeric type, or false if v has some other type or the conversion would change it.
+func (field *Field) convert(v any) (any, bool) {
+	want := reflect.TypeOf(field.defaultValue)
+	got := reflect.ValueOf(v)
+	if v == nil || got.Type() != want {
+		if v == nil || !isNumber(got) || !isNumber(reflect.Zero(want)) {
+			return nil, false
+		}
+		converted, ok := convertNumber(got, want)
+		if !ok {
+			return nil, false
+		}
+		v = converted.Interface()
+	}
+	return v, true
+}
+
+// Required makes the current field required, see [Field.Required].
+func (b *SectionBuilder) Required() *SectionBuilder {
+	b.current().Required()
+	return b
+}
+
+// RequiredIf makes the current field conditionally required, see [Field.RequiredIf].
+func (b *SectionBuilder) RequiredIf(other *Field, value any) *SectionBuilder {
+	b.current().RequiredIf(other, value)
+	return b
+}
+
+// Help sets the current field's help text, see [Field.Help].
+func (b *SectionBuilder) Help(text string) *SectionBuilder {
+	b.current().Help(text)
+	return b
+}
+
+// Range restricts the current field's values, see [Field.Range].
+func (b *SectionBuilder) Range(lo, hi float64) *SectionBuilder {
+	b.current().Range(lo, hi)
+	return b
+}
+
+// MaxLen limits the length of the current field's values, see [Field.MaxLen].
+func (b *SectionBuilder) MaxLen(n int) *SectionBuilder {
+	b.current().MaxLen(n)
+	return b
+}
+
+// MaxItems limits the number of elements of the current field's values, see [Field.MaxItems].
+func (b *SectionBuilder) MaxItems(n int) *SectionBuilder {
+	b.current().MaxItems(n)
+	return b
+}
+
+// Secret marks the current field as secret, see [Field.Secret].
+func (b *SectionBuilder) Secret() *SectionBuilder {
+	b.current().Secret()
+	return b
+}
+
+// DefaultFromEnv names the environment variable for the current field's default value, see
+// [Field.DefaultFromEnv].
+func (b *SectionBuilder) DefaultFromEnv(name string) *SectionBuilder {
+	b.current().DefaultFromEnv(name)
+	return b
+}
+
+// Required marks the field as required: it is a parse error if the field is not present in the
+// input and its [Field.DefaultFromEnv] variable, if any, is not set.  Fields of sections that are
+// skipped by [Parser.ParseSections] are not checked.  Returns the field.
+func (field *Field) Required() *Field {
+	field.required = true
+	return field
+}
+
+// IsRequired returns true if the field has been marked by [Field.Required].
+func (field *Field) IsRequired() bool {
+	return field.required
+}
+
+// Help sets a text that describes the field to users.  Returns the field.
+func (field *Field) Help(text string) *Field {
+	field.help = text
+	return field
+}
+
+// HelpText returns the text set by [Field.Help], or "".
+func (field *Field) HelpText() string {
+	return field.help
+}
+
+// Range restricts the values of a numeric field to the closed interval [lo, hi]: other values are
+// invalid.  Range panics if the field's values are not numbers.  Returns the field.
+func (field *Field) Range(lo, hi float64) *Field {
+	if !isNumber(reflect.ValueOf(field.defaultValue)) {
+		panic("Range on non-numeric field " + field.name)
+	}
+	if lo > hi {
+		panic("Empty range for field " + field.name)
+	}
+	valid := field.valid
+	field.valid = func(s string) (any, bool) {
+		v, ok := valid(s)
+		if !ok {
+			return v, false
+		}
+		n := toFloat(reflect.ValueOf(v))
+		return v, n >= lo && n <= hi
+	}
+	if field.bounds != nil {
+		lo, hi = max(lo, field.bounds[0]), min(hi, field.bounds[1])
+	}
+	field.bounds = &[2]float64{lo, hi}
+	return field
+}
+
+// MaxLen limits the values of a string field, or the elements of the values of a string list field,
+// to n bytes: it is a parse error if a value in the input, or from the field's
+// [Field.DefaultFromEnv] variable, is longer.  MaxLen panics if the field's values are not strings
+// or lists of strings, or if n is not positive.  Returns the field.
+func (field *Field) MaxLen(n int) *Field {
+	switch field.defaultValue.(type) {
+	case string, []string:
+	default:
+		panic("MaxLen on non-string field " + field.name)
+	}
+	if n <= 0 {
+		panic("Non-positive MaxLen for field " + field.name)
+	}
+	field.maxLen = n
+	return field
+}
+
+// MaxItems limits the values of a list or map field to n elements: it is a parse error if a value
+// in the input, or from the field's [Field.DefaultFromEnv] variable, has more.  MaxItems panics if
+// the field is not a list or map field, or if n is not positive.  Returns the field.
+func (field *Field) MaxItems(n int) *Field {
+	if !field.list {
+		panic("MaxItems on non-list field " + field.name)
+	}
+	if n <= 0 {
+		panic("Non-positive MaxItems for field " + field.name)
+	}
+	field.maxItems = n
+	return field
+}
+
+// checkLimits returns an error if the value v of the field exceeds the limits set by MaxLen and
+// MaxItems, otherwise nil.
+func (field *Field) checkLimits(v any) error {
+	if field.maxItems > 0 {
+		if n := reflect.ValueOf(v).Len(); n > field.maxItems {
+			return fmt.Errorf("Too many elements for field %s, the limit is %d", field.name,
+				field.maxItems)
+		}
+	}
+	if field.maxLen > 0 {
+		switch x := v.(type) {
+		case string:
+			if len(x) > field.maxLen {
+				return fmt.Errorf("Value too long for field %s, the limit is %d bytes", field.name,
+					field.maxLen)
+			}
+		case []string:
+			for i, e := range x {
+				if len(e) > field.maxLen {
+					return fmt.Errorf("Element %d too long for field %s, the limit is %d bytes",
+						i+1, field.name, field.maxLen)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func isNumber(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// convertNumber returns the number v converted to the numeric type t, and true, or false if the
+// conversion would change the value: if v is out of t's range, or t is an integer type and v has a
+// fractional part, or v is an integer that t cannot represent exactly.  A float that is converted
+// to a smaller float type may be rounded.
+func convertNumber(v reflect.Value, t reflect.Type) (reflect.Value, bool) {
+	r := reflect.New(t).Elem()
+	var lossy bool
+	switch {
+	case v.CanInt():
+		i := v.Int()
+		switch {
+		case r.CanInt():
+			lossy = r.OverflowInt(i)
+		case r.CanUint():
+			lossy = i < 0 || r.OverflowUint(uint64(i))
+		default:
+			f := v.Convert(t).Float()
+			lossy = f < math.MinInt64 || f >= -math.MinInt64 || int64(f) != i
+		}
+	case v.CanUint():
+		u := v.Uint()
+		switch {
+		case r.CanInt():
+			lossy = u > math.MaxInt64 || r.OverflowInt(int64(u))
+		case r.CanUint():
+			lossy = r.OverflowUint(u)
+		default:
+			f := v.Convert(t).Float()
+			lossy = f >= math.MaxUint64 || uint64(f) != u
+		}
+	default:
+		f := v.Float()
+		switch {
+		case r.CanInt():
+			lossy = f != math.Trunc(f) || f < math.MinInt64 || f >= -math.MinInt64 ||
+				r.OverflowInt(int64(f))
+		case r.CanUint():
+			lossy = f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 || r.OverflowUint(uint64(f))
+		default:
+			lossy = r.OverflowFloat(f)
+		}
+	}
+	if lossy {
+		return reflect.Value{}, false
+	}
+	return v.Convert(t), true
+}
+
+func toFloat(v reflect.Value) float64 {
+	switch {
+	case v.CanInt():
+		return float64(v.Int())
+	case v.CanUint():
+		return float64(v.Uint())
+	default:
+		return v.Float()
+	}
+}
+
+// checkRequired passes an error for every required section that is not present in the store, and
+// every required field that has no value in it, in declaration order, to fail, considering only the
+// sections in only if only is not nil, and returns the first error that fail returns.
+func (parser *Parser) checkRequired(
+	store *Store,
+	only map[string]bool,
+	fail func(error) error,
+) error {
+	for _, section := range parser.order {
+		if only != nil && !only[section.name] {
+			continue
+		}
+		if section.required && !section.Present(store) {
+			if err := fail(parseFail(0, "", "Missing required section %s", section.name)); err != nil {
+				return err
+			}
+		}
+		for _, field := range section.order {
+			if field.required && !field.Present(store) && store.origins[field].Kind != OriginEnv {
+				err := fail(parseFail(0, section.name, "Missing required field %s", field.name))
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
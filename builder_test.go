@@ -0,0 +1,123 @@
+package ini
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefine(t *testing.T) {
+	p := NewParser()
+	b := p.Define("net").
+		String("host").Default("localhost").Required().Help("The server's host name").
+		Int64("port").Default(8080).Range(1, 65535).
+		Float64("ratio").Default(1).Range(0, 1).
+		StringList("tags")
+	if b.Field().Name() != "tags" || b.Section() != p.Section("net") {
+		t.Fatal("Handles")
+	}
+	host, port := b.Field("host"), b.Field("port")
+	if !host.IsRequired() || host.HelpText() != "The server's host name" || port.IsRequired() {
+		t.Fatal("Attributes")
+	}
+	if port.Default() != int64(8080) || b.Field("ratio").Default() != 1.0 {
+		t.Fatal("Defaults")
+	}
+	if p.Define("net").Bool("verbose").Section() != b.Section() {
+		t.Fatal("Define should reuse the section")
+	}
+
+	store, err := p.Parse(strings.NewReader("[net]\nhost = example.com\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host.StringVal(store) != "example.com" || port.Int64Val(store) != 8080 {
+		t.Fatal("Values")
+	}
+
+	_, err = p.Parse(strings.NewReader("[net]\nhost = x\nport = 0\n"))
+	if err == nil || !strings.Contains(err.Error(), "port") {
+		t.Fatal(err)
+	}
+	_, err = p.Parse(strings.NewReader("[net]\nhost = x\nratio = 1.5\n"))
+	if err == nil {
+		t.Fatal("Should fail")
+	}
+	_, err = p.Parse(strings.NewReader("[net]\nport = 1\n"))
+	if err == nil || err.Error() != "In section net: Missing required field host" {
+		t.Fatal(err)
+	}
+
+	// The environment satisfies a required field, and skipped sections are not checked
+	host.DefaultFromEnv("INI_TEST_HOST")
+	t.Setenv("INI_TEST_HOST", "env.example.com")
+	if store, err := p.Parse(strings.NewReader("")); err != nil ||
+		host.StringVal(store) != "env.example.com" {
+		t.Fatal(err)
+	}
+	p.AddSection("other")
+	if _, err := p.ParseSections(strings.NewReader("[net]\n"), "other"); err != nil {
+		t.Fatal(err)
+	}
+
+	expectPanic(t, "Default value of the wrong type for field flag", func() {
+		p.Define("x").Bool("flag").Default("yes")
+	})
+	w := p.Define("w").Int64("i").Default(1.0).Uint64("u").Default(int8(3)).Float64("f").Default(2)
+	if w.Field("i").Default() != int64(1) || w.Field("u").Default() != uint64(3) ||
+		w.Field("f").Default() != 2.0 {
+		t.Fatal("Converted defaults")
+	}
+	// Conversions that change the value are refused
+	for _, c := range []struct {
+		field *SectionBuilder
+		v     any
+	}{
+		{p.Define("w").Int64("i1"), 1.5},
+		{p.Define("w").Int64("i2"), uint64(1 << 63)},
+		{p.Define("w").Uint64("u1"), -1},
+		{p.Define("w").Uint64("u2"), 1e20},
+		{p.Define("w").Float64("f1"), int64(1<<53 + 1)},
+	} {
+		name := c.field.Field().Name()
+		expectPanic(t, "Default value of the wrong type for field "+name, func() {
+			c.field.Default(c.v)
+		})
+	}
+	expectPanic(t, "Range on non-numeric field name", func() {
+		p.Define("y").String("name").Range(0, 1)
+	})
+	expectPanic(t, "No current field in section z", func() { p.Define("z").Required() })
+}
+
+func TestValueLimits(t *testing.T) {
+	t.Setenv("INI_TEST_NAME", "toolong")
+	p := NewParser()
+	b := p.Define("s").String("name").MaxLen(4).DefaultFromEnv("INI_TEST_NAME").
+		StringList("tags").MaxLen(3).MaxItems(2)
+	b.Add(b.Section().AddInt64Map("counts")).MaxItems(1)
+	input := "[s]\nname = abcd\ntags = a, bcd\ncounts = a:1\n"
+	if _, err := p.Parse(strings.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct{ input, want string }{
+		{"[s]\nname = abcde\n",
+			"Line 2: In section s: Value too long for field name, the limit is 4 bytes"},
+		{"[s]\nname = a\ntags = a, bcde\n",
+			"Line 3: In section s: Element 2 too long for field tags, the limit is 3 bytes"},
+		{"[s]\nname = a\ntags = a, b, c\n",
+			"Line 3: In section s: Too many elements for field tags, the limit is 2"},
+		{"[s]\nname = a\ncounts = a:1, b:2\n",
+			"Line 3: In section s: Too many elements for field counts, the limit is 1"},
+		{"", "In section s: In environment variable INI_TEST_NAME: Value too long for field name, " +
+			"the limit is 4 bytes"},
+	} {
+		if _, err := p.Parse(strings.NewReader(tc.input)); err == nil || err.Error() != tc.want {
+			t.Fatalf("%q: %v", tc.input, err)
+		}
+	}
+
+	s := b.Section()
+	expectPanic(t, "MaxLen on non-string field counts", func() { s.Field("counts").MaxLen(1) })
+	expectPanic(t, "MaxItems on non-list field name", func() { s.Field("name").MaxItems(1) })
+	expectPanic(t, "Non-positive MaxLen for field name", func() { s.Field("name").MaxLen(0) })
+}
@@ -0,0 +1,59 @@
+package ini
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// A BytesEncoding is the text encoding of the values of a field added with [Section.AddBytes].
+type BytesEncoding int
+
+const (
+	Base64    BytesEncoding = iota + 1 // Base64 with the standard alphabet, padding optional
+	Base64URL                          // Base64 with the URL and file name alphabet, padding optional
+	Hex                                // Hexadecimal, in either case
+)
+
+// AddBytes adds a new field of the given name to the section whose values are binary data, such as
+// keys, salts and tokens, written in the encoding.  The name must not be present in the section and
+// must be syntactically valid (see package comments).  The values are decoded when they are parsed,
+// so that an invalid value is reported with its location in the input, and blanks are not allowed
+// within them.  Values are formatted with padding for base64 and in lower case for hex.  The empty
+// value is a nil []byte, which is also the default value.  The field has type TyUser.  Use
+// [ValueOf] with []byte to access the value.  The fields are also available as the registered
+// types "base64", "base64url" and "hex", see [Section.AddTyped].  AddBytes panics if the encoding
+// is not one of the BytesEncodings.
+func (section *Section) AddBytes(name string, encoding BytesEncoding) *Field {
+	var decode func(s string) ([]byte, error)
+	var encode func(b []byte) string
+	switch encoding {
+	case Base64, Base64URL:
+		raw, padded := base64.RawStdEncoding, base64.StdEncoding
+		if encoding == Base64URL {
+			raw, padded = base64.RawURLEncoding, base64.URLEncoding
+		}
+		decode = func(s string) ([]byte, error) {
+			if strings.HasSuffix(s, "=") {
+				return padded.DecodeString(s)
+			}
+			return raw.DecodeString(s)
+		}
+		encode = padded.EncodeToString
+	case Hex:
+		decode, encode = hex.DecodeString, hex.EncodeToString
+	default:
+		panic("Unknown encoding for field " + name)
+	}
+	field := section.Add(name, TyUser, []byte(nil), func(s string) (any, bool) {
+		if s == "" {
+			return []byte(nil), true
+		}
+		b, err := decode(s)
+		return b, err == nil
+	})
+	field.format = func(v any) string {
+		return encode(v.([]byte))
+	}
+	return field
+}
@@ -0,0 +1,57 @@
+package ini
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBytes(t *testing.T) {
+	p := NewParser()
+	s := p.AddSection("keys")
+	std := s.AddBytes("std", Base64)
+	url := s.AddBytes("url", Base64URL)
+	hex := s.AddTyped("hex", "hex")
+	salt := s.AddBytes("salt", Base64)
+	store, err := p.Parse(strings.NewReader("[keys]\nstd = +/8=\nurl = -_8\nhex = DEADbeef\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{0xFB, 0xFF}
+	if !bytes.Equal(ValueOf[[]byte](std, store), want) ||
+		!bytes.Equal(ValueOf[[]byte](url, store), want) ||
+		!bytes.Equal(ValueOf[[]byte](hex, store), []byte{0xDE, 0xAD, 0xBE, 0xEF}) ||
+		ValueOf[[]byte](salt, store) != nil {
+		t.Fatal(store.AllSettings(true))
+	}
+	var b strings.Builder
+	if err := store.Write(&b, nil); err != nil ||
+		b.String() != "[keys]\nstd = +/8=\nurl = -_8=\nhex = deadbeef\n" {
+		t.Fatal(err, b.String())
+	}
+
+	for _, input := range []string{
+		"std = -_8=", "url = +/8", "hex = abc", "std = +/8==", "hex = ab cd",
+	} {
+		_, err := p.Parse(strings.NewReader("[keys]\n" + input + "\n"))
+		name, value, _ := strings.Cut(input, " = ")
+		if err == nil || err.Error() !=
+			"Line 2: In section keys: Value '"+value+"' is not valid for field "+name {
+			t.Fatal(input, err)
+		}
+	}
+	// The default value can be written and read back
+	store, err = p.Parse(strings.NewReader(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Reset()
+	if err := store.Write(&b, &WriteOptions{Defaults: true}); err != nil {
+		t.Fatal(err)
+	}
+	if again, err := p.Parse(strings.NewReader(b.String())); err != nil ||
+		ValueOf[[]byte](hex, again) != nil {
+		t.Fatal(err, b.String())
+	}
+	expectPanic(t, "Unknown encoding for field x", func() { s.AddBytes("x", 0) })
+}
@@ -0,0 +1,54 @@
+package ini
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// Choice returns a valid function that accepts exactly the keys of choices, producing the values
+// they map to, eg for list elements:
+//
+//	AddListOf[int](section, "modes", Choice(map[string]int{"fast": 1, "safe": 2}))
+func Choice[T any](choices map[string]T) func(s string) (any, bool) {
+	return func(s string) (any, bool) {
+		v, found := choices[s]
+		return v, found
+	}
+}
+
+// AddChoice adds a new field of the given name to the section whose values are the keys of
+// choices, which are case-sensitive, and whose values in the store are the values the keys map to,
+// of type T.  The name must not be present in the section and must be syntactically valid (see
+// package comments).  Several keys can map to the same value, eg "warn" and "warning".  The field
+// has type TyUser and the default value is T's zero value, which can be changed with
+// [SectionBuilder.Add] and [SectionBuilder.Default].  Use [ValueOf] to access the value with its
+// type, and [Field.Choices] to list the accepted keys.
+//
+// Values are formatted as the first key, in sorted order, that maps to them.  AddChoice panics if
+// there are no choices.
+func AddChoice[T any](section *Section, name string, choices map[string]T) *Field {
+	if len(choices) == 0 {
+		panic("No choices for field " + name)
+	}
+	choices = maps.Clone(choices)
+	keys := slices.Sorted(maps.Keys(choices))
+	var zero T
+	field := section.Add(name, TyUser, zero, Choice(choices))
+	field.choices = keys
+	field.format = func(v any) string {
+		for _, k := range keys {
+			if reflect.DeepEqual(choices[k], v) {
+				return k
+			}
+		}
+		return renderValue(v)
+	}
+	return field
+}
+
+// Choices returns the accepted values of a field added with [AddChoice], in sorted order, or nil
+// for other fields.
+func (field *Field) Choices() []string {
+	return slices.Clone(field.choices)
+}
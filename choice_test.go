@@ -0,0 +1,57 @@
+package ini
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestChoice(t *testing.T) {
+	p := NewParser()
+	levels := map[string]slog.Level{
+		"debug": slog.LevelDebug, "info": slog.LevelInfo, "warn": slog.LevelWarn,
+		"warning": slog.LevelWarn, "error": slog.LevelError,
+	}
+	s := p.AddSection("log")
+	level := AddChoice(s, "level", levels)
+	modes := AddListOf[int](s, "modes", Choice(map[string]int{"fast": 1, "safe": 2}))
+	store, err := p.Parse(strings.NewReader("[log]\nlevel = warning\nmodes = safe, fast\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ValueOf[slog.Level](level, store) != slog.LevelWarn {
+		t.Fatal(level.Value(store))
+	}
+	if m := ValueOf[[]int](modes, store); !slices.Equal(m, []int{2, 1}) {
+		t.Fatal(m)
+	}
+	if c := level.Choices(); !slices.Equal(c, []string{"debug", "error", "info", "warn", "warning"}) {
+		t.Fatal(c)
+	}
+	if modes.Choices() != nil {
+		t.Fatal("Choices")
+	}
+	if level.FormatValue(slog.LevelWarn) != "warn" {
+		t.Fatal(level.FormatValue(slog.LevelWarn))
+	}
+	if _, err := p.Parse(strings.NewReader("[log]\nlevel = Debug\n")); err == nil {
+		t.Fatal("Keys are case-sensitive")
+	}
+
+	// The default can be set with the builder, and the map is copied
+	p = NewParser()
+	b := p.Define("log")
+	level = b.Add(AddChoice(b.Section(), "level", levels)).Default(slog.LevelError).Field()
+	delete(levels, "info")
+	store, err = p.Parse(strings.NewReader("[log]\nlevel = info\n"))
+	if err != nil || ValueOf[slog.Level](level, store) != slog.LevelInfo {
+		t.Fatal(err)
+	}
+	store, _ = p.Parse(strings.NewReader(""))
+	if ValueOf[slog.Level](level, store) != slog.LevelError {
+		t.Fatal("Default")
+	}
+	expectPanic(t, "Field level is not in section other", func() { p.Define("other").Add(level) })
+	expectPanic(t, "No choices for field x", func() { AddChoice(b.Section(), "x", map[string]int{}) })
+}
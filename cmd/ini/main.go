@@ -0,0 +1,247 @@
+// Command ini reads, edits, checks and formats ini files, for use in shell scripts.
+//
+// Usage:
+//
+//	ini get FILE [SECTION.]KEY
+//	ini set FILE [SECTION.]KEY VALUE
+//	ini del FILE [SECTION.]KEY
+//	ini validate [-schema SCHEMA] FILE...
+//	ini fmt [-w] [FILE...]
+//
+// Get prints the value of a setting and fails if there is no such setting.  Set changes or adds a
+// setting and del removes a setting, editing the file in place and preserving its comments and
+// layout; set fails if the value cannot be written so that it reads back the same, eg if it
+// contains a line break.  A key without a section names a setting before the first section
+// header.  Validate checks the syntax of the files, and with -schema also checks them against the
+// sections, fields and types of the JSON schema file, see ini.LoadSchema.  Fmt formats the files,
+// or standard input if there are none, as ini.Format does, writing the result to standard output
+// or, with -w, back to the files.
+//
+// The exit status is 0 on success, 1 on failure, and 2 for invalid arguments.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/lars-t-hansen/ini"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+const usage = `Usage:
+  ini get FILE [SECTION.]KEY
+  ini set FILE [SECTION.]KEY VALUE
+  ini del FILE [SECTION.]KEY
+  ini validate [-schema SCHEMA] FILE...
+  ini fmt [-w] [FILE...]
+`
+
+// run runs the command with the arguments and returns the exit status.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	cmd, args := args[0], args[1:]
+	nargs := map[string]int{"get": 2, "set": 3, "del": 2}
+	if n, found := nargs[cmd]; found && len(args) != n {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	var err error
+	switch cmd {
+	case "get":
+		var doc *ini.Document
+		if doc, err = readDocument(args[0]); err == nil {
+			section, key := splitPath(args[1])
+			if v, found := doc.Get(section, key); found {
+				fmt.Fprintln(stdout, v)
+			} else {
+				err = fmt.Errorf("No setting %s", args[1])
+			}
+		}
+	case "set", "del":
+		var doc *ini.Document
+		if doc, err = readDocument(args[0]); err == nil {
+			section, key := splitPath(args[1])
+			if cmd == "set" {
+				if !validPath(section, key) {
+					err = fmt.Errorf("Invalid name %s", args[1])
+					break
+				}
+				if err = doc.Set(section, key, args[2]); err != nil {
+					break
+				}
+			} else if !doc.Delete(section, key) {
+				err = fmt.Errorf("No setting %s", args[1])
+				break
+			}
+			err = writeFile(args[0], []byte(doc.String()))
+		}
+	case "validate":
+		return runValidate(args, stderr)
+	case "fmt":
+		return runFmt(args, stdin, stdout, stderr)
+	default:
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "ini: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func runValidate(args []string, stderr io.Writer) int {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	schema := flags.String("schema", "", "Check the files against the JSON schema in this file")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	var parser *ini.Parser
+	if *schema != "" {
+		var err error
+		if parser, err = loadSchema(*schema); err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", *schema, err)
+			return 1
+		}
+	}
+	status := 0
+	for _, name := range flags.Args() {
+		var err error
+		if parser != nil {
+			_, err = parser.ParseFile(name)
+		} else {
+			_, err = readDocument(name)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", name, err)
+			status = 1
+		}
+	}
+	return status
+}
+
+func loadSchema(name string) (*ini.Parser, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ini.LoadSchema(f)
+}
+
+func runFmt(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	write := flags.Bool("w", false, "Write the result to the files instead of standard output")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(stderr, "ini: -w requires files")
+			return 2
+		}
+		if err := ini.Format(stdin, stdout, nil); err != nil {
+			fmt.Fprintf(stderr, "ini: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	status := 0
+	for _, name := range flags.Args() {
+		if err := formatFile(name, *write, stdout); err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", name, err)
+			status = 1
+		}
+	}
+	return status
+}
+
+func formatFile(name string, write bool, stdout io.Writer) error {
+	input, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	if err := ini.Format(bytes.NewReader(input), &out, nil); err != nil {
+		return err
+	}
+	if !write {
+		_, err := stdout.Write(out.Bytes())
+		return err
+	}
+	if bytes.Equal(input, out.Bytes()) {
+		return nil
+	}
+	return writeFile(name, out.Bytes())
+}
+
+func readDocument(name string) (*ini.Document, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ini.NewParser().ParseDocument(f)
+}
+
+// writeFile replaces the contents of the named file, atomically where the platform allows, and
+// keeps its permissions.
+func writeFile(name string, data []byte) error {
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// splitPath splits `section.key` into its parts, or `key` into "" and key.
+func splitPath(path string) (section, key string) {
+	if section, key, found := strings.Cut(path, "."); found {
+		return section, key
+	}
+	return "", path
+}
+
+var (
+	nameRe    = regexp.MustCompile(`^[-a-zA-Z0-9_$]+$`)
+	sectionRe = regexp.MustCompile(`^[-a-zA-Z0-9_$]+(:[-a-zA-Z0-9_$]+)?$`)
+)
+
+// validPath returns true if the section and key are valid names for Document.Set.
+func validPath(section, key string) bool {
+	return (section == "" || sectionRe.MatchString(section)) && nameRe.MatchString(key)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.ini")
+	if err := os.WriteFile(name, []byte("top=1\n# db\n[db]\nhost = \"h\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cli := func(stdin string, args ...string) (int, string, string) {
+		var stdout, stderr strings.Builder
+		status := run(args, strings.NewReader(stdin), &stdout, &stderr)
+		return status, stdout.String(), stderr.String()
+	}
+
+	if status, out, _ := cli("", "get", name, "db.host"); status != 0 || out != "h\n" {
+		t.Fatal(status, out)
+	}
+	if status, out, _ := cli("", "get", name, "top"); status != 0 || out != "1\n" {
+		t.Fatal(status, out)
+	}
+	if status, _, errs := cli("", "get", name, "db.port"); status != 1 ||
+		errs != "ini: No setting db.port\n" {
+		t.Fatal(status, errs)
+	}
+	if status, _, _ := cli("", "set", name, "db.port", "5432"); status != 0 {
+		t.Fatal(status)
+	}
+	if status, _, _ := cli("", "set", name, "db.a b", "x"); status != 1 {
+		t.Fatal(status)
+	}
+	if status, _, errs := cli("", "set", name, "db.a", "x\n[evil]\ny = 2"); status != 1 ||
+		errs != "ini: The value of db.a cannot be represented\n" {
+		t.Fatal(status, errs)
+	}
+	if status, _, _ := cli("", "del", name, "top"); status != 0 {
+		t.Fatal(status)
+	}
+	if status, _, _ := cli("", "del", name, "top"); status != 1 {
+		t.Fatal(status)
+	}
+	data, err := os.ReadFile(name)
+	if err != nil || string(data) != "# db\n[db]\nhost = \"h\"\nport = 5432\n" {
+		t.Fatalf("%q", data)
+	}
+	if info, _ := os.Stat(name); info.Mode().Perm() != 0o600 {
+		t.Fatal(info.Mode())
+	}
+
+	bad := filepath.Join(dir, "bad.ini")
+	os.WriteFile(bad, []byte("[db]\n?\n"), 0o644)
+	if status, _, _ := cli("", "validate", name); status != 0 {
+		t.Fatal(status)
+	}
+	if status, _, errs := cli("", "validate", name, bad); status != 1 ||
+		errs != bad+": Line 2: Invalid syntax\n" {
+		t.Fatal(status, errs)
+	}
+
+	if status, out, _ := cli("[a]\nx=1\n", "fmt"); status != 0 || out != "[a]\nx = 1\n" {
+		t.Fatal(status, out)
+	}
+	messy := filepath.Join(dir, "messy.ini")
+	os.WriteFile(messy, []byte("  [a]\nx=1\n"), 0o644)
+	if status, out, _ := cli("", "fmt", "-w", messy); status != 0 || out != "" {
+		t.Fatal(status, out)
+	}
+	if data, _ := os.ReadFile(messy); string(data) != "[a]\nx = 1\n" {
+		t.Fatalf("%q", data)
+	}
+
+	schema := filepath.Join(dir, "schema.json")
+	os.WriteFile(schema, []byte(`{"sections": [{"name": "db", "fields": [
+		{"name": "host", "type": "string"}, {"name": "port", "type": "int64", "max": 65535}]}]}`),
+		0o644)
+	if status, _, errs := cli("", "validate", "-schema", schema, name); status != 0 {
+		t.Fatal(status, errs)
+	}
+	os.WriteFile(bad, []byte("[db]\nport = 70000\n"), 0o644)
+	if status, _, errs := cli("", "validate", "--schema", schema, bad); status != 1 ||
+		errs != bad+": Line 2: In section db: Value '70000' is not valid for field port\n" {
+		t.Fatal(status, errs)
+	}
+	if status, _, _ := cli("", "validate", "-schema", bad, name); status != 1 {
+		t.Fatal(status)
+	}
+
+	for _, args := range [][]string{{}, {"get", name}, {"frob"}, {"validate"}, {"fmt", "-w"},
+		{"validate", "-schema", schema}} {
+		if status, _, _ := cli("", args...); status != 2 {
+			t.Fatal(args, status)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Command inigen generates Go code that declares an ini schema from a sample ini file.
+//
+// Usage:
+//
+//	inigen [-package name] [-type name] [-func name] [-o output.go] [sample.ini]
+//
+// The sample is read from standard input if no file is given, and the code is written to standard
+// output if -o is not given.  See package github.com/lars-t-hansen/ini/inigen for the details.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/lars-t-hansen/ini/inigen"
+)
+
+func main() {
+	var opts inigen.Options
+	flag.StringVar(&opts.Package, "package", "config", "The package name of the generated code")
+	flag.StringVar(&opts.TypeName, "type", "Config", "The name of the generated struct type")
+	flag.StringVar(&opts.FuncName, "func", "NewParser", "The name of the generated parser function")
+	output := flag.String("o", "", "The output file (default standard output)")
+	flag.Parse()
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var in io.Reader = os.Stdin
+	if flag.NArg() == 1 {
+		f, err := os.Open(flag.Arg(0))
+		if err != nil {
+			fail(err)
+		}
+		defer f.Close()
+		in = f
+	}
+	code, err := inigen.Generate(in, nil, opts)
+	if err != nil {
+		fail(err)
+	}
+	if *output == "" {
+		_, err = os.Stdout.Write(code)
+	} else {
+		err = os.WriteFile(*output, code, 0o644)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "inigen:", err)
+	os.Exit(1)
+}
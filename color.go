@@ -0,0 +1,76 @@
+package ini
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// AddColor adds a new field of the given name to the section whose values are colors, eg for the
+// themes of user interfaces.  The name must not be present in the section and must be
+// syntactically valid (see package comments).  ParseColor describes the accepted values.  The
+// field has type TyUser and the default value is the zero color.NRGBA, which is transparent.  Use
+// [ValueOf] with color.NRGBA to access the value.  The field is also available as the registered
+// type "color", see [Section.AddTyped].
+func (section *Section) AddColor(name string) *Field {
+	field := section.Add(name, TyUser, color.NRGBA{}, ParseColor)
+	field.format = formatColor
+	return field
+}
+
+// ParseColor accepts a color written as `#RRGGBB` or `#RRGGBBAA`, where RR, GG, BB and AA are the
+// hexadecimal red, green, blue and alpha components, or as the name of one of the basic colors of
+// CSS, eg `navy`, or `transparent`, ignoring case, returning the color as a color.NRGBA and a
+// validity flag.  The alpha of colors without one is 0xFF, opaque.
+func ParseColor(s string) (any, bool) {
+	if c, found := namedColors[strings.ToLower(s)]; found {
+		return c, true
+	}
+	if len(s) != 7 && len(s) != 9 || s[0] != '#' {
+		return color.NRGBA{}, false
+	}
+	var rgba [4]uint8
+	rgba[3] = 0xFF
+	for i := 1; i < len(s); i += 2 {
+		v, err := strconv.ParseUint(s[i:i+2], 16, 8)
+		if err != nil {
+			return color.NRGBA{}, false
+		}
+		rgba[i/2] = uint8(v)
+	}
+	return color.NRGBA{R: rgba[0], G: rgba[1], B: rgba[2], A: rgba[3]}, true
+}
+
+func formatColor(v any) string {
+	c := v.(color.NRGBA)
+	if c.A == 0xFF {
+		return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+}
+
+// namedColors holds the basic colors of CSS, which include the eight colors of ANSI terminals.
+var namedColors = map[string]color.NRGBA{
+	"transparent": {},
+	"black":       {0x00, 0x00, 0x00, 0xFF},
+	"silver":      {0xC0, 0xC0, 0xC0, 0xFF},
+	"gray":        {0x80, 0x80, 0x80, 0xFF},
+	"grey":        {0x80, 0x80, 0x80, 0xFF},
+	"white":       {0xFF, 0xFF, 0xFF, 0xFF},
+	"maroon":      {0x80, 0x00, 0x00, 0xFF},
+	"red":         {0xFF, 0x00, 0x00, 0xFF},
+	"purple":      {0x80, 0x00, 0x80, 0xFF},
+	"fuchsia":     {0xFF, 0x00, 0xFF, 0xFF},
+	"magenta":     {0xFF, 0x00, 0xFF, 0xFF},
+	"green":       {0x00, 0x80, 0x00, 0xFF},
+	"lime":        {0x00, 0xFF, 0x00, 0xFF},
+	"olive":       {0x80, 0x80, 0x00, 0xFF},
+	"yellow":      {0xFF, 0xFF, 0x00, 0xFF},
+	"navy":        {0x00, 0x00, 0x80, 0xFF},
+	"blue":        {0x00, 0x00, 0xFF, 0xFF},
+	"teal":        {0x00, 0x80, 0x80, 0xFF},
+	"aqua":        {0x00, 0xFF, 0xFF, 0xFF},
+	"cyan":        {0x00, 0xFF, 0xFF, 0xFF},
+	"orange":      {0xFF, 0xA5, 0x00, 0xFF},
+}
@@ -0,0 +1,50 @@
+package ini
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestColor(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		want  color.NRGBA
+		ok    bool
+	}{
+		{"#FF8000", color.NRGBA{0xFF, 0x80, 0x00, 0xFF}, true},
+		{"#ff800080", color.NRGBA{0xFF, 0x80, 0x00, 0x80}, true},
+		{"Navy", color.NRGBA{0x00, 0x00, 0x80, 0xFF}, true},
+		{"transparent", color.NRGBA{}, true},
+		{"#FF80", color.NRGBA{}, false},
+		{"#GG8000", color.NRGBA{}, false},
+		{"FF8000", color.NRGBA{}, false},
+		{"#+f8000", color.NRGBA{}, false},
+		{"chartreuse", color.NRGBA{}, false},
+	} {
+		v, ok := ParseColor(tc.input)
+		if ok != tc.ok || ok && v != tc.want {
+			t.Fatal(tc.input, v, ok)
+		}
+	}
+
+	p := NewParser()
+	theme := p.AddSection("theme")
+	fg := theme.AddColor("fg")
+	bg := theme.AddTyped("bg", "color")
+	store, err := p.Parse(strings.NewReader("[theme]\nfg = #102030\nbg = #10203040\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ValueOf[color.NRGBA](fg, store) != (color.NRGBA{0x10, 0x20, 0x30, 0xFF}) ||
+		ValueOf[color.NRGBA](bg, store) != (color.NRGBA{0x10, 0x20, 0x30, 0x40}) {
+		t.Fatal(fg.Value(store), bg.Value(store))
+	}
+	var b strings.Builder
+	if err := store.Write(&b, nil); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "[theme]\nfg = #102030\nbg = #10203040\n" {
+		t.Fatal(b.String())
+	}
+}
@@ -0,0 +1,101 @@
+package ini
+
+import (
+	"strings"
+)
+
+// The combinators in this file build valid functions for [Section.Add] from other valid functions,
+// such as ParseInt64 or the result of another combinator, so that compound types can be defined
+// without writing parsing code.  The element delimiters are fixed and elements cannot be quoted;
+// use list fields such as [Section.AddStringList] for lists that follow the parser's ListDelim and
+// quoting rules.
+
+// ListOf returns a valid function that accepts comma-separated lists of values accepted by elem,
+// which must produce values of type T or nil (as for Optional, taken to be T's zero value), and
+// produces a []T.  Blanks around elements are stripped.
+// The empty (or blank) string is the empty list.
+func ListOf[T any](elem func(s string) (any, bool)) func(s string) (any, bool) {
+	return func(s string) (any, bool) {
+		result := []T{}
+		if strings.TrimSpace(s) == "" {
+			return result, true
+		}
+		for _, e := range strings.Split(s, ",") {
+			v, ok := elem(strings.TrimSpace(e))
+			if !ok {
+				return nil, false
+			}
+			t, ok := v.(T)
+			if !ok && v != nil {
+				return nil, false
+			}
+			result = append(result, t)
+		}
+		return result, true
+	}
+}
+
+// MapOf returns a valid function that accepts comma-separated lists of `key:value` pairs, where the
+// keys are accepted by key and produce values of type K and the values are accepted by val and
+// produce values of type V or nil, and produces a map[K]V.  Blanks around keys and values are
+// stripped.  A key can't contain `:` and may not appear more than once.  The empty (or blank)
+// string is the empty map.
+func MapOf[K comparable, V any](key, val func(s string) (any, bool)) func(s string) (any, bool) {
+	return func(s string) (any, bool) {
+		result := make(map[K]V)
+		if strings.TrimSpace(s) == "" {
+			return result, true
+		}
+		for _, e := range strings.Split(s, ",") {
+			ks, vs, found := strings.Cut(e, ":")
+			if !found {
+				return nil, false
+			}
+			kv, ok := key(strings.TrimSpace(ks))
+			if !ok {
+				return nil, false
+			}
+			k, ok := kv.(K)
+			if !ok {
+				return nil, false
+			}
+			if _, dup := result[k]; dup {
+				return nil, false
+			}
+			vv, ok := val(strings.TrimSpace(vs))
+			if !ok {
+				return nil, false
+			}
+			v, ok := vv.(V)
+			if !ok && vv != nil {
+				return nil, false
+			}
+			result[k] = v
+		}
+		return result, true
+	}
+}
+
+// Optional returns a valid function that accepts the empty string, producing nil, and any string
+// accepted by valid, producing valid's value.
+func Optional(valid func(s string) (any, bool)) func(s string) (any, bool) {
+	return func(s string) (any, bool) {
+		if s == "" {
+			return nil, true
+		}
+		return valid(s)
+	}
+}
+
+// OneOf returns a valid function that accepts any string accepted by one of the valids, producing
+// the value of the first of them that accepts it.
+func OneOf(valids ...func(s string) (any, bool)) func(s string) (any, bool) {
+	return func(s string) (any, bool) {
+		for _, valid := range valids {
+			if v, ok := valid(s); ok {
+				return v, true
+			}
+		}
+		return nil, false
+	}
+}
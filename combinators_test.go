@@ -0,0 +1,66 @@
+package ini
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCombinators(t *testing.T) {
+	p := NewParser()
+	s := p.AddSection("sect")
+	ports := s.Add("ports", TyUser, []int64(nil), ListOf[int64](ParseInt64))
+	limits := s.Add("limits", TyUser, map[string]float64(nil),
+		MapOf[string, float64](ParseString, ParseFloat64))
+	timeout := s.Add("timeout", TyUser, nil, Optional(ParseInt64))
+	mode := s.Add("mode", TyUser, nil, OneOf(ParseBool, ParseInt64))
+	nested := s.Add("nested", TyUser, nil, ListOf[any](Optional(ParseInt64)))
+	store, err := p.Parse(strings.NewReader(`
+[sect]
+ports = 80, 443 ,8080
+limits = cpu: 1.5, mem:2
+timeout =
+mode = 3
+nested = 1,,2
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x := ValueOf[[]int64](ports, store); !slices.Equal(x, []int64{80, 443, 8080}) {
+		t.Fatal(x)
+	}
+	if x := ValueOf[map[string]float64](limits, store); !maps.Equal(
+		x, map[string]float64{"cpu": 1.5, "mem": 2}) {
+		t.Fatal(x)
+	}
+	if x := timeout.Value(store); x != nil {
+		t.Fatal(x)
+	}
+	if x := mode.Value(store); x != int64(3) {
+		t.Fatal(x)
+	}
+	if x := ValueOf[[]any](nested, store); !slices.Equal(x, []any{int64(1), nil, int64(2)}) {
+		t.Fatal(x)
+	}
+
+	for _, input := range []string{
+		"ports = 80, x",
+		"ports = 80,",
+		"limits = cpu",
+		"limits = cpu:1, cpu:2",
+		"limits = cpu:x",
+		"timeout = x",
+		"mode = x",
+	} {
+		if _, err := p.Parse(strings.NewReader("[sect]\n" + input)); err == nil {
+			t.Fatal("Should fail", input)
+		}
+	}
+
+	// The element type must match
+	s.Add("wrong", TyUser, nil, ListOf[string](ParseInt64))
+	if _, err := p.Parse(strings.NewReader("[sect]\nwrong = 1")); err == nil {
+		t.Fatal("Should fail")
+	}
+}
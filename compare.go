@@ -0,0 +1,31 @@
+package ini
+
+import (
+	"reflect"
+)
+
+// Equal returns true if the store and other were produced by the same parser and every field has
+// the same presence and the same value in both, as compared by reflect.DeepEqual.
+func (store *Store) Equal(other *Store) bool {
+	if store.parser != other.parser {
+		return false
+	}
+	for _, section := range store.parser.order {
+		if store.lookupSect(section) != other.lookupSect(section) {
+			return false
+		}
+		for _, field := range section.order {
+			if field.Present(store) != field.Present(other) || field.Changed(store, other) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Changed returns true if the field's value, or its default value if it was not present, differs
+// between the stores, as compared by reflect.DeepEqual.  Use it in reload logic to decide whether
+// resources that depend on the field must be recreated.
+func (field *Field) Changed(old, new *Store) bool {
+	return !reflect.DeepEqual(field.Value(old), field.Value(new))
+}
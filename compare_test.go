@@ -0,0 +1,51 @@
+package ini
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	p := NewParser()
+	s := p.AddSection("sect")
+	a := s.AddInt64("a")
+	b := s.AddStringList("b")
+	p.AddSection("other")
+	parse := func(input string) *Store {
+		store, err := p.Parse(strings.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return store
+	}
+	base := parse("[sect]\na = 1\nb = x, y\n")
+	if !base.Equal(parse("[sect]\nb = x,y\na=1\n")) {
+		t.Fatal("Equal")
+	}
+	for _, input := range []string{
+		"[sect]\na = 2\nb = x, y\n",
+		"[sect]\na = 1\nb = x\n",
+		"[sect]\na = 1\nb = x, y\n[other]\n",
+	} {
+		if base.Equal(parse(input)) {
+			t.Fatal("Not equal", input)
+		}
+	}
+
+	// Presence matters for Equal but not for Changed
+	explicit := parse("[sect]\na = 0\n")
+	implicit := parse("[sect]\n")
+	if explicit.Equal(implicit) || a.Changed(explicit, implicit) {
+		t.Fatal("Presence")
+	}
+	if !a.Changed(base, implicit) || !b.Changed(base, implicit) {
+		t.Fatal("Changed")
+	}
+
+	q := NewParser()
+	q.AddSection("sect")
+	other, _ := q.Parse(strings.NewReader(""))
+	if implicit.Equal(other) {
+		t.Fatal("Different parsers")
+	}
+}
@@ -0,0 +1,93 @@
+package ini
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// These tests are mainly useful with the race detector: go test -race
+
+func TestConcurrentParse(t *testing.T) {
+	p := NewParser()
+	s := p.AddSection("s")
+	n := s.AddInt64("n")
+	tags := s.AddStringList("tags")
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 50 {
+				input := fmt.Sprintf("[s]\nn = %d\ntags = a, b\n", i*100+j)
+				store, err := p.Parse(strings.NewReader(input))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if n.Int64Val(store) != int64(i*100+j) || len(ValueOf[[]string](tags, store)) != 2 {
+					t.Error("Values")
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestConcurrentReads(t *testing.T) {
+	p := NewParser()
+	s := p.AddSection("s")
+	tags := s.AddStringList("tags")
+	s.AddString("name")
+	store, err := p.Parse(strings.NewReader("[s]\ntags = a, b, c\nname = x\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				// Modifying a returned value does not change the store
+				v := ValueOf[[]string](tags, store)
+				v[0] = "changed"
+				_ = store.GetString("s.name")
+				_ = store.WithDefaults()
+				_ = store.RedactedMap()
+				var b strings.Builder
+				if err := store.Write(&b, nil); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if v := ValueOf[[]string](tags, store); !slices.Equal(v, []string{"a", "b", "c"}) {
+		t.Fatal(v)
+	}
+}
+
+func TestValuesAreCopies(t *testing.T) {
+	p := NewParser()
+	s := p.AddSection("s")
+	m := s.Add("m", TyUser, map[string]int{"a": 1}, func(s string) (any, bool) {
+		return map[string]int{s: 2}, true
+	})
+	store, err := p.Parse(strings.NewReader(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ValueOf[map[string]int](m, store)["b"] = 3
+	if len(ValueOf[map[string]int](m, store)) != 1 || len(m.Default().(map[string]int)) != 1 {
+		t.Fatal("Default value was modified")
+	}
+	m.Default().(map[string]int)["c"] = 4
+	if len(ValueOf[map[string]int](m, store)) != 1 || len(m.Default().(map[string]int)) != 1 {
+		t.Fatal("Default value was modified")
+	}
+}
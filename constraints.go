@@ -0,0 +1,118 @@
+package ini
+
+import (
+	"reflect"
+)
+
+// A constraintKind selects the relation that a constraint expresses.
+type constraintKind int
+
+const (
+	exclusiveConstraint  constraintKind = iota // a and b must not both be set
+	requiresConstraint                         // b must be set if a is
+	requiredIfConstraint                       // a must be set if b has the value
+)
+
+// A constraint is a relation between two fields that is checked at the end of a parse, see
+// MutuallyExclusive, Requires, and Field.RequiredIf.
+type constraint struct {
+	kind  constraintKind
+	a, b  *Field
+	value any // For requiredIfConstraint, the value of b
+}
+
+// MutuallyExclusive declares that at most one of the fields can be set: it is a parse error if both
+// are present in the input or have values from their [Field.DefaultFromEnv] variables.  The fields
+// must be distinct fields of the same parser, and are not checked if either is in a section that is
+// skipped by [Parser.ParseSections].
+func MutuallyExclusive(f1, f2 *Field) {
+	addConstraint(constraint{kind: exclusiveConstraint, a: f1, b: f2})
+}
+
+// Requires declares that f1 depends on f2: it is a parse error if f1 is set, as for
+// MutuallyExclusive, and f2 is not.  The fields must be distinct fields of the same parser, and are
+// not checked if either is in a section that is skipped by [Parser.ParseSections].
+func Requires(f1, f2 *Field) {
+	addConstraint(constraint{kind: requiresConstraint, a: f1, b: f2})
+}
+
+// RequiredIf marks the field as required when the value of other, which may be its default value,
+// is value, as compared by reflect.DeepEqual: it is then a parse error if the field is not present
+// in the input and its [Field.DefaultFromEnv] variable, if any, is not set.  The value must have
+// the type of other's values, except that a number is converted to other's numeric type.  The
+// fields must be distinct fields of the same parser, and are not checked if either is in a section
+// that is skipped by [Parser.ParseSections].  Returns the field.
+func (field *Field) RequiredIf(other *Field, value any) *Field {
+	v, ok := other.convert(value)
+	if !ok {
+		panic("RequiredIf value of the wrong type for field " + other.name)
+	}
+	addConstraint(constraint{kind: requiredIfConstraint, a: field, b: other, value: v})
+	return field
+}
+
+func addConstraint(c constraint) {
+	parser := c.a.section.parser
+	if c.b.section.parser != parser {
+		panic("Fields " + c.a.name + " and " + c.b.name + " are from different parsers")
+	}
+	if c.a == c.b {
+		panic("Constraint relates field " + c.a.name + " to itself")
+	}
+	parser.constraints = append(parser.constraints, c)
+}
+
+// isSet returns true if the field is present in the input or has a value from the environment.
+func (field *Field) isSet(store *Store) bool {
+	return field.Present(store) || store.origins[field].Kind == OriginEnv
+}
+
+// checkConstraints passes an error for every violated constraint, in declaration order, to fail,
+// considering only the sections in only if only is not nil, and returns the first error that fail
+// returns.
+func (parser *Parser) checkConstraints(
+	store *Store,
+	only map[string]bool,
+	fail func(error) error,
+) error {
+	for _, c := range parser.constraints {
+		if only != nil && (!only[c.a.section.name] || !only[c.b.section.name]) {
+			continue
+		}
+		if err := c.check(store); err != nil {
+			if err := fail(err); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// check returns an error if the constraint does not hold in the store, otherwise nil.
+func (c constraint) check(store *Store) error {
+	a, b := c.a, c.b
+	switch c.kind {
+	case exclusiveConstraint:
+		if a.isSet(store) && b.isSet(store) {
+			return parseFail(0, "", "Field %s (%s) and field %s (%s) are mutually exclusive",
+				a.qualifiedName(), a.Origin(store), b.qualifiedName(), b.Origin(store))
+		}
+	case requiresConstraint:
+		if a.isSet(store) && !b.isSet(store) {
+			return parseFail(0, "", "Field %s (%s) requires field %s, which is not set",
+				a.qualifiedName(), a.Origin(store), b.qualifiedName())
+		}
+	case requiredIfConstraint:
+		if !a.isSet(store) && reflect.DeepEqual(b.Value(store), c.value) {
+			return parseFail(0, a.section.name,
+				"Missing field %s, which is required when field %s is %s (%s)",
+				a.name, b.qualifiedName(), b.redact(b.FormatValue(c.value)), b.Origin(store))
+		}
+	}
+	return nil
+}
+
+// qualifiedName returns the field's name qualified by its section's, as `section.field`.
+func (field *Field) qualifiedName() string {
+	return field.section.name + "." + field.name
+}
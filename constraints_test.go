@@ -0,0 +1,101 @@
+package ini
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConstraints(t *testing.T) {
+	t.Setenv("INI_TEST_KEY", "k")
+	p := NewParser()
+	auth := p.AddSection("auth")
+	password, keyFile := auth.AddString("password"), auth.AddString("key-file")
+	user := auth.AddString("user")
+	token := auth.AddString("token").DefaultFromEnv("INI_TEST_KEY")
+	tls := p.AddSection("tls")
+	cert := tls.AddString("cert")
+	MutuallyExclusive(password, keyFile)
+	Requires(password, user)
+	Requires(cert, keyFile)
+
+	for _, tc := range []struct {
+		input string
+		want  string
+	}{
+		{"[auth]\nuser = u\npassword = p\n", ""},
+		{"[auth]\nkey-file = k\n[tls]\ncert = c\n", ""},
+		{"[auth]\nuser = u\npassword = p\n\nkey-file = k\n",
+			"Field auth.password (line 3) and field auth.key-file (line 5) are mutually exclusive"},
+		{"[auth]\npassword = p\n",
+			"Field auth.password (line 2) requires field auth.user, which is not set"},
+		{"[tls]\ncert = c\n", "Field tls.cert (line 2) requires field auth.key-file, which is not set"},
+	} {
+		_, err := p.Parse(strings.NewReader(tc.input))
+		if (err == nil) != (tc.want == "") || err != nil && err.Error() != tc.want {
+			t.Fatalf("%q: %v", tc.input, err)
+		}
+	}
+
+	// Values from the environment count as set, and skipped sections are not checked
+	MutuallyExclusive(token, password)
+	_, err := p.Parse(strings.NewReader("[auth]\nuser = u\npassword = p\n"))
+	want := "Field auth.token (env INI_TEST_KEY) and field auth.password (line 3) " +
+		"are mutually exclusive"
+	if err == nil || err.Error() != want {
+		t.Fatal(err)
+	}
+	if _, err := p.ParseSections(strings.NewReader("[tls]\ncert = c\n"), "tls"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Preview reports all the violations
+	store, err := p.Parse(strings.NewReader("[auth]\nkey-file = k\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, errs := Preview(p, store, strings.NewReader("[auth]\npassword = p\nkey-file = k\n"))
+	if len(errs) != 3 {
+		t.Fatal(errs)
+	}
+
+	other := NewParser().AddSection("s").AddString("x")
+	expectPanic(t, "Fields user and x are from different parsers", func() { Requires(user, other) })
+	expectPanic(t, "Constraint relates field user to itself", func() { MutuallyExclusive(user, user) })
+}
+
+func TestRequiredIf(t *testing.T) {
+	p := NewParser()
+	b := p.Define("tls").Bool("enabled").Int64("port").Default(443)
+	enabled, port := b.Field("enabled"), b.Field("port")
+	b.String("cert").RequiredIf(enabled, true).String("ca").RequiredIf(port, 8443)
+	for _, tc := range []struct {
+		input string
+		want  string
+	}{
+		{"[tls]\n", ""},
+		{"[tls]\nenabled = true\ncert = c\n", ""},
+		{"[tls]\nenabled = true\n",
+			"In section tls: Missing field cert, which is required when field tls.enabled is true " +
+				"(line 2)"},
+		{"[tls]\nport = 8443\nca = x\n", ""},
+		{"[tls]\nport = 8443\n",
+			"In section tls: Missing field ca, which is required when field tls.port is 8443 (line 2)"},
+	} {
+		_, err := p.Parse(strings.NewReader(tc.input))
+		if (err == nil) != (tc.want == "") || err != nil && err.Error() != tc.want {
+			t.Fatalf("%q: %v", tc.input, err)
+		}
+	}
+
+	// The condition can hold for a default value
+	p.Section("tls").AddString("key").RequiredIf(port, 443)
+	_, err := p.Parse(strings.NewReader("[tls]\n"))
+	want := "In section tls: Missing field key, which is required when field tls.port is 443 (default)"
+	if err == nil || err.Error() != want {
+		t.Fatal(err)
+	}
+
+	expectPanic(t, "RequiredIf value of the wrong type for field enabled", func() {
+		p.Section("tls").AddString("x").RequiredIf(enabled, "yes")
+	})
+}
@@ -0,0 +1,32 @@
+package ini
+
+// A Coverage reports which of the parser's sections and fields were present in the input for a
+// store, to help find parts of a schema that no configuration uses any more, or configurations
+// that are incomplete.  All lists are in declaration order.
+type Coverage struct {
+	PresentSections []*Section // The sections with a header in the input
+	AbsentSections  []*Section // The sections without a header in the input
+	PresentFields   []*Field   // The fields with a setting in the input
+	AbsentFields    []*Field   // The fields without a setting in the input
+}
+
+// Coverage returns the coverage of the parser's schema by the input that produced the store.  A
+// field whose value comes from the environment (see [Field.DefaultFromEnv]) is absent.
+func (store *Store) Coverage() Coverage {
+	var c Coverage
+	for _, section := range store.parser.order {
+		if section.Present(store) {
+			c.PresentSections = append(c.PresentSections, section)
+		} else {
+			c.AbsentSections = append(c.AbsentSections, section)
+		}
+		for _, field := range section.order {
+			if field.Present(store) {
+				c.PresentFields = append(c.PresentFields, field)
+			} else {
+				c.AbsentFields = append(c.AbsentFields, field)
+			}
+		}
+	}
+	return c
+}
@@ -0,0 +1,40 @@
+package ini
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCoverage(t *testing.T) {
+	p := NewParser()
+	db := p.AddSection("db").Required()
+	user, port := db.AddString("user"), db.AddInt64("port")
+	log := p.AddSection("log")
+	verbose := log.AddBool("verbose")
+	store, err := p.Parse(strings.NewReader("[db]\nuser = joe\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := store.Coverage()
+	if !slices.Equal(c.PresentSections, []*Section{db}) ||
+		!slices.Equal(c.AbsentSections, []*Section{log}) ||
+		!slices.Equal(c.PresentFields, []*Field{user}) ||
+		!slices.Equal(c.AbsentFields, []*Field{port, verbose}) {
+		t.Fatalf("%+v", c)
+	}
+
+	if !db.IsRequired() || log.IsRequired() {
+		t.Fatal("IsRequired")
+	}
+	if _, err := p.Parse(strings.NewReader("[db]\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Parse(strings.NewReader("[log]\n")); err == nil ||
+		err.Error() != "Missing required section db" {
+		t.Fatal(err)
+	}
+	if _, err := p.ParseSections(strings.NewReader("[log]\n"), "log"); err != nil {
+		t.Fatal(err)
+	}
+}
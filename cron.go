@@ -0,0 +1,125 @@
+package ini
+
+import (
+	"strconv"
+	"strings"
+)
+
+// AddCron adds a new string field of the given name to the section for a cron expression, the
+// schedule of a recurring job.  The name must not be present in the section and must be
+// syntactically valid (see package comments).  The value is the expression normalized by
+// ParseCron, or if the parser's CronValidator is not nil, the expression with its blanks
+// normalized, if the validator accepts it.  The empty value, for no schedule, is accepted in
+// either case and is also the default value.  The field is also available as the registered type
+// "cron", see [Section.AddTyped].
+func (section *Section) AddCron(name string) *Field {
+	return section.Add(name, TyString, "", func(s string) (any, bool) {
+		validate := section.parser.CronValidator
+		if validate == nil {
+			return ParseCron(s)
+		}
+		s = strings.Join(strings.Fields(s), " ")
+		return s, s == "" || validate(s) == nil
+	})
+}
+
+// ParseCron accepts a standard cron expression, returning it normalized and a validity flag.  The
+// expression has five fields, minute (0-59), hour (0-23), day of month (1-31), month (1-12 or
+// jan-dec) and day of week (0-7, where 0 and 7 are Sunday, or sun-sat), or six fields with the
+// second (0-59) first.  Each field is `*` or a comma-separated list of values and ranges like
+// `1-5`, and `*` and ranges can be followed by a step like `/15`, as can a single value, which then
+// starts a range to the end.  The names are case-insensitive.  The expression can also be one of
+// the macros @yearly, @annually, @monthly, @weekly, @daily, @midnight and @hourly.
+//
+// The normalized expression has its fields separated by single spaces and its names in lower case,
+// and macros are replaced by the equivalent five fields, eg `0 0 * * *` for @daily.  The empty
+// (or blank) string is accepted too, for no schedule, and normalized to the empty string.
+func ParseCron(s string) (any, bool) {
+	fields := strings.Fields(strings.ToLower(s))
+	if len(fields) == 0 {
+		return "", true
+	}
+	if len(fields) == 1 {
+		if expansion, found := cronMacros[fields[0]]; found {
+			return expansion, true
+		}
+	}
+	ranges := cronRanges[:]
+	switch len(fields) {
+	case 5:
+		ranges = ranges[1:]
+	case 6:
+	default:
+		return "", false
+	}
+	for i, field := range fields {
+		if !ranges[i].valid(field) {
+			return "", false
+		}
+	}
+	return strings.Join(fields, " "), true
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// A cronRange is the range of the values of a field of a cron expression, with the names of the
+// values starting at lo, if any.
+type cronRange struct {
+	lo, hi int
+	names  []string
+}
+
+var cronRanges = [6]cronRange{
+	{0, 59, nil},
+	{0, 59, nil},
+	{0, 23, nil},
+	{1, 31, nil},
+	{1, 12, strings.Fields("jan feb mar apr may jun jul aug sep oct nov dec")},
+	{0, 7, strings.Fields("sun mon tue wed thu fri sat")},
+}
+
+// valid returns true if the field, in lower case, is valid for the range.
+func (r cronRange) valid(field string) bool {
+	for _, item := range strings.Split(field, ",") {
+		item, step, hasStep := strings.Cut(item, "/")
+		if hasStep {
+			if n, err := strconv.Atoi(step); err != nil || n <= 0 || n > r.hi {
+				return false
+			}
+		}
+		if item == "*" {
+			continue
+		}
+		first, last, isRange := strings.Cut(item, "-")
+		lo, ok := r.value(first)
+		if !ok {
+			return false
+		}
+		if isRange {
+			hi, ok := r.value(last)
+			if !ok || hi < lo {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// value returns the value of the number or name s and true, or false if s is not in the range.
+func (r cronRange) value(s string) (int, bool) {
+	for i, name := range r.names {
+		if s == name {
+			return r.lo + i, true
+		}
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil && n >= r.lo && n <= r.hi && s[0] != '+' && s[0] != '-'
+}
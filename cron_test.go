@@ -0,0 +1,83 @@
+package ini
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCron(t *testing.T) {
+	for _, tc := range []struct {
+		input, want string
+	}{
+		{"*/15 * * * *", "*/15 * * * *"},
+		{" 0  9-17 * *   MON-fri ", "0 9-17 * * mon-fri"},
+		{"30 0 0 1,15 Jan,jul 0", "30 0 0 1,15 jan,jul 0"},
+		{"0 0 1/2 * 7", "0 0 1/2 * 7"},
+		{"5-55/10 23 31 12 sun", "5-55/10 23 31 12 sun"},
+		{"@Daily", "0 0 * * *"},
+		{"@weekly", "0 0 * * 0"},
+		{"60 * * * *", ""},
+		{"* 24 * * *", ""},
+		{"* * 0 * *", ""},
+		{"* * * 13 *", ""},
+		{"* * * * 8", ""},
+		{"* * * * mon-sun", ""},
+		{"*/0 * * * *", ""},
+		{"1,,2 * * * *", ""},
+		{"+1 * * * *", ""},
+		{"* * * *", ""},
+		{"0 * * * * * *", ""},
+		{"@every 5m", ""},
+		{"? * * * *", ""},
+	} {
+		v, ok := ParseCron(tc.input)
+		if ok != (tc.want != "") || v != tc.want {
+			t.Fatalf("%q: %q %v", tc.input, v, ok)
+		}
+	}
+	if v, ok := ParseCron("  "); !ok || v != "" {
+		t.Fatal(v, ok)
+	}
+
+	p := NewParser()
+	s := p.AddSection("jobs")
+	backup := s.AddCron("backup")
+	report := s.AddTyped("report", "cron")
+	store, err := p.Parse(strings.NewReader("[jobs]\nbackup = @hourly\nreport = 0 8 * * Mon\n"))
+	if err != nil || backup.StringVal(store) != "0 * * * *" ||
+		report.StringVal(store) != "0 8 * * mon" {
+		t.Fatal(err, store.AllSettings(false))
+	}
+
+	// A CronValidator replaces ParseCron
+	p.CronValidator = func(expr string) error {
+		if !strings.HasPrefix(expr, "@every ") {
+			return errors.New("Not an interval")
+		}
+		return nil
+	}
+	store, err = p.Parse(strings.NewReader("[jobs]\nbackup = @every   5m\n"))
+	if err != nil || backup.StringVal(store) != "@every 5m" {
+		t.Fatal(err, backup.StringVal(store))
+	}
+	_, err = p.Parse(strings.NewReader("[jobs]\nbackup = @hourly\n"))
+	if err == nil ||
+		err.Error() != "Line 2: In section jobs: Value '@hourly' is not valid for field backup" {
+		t.Fatal(err)
+	}
+
+	// The defaults can be written and read back
+	store, err = p.Parse(strings.NewReader(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := store.Write(&b, &WriteOptions{Defaults: true}); err != nil {
+		t.Fatal(err)
+	}
+	if again, err := p.Parse(strings.NewReader(b.String())); err != nil ||
+		backup.StringVal(again) != "" || report.StringVal(again) != "" {
+		t.Fatal(err, b.String())
+	}
+}
@@ -0,0 +1,207 @@
+package ini
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// Decode stores the values of the section's fields in the store into the struct that dst points
+// to, so that code that consumes the configuration can use a plain Go struct, eg
+//
+//	type UserConfig struct {
+//		Name  string
+//		Level uint64 `ini:"log-level"`
+//	}
+//	var user UserConfig
+//	users.Decode(store, &user)
+//
+// Every exported member of the struct receives the value of the field whose name is given by the
+// member's `ini` tag, or otherwise of the field whose name matches the member's name as determined
+// by the parser's NameMapper.  A member tagged `ini:"-"` is skipped.  Fields that were not present
+// get their default values, as for [Field.Value].  A value is stored if it is assignable to the
+// member, or if both are numbers and the member's type can represent the value exactly, in which
+// case it is converted to the member's type.  A member of type *T, for a field whose values can be
+// stored in a T, is optional: it is set to nil if the field was not set in the input or by its
+// [Field.DefaultFromEnv] variable, so that an absent field can be told from one that is set to the
+// zero value, and otherwise to a new T holding the value.  Decode panics if dst is not a pointer to
+// a struct, if a member has no field, or if a field's value cannot be stored in its member, eg if
+// it is out of the range of the member's type.
+func (section *Section) Decode(store *Store, dst any) {
+	if store.parser != section.parser {
+		panic("Store is from a different parser")
+	}
+	v := structValue(dst)
+	for i := range v.NumField() {
+		member := v.Type().Field(i)
+		name, ok := memberName(member)
+		if !ok {
+			continue
+		}
+		field := section.fields[name]
+		if field == nil {
+			field = lookupMember(section.parser, section.order, name, (*Field).Name, member)
+		}
+		if field == nil {
+			panic("No field " + name + " in section " + section.name + " for member " +
+				member.Name)
+		}
+		m, val := v.Field(i), field.Value(store)
+		if m.Kind() == reflect.Pointer && val != nil && !reflect.TypeOf(val).AssignableTo(m.Type()) {
+			// An optional member
+			if !field.isSet(store) {
+				m.SetZero()
+				continue
+			}
+			m.Set(reflect.New(m.Type().Elem()))
+			m = m.Elem()
+		}
+		if !setMember(m, val) {
+			panic("Values of field " + field.name + " cannot be stored in member " + member.Name +
+				" of type " + member.Type.String())
+		}
+	}
+}
+
+// Decode stores the values of all the fields in the store into the struct that dst points to,
+// which has a member of struct type for every section whose fields it needs, eg
+//
+//	type Config struct {
+//		User    UserConfig
+//		Network NetConfig `ini:"net"`
+//	}
+//
+// Members are matched to sections by name as for [Section.Decode], which fills each member.
+// Decode panics if dst is not a pointer to a struct or a member has no section, and as for
+// Section.Decode.
+func (store *Store) Decode(dst any) {
+	v := structValue(dst)
+	for i := range v.NumField() {
+		member := v.Type().Field(i)
+		name, ok := memberName(member)
+		if !ok {
+			continue
+		}
+		section := store.parser.sections[name]
+		if section == nil {
+			section = lookupMember(store.parser, store.parser.order, name, (*Section).Name, member)
+		}
+		if section == nil {
+			panic("No section " + name + " for member " + member.Name)
+		}
+		section.Decode(store, v.Field(i).Addr().Interface())
+	}
+}
+
+// structValue returns the struct that dst points to, or panics if it does not point to a struct.
+func structValue(dst any) reflect.Value {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		panic("Decode destination is not a pointer to a struct")
+	}
+	return v.Elem()
+}
+
+// memberName returns the name of the field or section for the struct member, and true, or false if
+// the member is unexported or is tagged to be skipped.
+func memberName(member reflect.StructField) (string, bool) {
+	if !member.IsExported() {
+		return "", false
+	}
+	switch tag := member.Tag.Get("ini"); tag {
+	case "-":
+		return "", false
+	case "":
+		return member.Name, true
+	default:
+		return tag, true
+	}
+}
+
+// lookupMember returns the element of xs whose name matches the member's name, which is name, as
+// determined by the parser's NameMapper, if the member is not tagged, otherwise nil.
+func lookupMember[T any](
+	parser *Parser,
+	xs []*T,
+	name string,
+	nameOf func(*T) string,
+	member reflect.StructField,
+) *T {
+	if member.Tag.Get("ini") != "" {
+		return nil
+	}
+	match := func(s string) bool { return strings.EqualFold(foldName(s), foldName(name)) }
+	if parser.NameMapper != nil {
+		mapped := parser.NameMapper(name)
+		match = func(s string) bool { return s == mapped }
+	}
+	for _, x := range xs {
+		if match(nameOf(x)) {
+			return x
+		}
+	}
+	return nil
+}
+
+// foldName returns s without the word separators `_` and `-`.
+func foldName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// SnakeCase maps a Go name to the snake_case convention, eg LogLevel to log_level and HTTPPort to
+// http_port, for use as a parser's NameMapper.
+func SnakeCase(name string) string {
+	return splitWords(name, '_')
+}
+
+// KebabCase maps a Go name to the kebab-case convention, eg LogLevel to log-level and HTTPPort to
+// http-port, for use as a parser's NameMapper.
+func KebabCase(name string) string {
+	return splitWords(name, '-')
+}
+
+// splitWords returns the words of the CamelCase name in lower case, separated by sep.  A word
+// starts at an upper case letter that follows a lower case letter or digit, or that follows an
+// upper case letter and precedes a lower case letter, as at the end of an acronym.
+func splitWords(name string, sep rune) string {
+	rs := []rune(name)
+	var b strings.Builder
+	for i, r := range rs {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := rs[i-1]
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				unicode.IsUpper(prev) && i+1 < len(rs) && unicode.IsLower(rs[i+1]) {
+				b.WriteRune(sep)
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// setMember stores val in the struct member m, converting numbers, and returns true, or returns
+// false if val cannot be stored in m, including if it is a number that m's type cannot represent
+// exactly.
+func setMember(m reflect.Value, val any) bool {
+	rv := reflect.ValueOf(val)
+	switch {
+	case val == nil:
+		m.SetZero()
+	case rv.Type().AssignableTo(m.Type()):
+		m.Set(rv)
+	case isNumber(rv) && isNumber(m):
+		converted, ok := convertNumber(rv, m.Type())
+		if !ok {
+			return false
+		}
+		m.Set(converted)
+	default:
+		return false
+	}
+	return true
+}
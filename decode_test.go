@@ -0,0 +1,141 @@
+package ini
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	type UserConfig struct {
+		Name    string
+		Level   uint64 `ini:"log-level"`
+		Retries int
+		Tags    []string
+		Ratio   float32
+		Ignored func() `ini:"-"`
+		private int
+	}
+	type NetConfig struct {
+		Port int64
+	}
+	type Config struct {
+		User    UserConfig
+		Network NetConfig `ini:"net"`
+	}
+
+	p := NewParser()
+	user := p.AddSection("user")
+	user.AddString("name")
+	user.AddUint64("log-level")
+	user.AddInt64("retries").DefaultFunc(func() any { return int64(3) })
+	user.AddStringList("tags")
+	user.AddFloat64("ratio")
+	p.AddSection("net").AddInt64("port")
+	store, err := p.Parse(strings.NewReader(
+		"[user]\nname = joe\nlog-level = 2\ntags = a, b\nratio = 0.5\n[net]\nport = 80\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg Config
+	store.Decode(&cfg)
+	u := cfg.User
+	if u.Name != "joe" || u.Level != 2 || u.Retries != 3 || len(u.Tags) != 2 || u.Tags[1] != "b" ||
+		u.Ratio != 0.5 || cfg.Network.Port != 80 {
+		t.Fatalf("%+v", cfg)
+	}
+
+	var optional struct {
+		Name    *string
+		Retries *int
+		Ratio   *float64
+	}
+	optional.Retries = new(int)
+	user.Decode(store, &optional)
+	if optional.Name == nil || *optional.Name != "joe" || optional.Retries != nil ||
+		optional.Ratio == nil || *optional.Ratio != 0.5 {
+		t.Fatalf("%+v", optional)
+	}
+
+	var other struct{ Missing string }
+	expectPanic(t, "No field Missing in section user for member Missing", func() {
+		user.Decode(store, &other)
+	})
+	var wrong struct{ Name int }
+	expectPanic(t, "Values of field name cannot be stored in member Name of type int", func() {
+		user.Decode(store, &wrong)
+	})
+	store, err = p.Parse(strings.NewReader("[user]\nlog-level = 300\n[net]\nport = -1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var small struct {
+		Level uint8 `ini:"log-level"`
+	}
+	expectPanic(t, "Values of field log-level cannot be stored in member Level of type uint8",
+		func() { user.Decode(store, &small) })
+	var unsigned struct{ Port uint32 }
+	expectPanic(t, "Values of field port cannot be stored in member Port of type uint32", func() {
+		p.Section("net").Decode(store, &unsigned)
+	})
+	expectPanic(t, "Decode destination is not a pointer to a struct", func() { store.Decode(cfg) })
+	var sects struct{ Nope NetConfig }
+	expectPanic(t, "No section Nope for member Nope", func() { store.Decode(&sects) })
+}
+
+func TestDecodeNames(t *testing.T) {
+	type Server struct {
+		LogLevel string
+		HTTPPort int64
+		MaxConns int64 `ini:"connections"`
+	}
+	type Config struct {
+		WebServer Server
+	}
+	input := "[web_server]\nlog-level = debug\nhttp_port = 80\nconnections = 5\n"
+	p := NewParser()
+	sect := p.AddSection("web_server")
+	sect.AddString("log-level")
+	sect.AddInt64("http_port")
+	sect.AddInt64("connections")
+	store, err := p.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg Config
+	store.Decode(&cfg)
+	if cfg.WebServer != (Server{"debug", 80, 5}) {
+		t.Fatalf("%+v", cfg)
+	}
+
+	// With a mapper, the mapped name must match exactly
+	p.NameMapper = SnakeCase
+	expectPanic(t, "No field LogLevel in section web_server for member LogLevel", func() {
+		store.Decode(&cfg)
+	})
+	q := NewParser(WithNameMapper(KebabCase))
+	q.Define("web-server").String("log-level").Int64("http-port").Int64("connections")
+	store, err = q.Parse(strings.NewReader("[web-server]\nhttp-port = 8080\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Decode(&cfg)
+	if cfg.WebServer != (Server{"", 8080, 0}) {
+		t.Fatalf("%+v", cfg)
+	}
+
+	for _, tc := range []struct{ name, snake, kebab string }{
+		{"LogLevel", "log_level", "log-level"},
+		{"HTTPPort", "http_port", "http-port"},
+		{"UserID2", "user_id2", "user-id2"},
+		{"Port80Open", "port80_open", "port80-open"},
+		{"X", "x", "x"},
+	} {
+		if s := SnakeCase(tc.name); s != tc.snake {
+			t.Fatal(tc.name, s)
+		}
+		if s := KebabCase(tc.name); s != tc.kebab {
+			t.Fatal(tc.name, s)
+		}
+	}
+}
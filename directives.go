@@ -0,0 +1,88 @@
+package ini
+
+import (
+	"strings"
+)
+
+// A conditional is the state of an `@if` directive whose `@endif` has not yet been seen.
+type conditional struct {
+	line    int  // The line of the `@if`
+	outer   bool // True if the enclosing region is active
+	active  bool // True if the current branch is active
+	taken   bool // True if some branch has been active
+	sawElse bool // True if the `@else` has been seen
+}
+
+// conditionals is the stack of open `@if` directives, innermost last.
+type conditionals []conditional
+
+// active returns true if lines in the current region are to be processed.
+func (conds conditionals) active() bool {
+	return len(conds) == 0 || conds[len(conds)-1].active
+}
+
+// directive processes a directive token against the parser's Facts.
+func (parser *Parser) directive(tok Token, conds *conditionals, sectName string) error {
+	var cond bool
+	switch tok.Name {
+	case "if", "elif":
+		var ok bool
+		cond, ok = parser.evalCondition(tok.Value)
+		if !ok {
+			return parseFail(tok.Line, sectName, "Invalid condition: %s", strings.TrimSpace(tok.Value))
+		}
+	case "else", "endif":
+		if strings.TrimSpace(tok.Value) != "" {
+			return parseFail(tok.Line, sectName, "Unexpected text after @%s", tok.Name)
+		}
+	default:
+		return parseFail(tok.Line, sectName, "Unknown directive @%s", tok.Name)
+	}
+	if tok.Name == "if" {
+		outer := conds.active()
+		*conds = append(*conds, conditional{
+			line:   tok.Line,
+			outer:  outer,
+			active: outer && cond,
+			taken:  outer && cond,
+		})
+		return nil
+	}
+	if len(*conds) == 0 {
+		return parseFail(tok.Line, sectName, "@%s without @if", tok.Name)
+	}
+	top := &(*conds)[len(*conds)-1]
+	switch tok.Name {
+	case "elif", "else":
+		if top.sawElse {
+			return parseFail(tok.Line, sectName, "@%s after @else", tok.Name)
+		}
+		if tok.Name == "else" {
+			cond = true
+			top.sawElse = true
+		}
+		top.active = top.outer && !top.taken && cond
+		top.taken = top.taken || top.active
+	case "endif":
+		*conds = (*conds)[:len(*conds)-1]
+	}
+	return nil
+}
+
+// evalCondition evaluates a condition of the form `fact == value` or `fact != value`, returning its
+// value and true, or false and false if the condition is malformed.  A fact that is not in Facts
+// has the value "".  The value may be quoted with QuoteChar.
+func (parser *Parser) evalCondition(s string) (result, ok bool) {
+	op := "=="
+	name, value, found := strings.Cut(s, op)
+	if !found {
+		op = "!="
+		name, value, found = strings.Cut(s, op)
+	}
+	name = strings.TrimSpace(name)
+	if !found || !isName(name) {
+		return false, false
+	}
+	value, _ = stripQuotes(strings.TrimSpace(value), parser.QuoteChar)
+	return (parser.Facts[name] == value) == (op == "=="), true
+}
@@ -0,0 +1,304 @@
+package ini
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A Document is a lossless, schema-free representation of an ini file that can be edited and
+// written back out with its comments, blank lines, directives and layout intact, except that line
+// breaks are written as "\n".  Sections are identified by the name in their headers, including
+// any profile, eg "server:prod", and the settings before the first header are in the section "".
+// If a section header appears more than once then the occurrences are treated as one section.
+// Conditional directives are not evaluated: a Document sees the settings of all branches.
+type Document struct {
+	parser *Parser
+	lines  []Token
+}
+
+// ParseDocument reads an ini file from r into a Document.  The parser's options determine the
+// syntax of the input and of the values returned by [Document.Get] and written by
+// [Document.Set], but its sections and fields are not consulted.  Syntax errors are reported as
+// for [Parser.Parse], except that directives are accepted even if Facts is nil.  The parser's
+// LineHook is not applied, as the document must hold the input as it is.
+func (parser *Parser) ParseDocument(r io.Reader) (*Document, error) {
+	scanner := parser.newScanner(r)
+	scanner.LineHook = nil
+	doc := &Document{parser: parser}
+	for scanner.Scan() {
+		tok := scanner.Token()
+		if tok.Kind == TokInvalid {
+			return nil, parseFail(tok.Line, "", "Invalid syntax")
+		}
+		doc.lines = append(doc.lines, tok)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, parser.inputError(err, len(doc.lines))
+	}
+	return doc, nil
+}
+
+// sectionName returns the name by which a Document identifies the section of a header token.
+func sectionName(tok Token) string {
+	if tok.Profile != "" {
+		return tok.Name + ":" + tok.Profile
+	}
+	return tok.Name
+}
+
+// isSectionName returns true if s is a valid section name, optionally followed by `:` and a valid
+// profile name.
+func isSectionName(s string) bool {
+	name, profile, found := strings.Cut(s, ":")
+	return isName(name) && (!found || isName(profile))
+}
+
+// find returns the index of the last setting of key in the section, or -1.
+func (doc *Document) find(section, key string) int {
+	found := -1
+	current := ""
+	for i, tok := range doc.lines {
+		switch tok.Kind {
+		case TokSection:
+			current = sectionName(tok)
+		case TokSetting:
+			if current == section && tok.Name == key {
+				found = i
+			}
+		}
+	}
+	return found
+}
+
+// Sections returns the names of the document's sections in the order of their first headers,
+// preceded by "" if there are settings before the first header.
+func (doc *Document) Sections() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, tok := range doc.lines {
+		name := ""
+		switch tok.Kind {
+		case TokSection:
+			name = sectionName(tok)
+		case TokSetting:
+			if len(names) > 0 {
+				continue
+			}
+		default:
+			continue
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Get returns the value of the last setting of key in the section, with blanks and quotes
+// stripped and variables expanded as for [Parser.Parse], and true, or "" and false if there is no
+// such setting.  References to unbound variables that Parse would report as errors are replaced by
+// the empty string.
+func (doc *Document) Get(section, key string) (string, bool) {
+	i := doc.find(section, key)
+	if i < 0 {
+		return "", false
+	}
+	v, _ := doc.parser.expandedValue(doc.lines[i].Value, doc.parser.expands(section, key), "")
+	return v, true
+}
+
+// Set sets key in the section to value, which is quoted as required.  If the key has settings
+// then the last one is changed in place, otherwise a new setting is added after the section's last
+// setting, or in a new section at the end of the document if there is no such section.  Set
+// returns an error, and leaves the document unchanged, if the value cannot be represented so that
+// the parser reads it back, eg if it contains a line break and the parser does not have Escapes.
+// Set panics if the section or key is not a valid name.
+func (doc *Document) Set(section, key, value string) error {
+	if section != "" && !isSectionName(section) || !isName(key) {
+		panic("Invalid name " + section + "." + key)
+	}
+	expand := doc.parser.expands(section, key)
+	text := doc.parser.quote(value, false, expand)
+	if _, back, ok := doc.parser.checkedLine(key, text, false, expand); !ok || back != value {
+		path := key
+		if section != "" {
+			path = section + "." + key
+		}
+		return fmt.Errorf("The value of %s cannot be represented", path)
+	}
+	doc.setText(section, key, text)
+	return nil
+}
+
+// setText sets key in the section to the value text, which is written as it is, as for Set.
+func (doc *Document) setText(section, key, text string) {
+	if i := doc.find(section, key); i >= 0 {
+		tok := doc.lines[i]
+		line := tok.Text[:len(tok.Text)-len(tok.Value)]
+		if text != "" {
+			line += " " + text
+		}
+		doc.replace(i, line)
+		return
+	}
+	line := key + " ="
+	if text != "" {
+		line += " " + text
+	}
+	doc.insert(doc.end(section), line)
+}
+
+// end returns the index of the line after the last setting or header of the section, adding the
+// section at the end of the document if it has neither.
+func (doc *Document) end(section string) int {
+	at := -1
+	current := ""
+	for i, tok := range doc.lines {
+		switch tok.Kind {
+		case TokSection:
+			current = sectionName(tok)
+			if current == section {
+				at = i + 1
+			}
+		case TokSetting:
+			if current == section {
+				at = i + 1
+			}
+		}
+	}
+	if at < 0 && section == "" {
+		at = len(doc.lines)
+		for i, tok := range doc.lines {
+			if tok.Kind == TokSection {
+				at = i
+				break
+			}
+		}
+	}
+	if at < 0 {
+		if n := len(doc.lines); n > 0 && doc.lines[n-1].Kind != TokBlank {
+			doc.insert(n, "")
+		}
+		doc.insert(len(doc.lines), "["+section+"]")
+		at = len(doc.lines)
+	}
+	return at
+}
+
+// ApplyPatch updates the document with the settings of the ini fragment read from r, as
+// configuration management tools do to ensure that keys have given values: every setting in the
+// fragment is applied as for [Document.Set], with its value text copied as it is, so that quoting
+// and variable references are kept, and every section in the fragment is added to the document if
+// it is not there.  Everything else in the document is preserved.  The fragment has the syntax of
+// the document's parser, and comments and blank lines in it are ignored.  ApplyPatch returns an
+// error, and leaves the document unchanged, if the fragment is not valid or contains directives.
+func (doc *Document) ApplyPatch(r io.Reader) error {
+	patch, err := doc.parser.ParseDocument(r)
+	if err != nil {
+		return err
+	}
+	for _, tok := range patch.lines {
+		if tok.Kind == TokDirective {
+			return parseFail(tok.Line, "", "Directive @%s in patch", tok.Name)
+		}
+	}
+	section := ""
+	for _, tok := range patch.lines {
+		switch tok.Kind {
+		case TokSection:
+			section = sectionName(tok)
+			doc.end(section)
+		case TokSetting:
+			doc.setText(section, tok.Name, strings.TrimSpace(tok.Value))
+		}
+	}
+	return nil
+}
+
+// Delete removes all settings of key in the section and returns true if there were any.
+func (doc *Document) Delete(section, key string) bool {
+	deleted := false
+	for i := doc.find(section, key); i >= 0; i = doc.find(section, key) {
+		doc.lines = append(doc.lines[:i], doc.lines[i+1:]...)
+		deleted = true
+	}
+	doc.renumber()
+	return deleted
+}
+
+// Rename renames all settings of oldKey in the section to newKey, keeping their values, and
+// returns true if there were any.  Rename panics if newKey is not a valid name.
+func (doc *Document) Rename(section, oldKey, newKey string) bool {
+	if !isName(newKey) {
+		panic("Invalid name " + newKey)
+	}
+	renamed := false
+	current := ""
+	for i, tok := range doc.lines {
+		switch tok.Kind {
+		case TokSection:
+			current = sectionName(tok)
+		case TokSetting:
+			if current == section && tok.Name == oldKey {
+				start := strings.Index(tok.Text, oldKey)
+				doc.replace(i, tok.Text[:start]+newKey+tok.Text[start+len(oldKey):])
+				renamed = true
+			}
+		}
+	}
+	return renamed
+}
+
+// RenameSection renames all headers of the section oldName to newName and returns true if there
+// were any.  RenameSection panics if newName is not a valid section name.
+func (doc *Document) RenameSection(oldName, newName string) bool {
+	if !isSectionName(newName) {
+		panic("Invalid name " + newName)
+	}
+	renamed := false
+	for i, tok := range doc.lines {
+		if tok.Kind == TokSection && sectionName(tok) == oldName {
+			doc.replace(i, "["+newName+"]")
+			renamed = true
+		}
+	}
+	return renamed
+}
+
+// replace replaces the text of line i.
+func (doc *Document) replace(i int, text string) {
+	tok := classify(text, doc.parser.CommentChar)
+	tok.Line = doc.lines[i].Line
+	doc.lines[i] = tok
+}
+
+// insert inserts a line before line i.
+func (doc *Document) insert(i int, text string) {
+	tok := classify(text, doc.parser.CommentChar)
+	doc.lines = append(doc.lines[:i], append([]Token{tok}, doc.lines[i:]...)...)
+	doc.renumber()
+}
+
+func (doc *Document) renumber() {
+	for i := range doc.lines {
+		doc.lines[i].Line = i + 1
+	}
+}
+
+// String returns the text of the document.
+func (doc *Document) String() string {
+	var b strings.Builder
+	for _, tok := range doc.lines {
+		b.WriteString(tok.Text)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// WriteTo writes the text of the document to w.
+func (doc *Document) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, doc.String())
+	return int64(n), err
+}
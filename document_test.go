@@ -0,0 +1,108 @@
+package ini
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDocument(t *testing.T) {
+	p := NewParser()
+	doc, err := p.ParseDocument(strings.NewReader(`top = 1
+# The server
+[server]
+  host   =  "localhost"   
+port=80
+
+[server:prod]
+port = 443
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x := doc.Sections(); !slices.Equal(x, []string{"", "server", "server:prod"}) {
+		t.Fatal(x)
+	}
+	if v, found := doc.Get("server", "host"); !found || v != "localhost" {
+		t.Fatal(v)
+	}
+	if _, found := doc.Get("server", "nope"); found {
+		t.Fatal("Get")
+	}
+	doc.Set("server", "host", " example.com ")
+	doc.Set("server", "timeout", "5")
+	doc.Set("", "more", "")
+	doc.Set("client", "retries", "3")
+	if !doc.Rename("server", "port", "listen") || doc.Rename("server", "port", "x") {
+		t.Fatal("Rename")
+	}
+	if !doc.RenameSection("server:prod", "server:production") {
+		t.Fatal("RenameSection")
+	}
+	if !doc.Delete("", "top") || doc.Delete("", "top") {
+		t.Fatal("Delete")
+	}
+	var b strings.Builder
+	if _, err := doc.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	expect := `more =
+# The server
+[server]
+  host   = " example.com "
+listen=80
+timeout = 5
+
+[server:production]
+port = 443
+
+[client]
+retries = 3
+`
+	if b.String() != expect {
+		t.Fatalf("Got\n%s", b.String())
+	}
+
+	// Values that would not read back are rejected
+	if err := doc.Set("server", "host", "x\n[evil]\ny = 2"); err == nil ||
+		err.Error() != "The value of server.host cannot be represented" {
+		t.Fatal(err)
+	}
+	if v, _ := doc.Get("server", "host"); v != " example.com " {
+		t.Fatal(v)
+	}
+	expectPanic(t, "Invalid name server.a b", func() { doc.Set("server", "a b", "") })
+	expectPanic(t, "Invalid name a:", func() { doc.RenameSection("server", "a:") })
+	if _, err := p.ParseDocument(strings.NewReader("[server]\n?\n")); err == nil ||
+		err.Error() != "Line 2: Invalid syntax" {
+		t.Fatal(err)
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	p := NewParser()
+	input := "# Config\n[server]\nhost = a # not a comment\nport = 80\n\n[log]\nlevel = info\n"
+	doc, err := p.ParseDocument(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	patch := "# Ensure these\nroot = $HOME\n[server]\nport = 8080\ntimeout = \" 5s \"\n[log]\n" +
+		"[cache]\n[client:prod]\nretries = 3\n"
+	if err := doc.ApplyPatch(strings.NewReader(patch)); err != nil {
+		t.Fatal(err)
+	}
+	want := "# Config\nroot = $HOME\n[server]\nhost = a # not a comment\nport = 8080\n" +
+		"timeout = \" 5s \"\n\n[log]\nlevel = info\n\n[cache]\n\n[client:prod]\nretries = 3\n"
+	if doc.String() != want {
+		t.Fatalf("Got\n%s", doc.String())
+	}
+
+	for _, bad := range []string{"[server]\nport 80\n", "@if x\nport = 80\n@end\n"} {
+		if err := doc.ApplyPatch(strings.NewReader(bad)); err == nil {
+			t.Fatal("Expected an error for", bad)
+		}
+	}
+	if doc.String() != want {
+		t.Fatalf("Changed by a bad patch\n%s", doc.String())
+	}
+}
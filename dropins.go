@@ -0,0 +1,108 @@
+package ini
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// DropinOptions control [LoadWithDropins].  The zero value gives the default behavior.
+type DropinOptions struct {
+	// Dir is the directory of the drop-in files (default the path of the main file with ".d"
+	// appended, eg /etc/app.conf.d for /etc/app.conf).
+	Dir string
+
+	// Pattern selects the drop-in files in Dir by their names, as for filepath.Match (default
+	// "*.conf").  Hidden files, whose names start with ".", are never drop-ins.
+	Pattern string
+
+	// AppendLists controls whether a drop-in's setting of a list or map field adds its elements to
+	// the value from the files before it rather than replacing the value (default false).  If true,
+	// setting the field to the empty value in a drop-in discards the elements from the files before
+	// it, as for systemd unit files.
+	AppendLists bool
+}
+
+// LoadWithDropins parses the main configuration file at path and the drop-in files that override
+// it, in the style of systemd: the settings of the drop-ins in the directory path.d, in lexical
+// order of their names, take precedence over the settings of the main file and of the drop-ins
+// before them.  The main file must exist, but the directory need not.  The files are parsed and
+// merged, and errors are reported, as for [ParseGlob], so [Field.Source] names the file that a
+// field's value came from and [Field.Origin] gives its line; a list field whose value was built by
+// AppendLists has the source of the last file that set it.  If opts is nil the default options are
+// used.
+func LoadWithDropins(parser *Parser, path string, opts *DropinOptions) (*Store, error) {
+	if opts == nil {
+		opts = &DropinOptions{}
+	}
+	dir, pattern := opts.Dir, opts.Pattern
+	if dir == "" {
+		dir = path + ".d"
+	}
+	if pattern == "" {
+		pattern = "*.conf"
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	names := []string{path}
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		matched, _ := filepath.Match(pattern, name)
+		if matched && !entry.IsDir() && !strings.HasPrefix(name, ".") {
+			names = append(names, filepath.Join(dir, name))
+		}
+	}
+	return parser.parseFragments(names, opts.AppendLists)
+}
+
+// appendLists sets the value of every list and map field in the store, which was merged from the
+// stores, to the concatenation of its values in the stores, in order, starting over at every
+// empty value, and its occurrences to those of the values.
+func (store *Store) appendLists(stores []*Store) {
+	for _, section := range store.parser.order {
+		for _, field := range section.order {
+			if !field.list || !field.Present(store) {
+				continue
+			}
+			var acc reflect.Value
+			var occurrences []Occurrence
+			for _, s := range stores {
+				v, found := s.lookupVal(section, field)
+				if !found {
+					continue
+				}
+				if rv := reflect.ValueOf(v); !acc.IsValid() || rv.Len() == 0 {
+					acc = rv
+					occurrences = nil
+				} else {
+					acc = concatValues(acc, rv)
+				}
+				occurrences = append(occurrences, s.occurrences[field]...)
+			}
+			store.ensure(section).set(field.name, acc.Interface())
+			store.occurrences[field] = occurrences
+		}
+	}
+}
+
+// concatValues returns a new slice with the elements of the slices a and b, or a new map with the
+// entries of the maps a and b, where b's take precedence.
+func concatValues(a, b reflect.Value) reflect.Value {
+	if a.Kind() == reflect.Map {
+		m := reflect.MakeMapWithSize(a.Type(), a.Len()+b.Len())
+		for _, x := range []reflect.Value{a, b} {
+			for iter := x.MapRange(); iter.Next(); {
+				m.SetMapIndex(iter.Key(), iter.Value())
+			}
+		}
+		return m
+	}
+	s := reflect.MakeSlice(a.Type(), 0, a.Len()+b.Len())
+	return reflect.AppendSlice(reflect.AppendSlice(s, a), b)
+}
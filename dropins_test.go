@@ -0,0 +1,75 @@
+package ini
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLoadWithDropins(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, text string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, "app.conf")
+	if err := os.Mkdir(path+".d", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write("app.conf", "[net]\nport = 80\npeers = a, b\nlabels = x: 1\n")
+	write("app.conf.d/20-peers.conf", "[net]\npeers = c\nlabels = y: 2\n")
+	write("app.conf.d/10-port.conf", "[net]\nport = 8080\n")
+	write("app.conf.d/notes.txt", "[net]\nport = 1\n")
+
+	p := NewParser()
+	net := p.AddSection("net")
+	port := net.AddInt64("port")
+	peers := net.AddStringList("peers")
+	labels := net.AddStringMap("labels")
+	store, err := LoadWithDropins(p, path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port.Int64Val(store) != 8080 || !slices.Equal(peers.StringListVal(store), []string{"c"}) {
+		t.Fatal(port.Int64Val(store), peers.StringListVal(store))
+	}
+	if src := port.Source(store); src != filepath.Join(dir, "app.conf.d/10-port.conf") {
+		t.Fatal(src)
+	}
+
+	store, err = LoadWithDropins(p, path, &DropinOptions{AppendLists: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := peers.StringListVal(store); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Fatal(got)
+	}
+	if got := labels.StringMapVal(store); len(got) != 2 || got["x"] != "1" || got["y"] != "2" {
+		t.Fatal(got)
+	}
+
+	// An empty value starts over
+	write("app.conf.d/30-reset.conf", "[net]\npeers =\n")
+	write("app.conf.d/40-more.conf", "[net]\npeers = d\n")
+	store, err = LoadWithDropins(p, path, &DropinOptions{AppendLists: true})
+	if err != nil || !slices.Equal(peers.StringListVal(store), []string{"d"}) {
+		t.Fatal(err, peers.StringListVal(store))
+	}
+
+	// Options select other drop-ins
+	store, err = LoadWithDropins(p, path, &DropinOptions{Dir: path + ".d", Pattern: "*.txt"})
+	if err != nil || port.Int64Val(store) != 1 {
+		t.Fatal(err, port.Int64Val(store))
+	}
+	_, err = LoadWithDropins(p, filepath.Join(dir, "nope.conf"), nil)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatal(err)
+	}
+	if _, err := LoadWithDropins(p, path, &DropinOptions{Pattern: "["}); err == nil {
+		t.Fatal("Expected an error")
+	}
+}
@@ -0,0 +1,112 @@
+package ini
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// An OriginKind classifies the origin of a value.
+type OriginKind int
+
+const (
+	OriginDefault OriginKind = iota // The value is the field's default value
+	OriginInput                     // The value was set in the input
+	OriginEnv                       // The value is from the environment, see DefaultFromEnv
+)
+
+// An Origin describes where a field's value in a store came from.
+type Origin struct {
+	Kind OriginKind
+	File string // For OriginInput, the name of the input file if known
+	Line int    // For OriginInput, the line number in the input
+	Env  string // For OriginEnv, the name of the environment variable
+}
+
+// String renders the origin as "file:line", "line N" if the file is not known, "env NAME", or
+// "default".
+func (o Origin) String() string {
+	switch o.Kind {
+	case OriginInput:
+		if o.File != "" {
+			return o.File + ":" + strconv.Itoa(o.Line)
+		}
+		return "line " + strconv.Itoa(o.Line)
+	case OriginEnv:
+		return "env " + o.Env
+	default:
+		return "default"
+	}
+}
+
+// Origin returns the origin of the field's value in the store.
+func (field *Field) Origin(store *Store) Origin {
+	return store.origins[field]
+}
+
+// EffectiveOptions control the output of [Store.WriteEffective].  The zero value gives the default
+// behavior.
+type EffectiveOptions struct {
+	// HideOrigins, if true, omits the comments that give the origins of the values.
+	HideOrigins bool
+
+	// ShowSecrets, if true, writes the values of secret fields instead of [Redacted].
+	ShowSecrets bool
+}
+
+// WriteEffective writes the effective configuration in the store to w, as ini text that the
+// store's parser can read: every field of every section, whether present in the input or not, with
+// its value (see [Field.Value]), preceded by any comment captured with it (see [Store.CommentFor])
+// and a comment that gives its origin (see [Field.Origin]), prefixed by its source in a merged
+// store (see [Field.Source]).  The values of secret fields are written as [Redacted], and a nil
+// default value of a list field is written as the empty list.  Sections and fields are written in
+// the order they were added to the parser.  If opts is nil then default options are used.
+func (store *Store) WriteEffective(w io.Writer, opts *EffectiveOptions) error {
+	if opts == nil {
+		opts = &EffectiveOptions{}
+	}
+	parser := store.parser
+	comment := string(parser.CommentChar)
+	out := bufio.NewWriter(w)
+	for i, section := range parser.order {
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		writeComment(out, comment, store.SectionComment(section))
+		out.WriteString("[" + section.name + "]\n")
+		for _, field := range section.order {
+			writeComment(out, comment, store.CommentFor(field))
+			if !opts.HideOrigins {
+				if source := field.Source(store); source != "" {
+					fmt.Fprintf(out, "%s %s: %s\n", comment, source, field.Origin(store))
+				} else {
+					fmt.Fprintf(out, "%s %s\n", comment, field.Origin(store))
+				}
+			}
+			var text string
+			if field.secret && !opts.ShowSecrets {
+				text = parser.quoteValue(Redacted, false)
+			} else {
+				text = field.FormatValue(field.Value(store))
+			}
+			out.WriteString(field.name + " =")
+			if text != "" {
+				out.WriteString(" " + text)
+			}
+			out.WriteString("\n")
+		}
+	}
+	return out.Flush()
+}
+
+// writeComment writes the lines of text, if any, as comment lines.
+func writeComment(out *bufio.Writer, comment, text string) {
+	if text == "" {
+		return
+	}
+	for _, l := range strings.Split(text, "\n") {
+		out.WriteString(comment + " " + l + "\n")
+	}
+}
@@ -0,0 +1,74 @@
+package ini
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteEffective(t *testing.T) {
+	p := NewParser()
+	s := p.AddSection("server")
+	s.AddString("host")
+	s.AddInt64("port").DefaultFromEnv("INI_TEST_PORT")
+	s.AddString("password").Secret()
+	tags := []string{}
+	s.AddStringListVar("tags", &tags)
+	p.AddSection("client").AddBool("verbose")
+
+	name := filepath.Join(t.TempDir(), "app.ini")
+	input := "[server]\nhost = \" example.com \"\npassword = hunter2\n"
+	if err := os.WriteFile(name, []byte(input), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("INI_TEST_PORT", "8080")
+	store, err := p.ParseFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o := s.Field("host").Origin(store); o.Kind != OriginInput || o.Line != 2 || o.File != name {
+		t.Fatal(o)
+	}
+	if _, err := p.ParseFile(filepath.Join(t.TempDir(), "missing.ini")); err == nil {
+		t.Fatal("Should fail")
+	}
+
+	var b strings.Builder
+	if err := store.WriteEffective(&b, nil); err != nil {
+		t.Fatal(err)
+	}
+	expect := `[server]
+# ` + name + `:2
+host = " example.com "
+# env INI_TEST_PORT
+port = 8080
+# ` + name + `:3
+password = <redacted>
+# default
+tags =
+
+[client]
+# default
+verbose = false
+`
+	if b.String() != expect {
+		t.Fatalf("Got\n%s", b.String())
+	}
+
+	b.Reset()
+	opts := &EffectiveOptions{HideOrigins: true, ShowSecrets: true}
+	if err := store.WriteEffective(&b, opts); err != nil {
+		t.Fatal(err)
+	}
+	again, err := p.Parse(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.Equal(store.WithDefaults()) {
+		t.Fatalf("Got\n%s", b.String())
+	}
+	if o := s.Field("port").Origin(again); o.String() != "line 3" {
+		t.Fatal(o)
+	}
+}
@@ -0,0 +1,62 @@
+package ini
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// DecodeLatin1 returns a reader that transcodes the ISO 8859-1 (Latin-1) input from r to UTF-8, for
+// use as a parser's Decoder.
+func DecodeLatin1(r io.Reader) io.Reader {
+	return &charmapReader{r: r, table: &latin1}
+}
+
+// DecodeWindows1252 returns a reader that transcodes the Windows-1252 input from r to UTF-8, for
+// use as a parser's Decoder.  Windows-1252 is Latin-1 with printable characters, such as `€` and
+// curly quotes, in place of most of the C1 control characters; the five undefined bytes are mapped
+// to the control characters, as web browsers do.
+func DecodeWindows1252(r io.Reader) io.Reader {
+	return &charmapReader{r: r, table: &windows1252}
+}
+
+var latin1, windows1252 [256]rune
+
+func init() {
+	for i := range latin1 {
+		latin1[i] = rune(i)
+	}
+	windows1252 = latin1
+	copy(windows1252[0x80:], []rune{
+		'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+		0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+	})
+}
+
+// charmapReader transcodes the input from r, in a single-byte character set given by the table
+// from bytes to runes, to UTF-8.
+type charmapReader struct {
+	r     io.Reader
+	table *[256]rune
+	in    [4096]byte
+	out   []byte // Transcoded text that has not been read
+	buf   []byte // The storage for out
+	err   error  // The error from r, to be returned when out is empty
+}
+
+func (cr *charmapReader) Read(p []byte) (int, error) {
+	for len(cr.out) == 0 {
+		if cr.err != nil {
+			return 0, cr.err
+		}
+		n, err := cr.r.Read(cr.in[:])
+		cr.out = cr.buf[:0]
+		for _, b := range cr.in[:n] {
+			cr.out = utf8.AppendRune(cr.out, cr.table[b])
+		}
+		cr.buf = cr.out
+		cr.err = err
+	}
+	n := copy(p, cr.out)
+	cr.out = cr.out[n:]
+	return n, nil
+}
@@ -0,0 +1,70 @@
+package ini
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDecoder(t *testing.T) {
+	latin1Input := "[s]\nname = caf\xe9 \xab\xbb\n"
+	for _, tc := range []struct {
+		decoder func(r io.Reader) io.Reader
+		input   string
+		want    string
+	}{
+		{DecodeLatin1, latin1Input, "café «»"},
+		{DecodeWindows1252, latin1Input, "café «»"},
+		{DecodeLatin1, "[s]\nname = \x80\x93x\x94\n", "\u0080\u0093x\u0094"},
+		{DecodeWindows1252, "[s]\nname = \x80\x93x\x94\x81\n", "€“x”\u0081"},
+	} {
+		p := NewParser(WithDecoder(tc.decoder), WithRequireUTF8(true))
+		name := p.AddSection("s").AddString("name")
+		for _, parse := range []func() (*Store, error){
+			func() (*Store, error) {
+				return p.Parse(iotest.OneByteReader(strings.NewReader(tc.input)))
+			},
+			func() (*Store, error) { return p.ParseString(tc.input) },
+		} {
+			store, err := parse()
+			if err != nil || name.StringVal(store) != tc.want {
+				t.Fatalf("%q: %v %q", tc.input, err, name.StringVal(store))
+			}
+		}
+	}
+
+	input := strings.Repeat("x\xff", 5000)
+	var want strings.Builder
+	for range 5000 {
+		want.WriteString("xÿ")
+	}
+	r := DecodeLatin1(strings.NewReader(input))
+	if err := iotest.TestReader(r, []byte(want.String())); err != nil {
+		t.Fatal(err)
+	}
+
+	// Migrated input is transcoded once
+	p := NewParser(WithDecoder(DecodeLatin1))
+	p.AddSection("meta").AddInt64("version")
+	name := p.AddSection("s").AddString("name")
+	p.Version("meta.version", 1)
+	p.AddMigration(0, 1, func(doc *Document) error { return nil })
+	store, err := p.Parse(strings.NewReader(latin1Input))
+	if err != nil || name.StringVal(store) != "café «»" {
+		t.Fatal(err, name.StringVal(store))
+	}
+}
+
+func TestRequireUTF8(t *testing.T) {
+	p := NewParser(WithRequireUTF8(true))
+	p.AddSection("s").AddString("name")
+	_, err := p.Parse(strings.NewReader("[s]\nname = caf\xe9\n"))
+	if err == nil || err.Error() != "Line 2: In section s: Invalid UTF-8" {
+		t.Fatal(err)
+	}
+	p.RequireUTF8 = false
+	if _, err := p.Parse(strings.NewReader("[s]\nname = caf\xe9\n")); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,434 @@
+package ini
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// An EventHandler receives the contents of the input from [Parser.ParseEvents] as a sequence of
+// events, in input order.  Line numbers are 1-based.  If a method returns an error, parsing stops
+// and ParseEvents returns the error, wrapped in a [*ParseError] unless it is one already.
+type EventHandler interface {
+	// SectionStart is called for a section header with the section's name.  A section for the
+	// active profile is reported with the name of its base section, and sections for other
+	// profiles and their contents are not reported at all.
+	SectionStart(line int, name string) error
+
+	// KeyValue is called for a setting with the name of the current section (which is "" for a
+	// setting before the first section header), the setting's name, and its value.  The value has
+	// been processed according to the parser's variable expansion and quoting settings.
+	KeyValue(line int, section, key, value string) error
+
+	// Comment is called for a comment line with the text following the comment character.
+	Comment(line int, text string) error
+
+	// EOF is called at the end of the input with the number of lines read.
+	EOF(line int) error
+}
+
+// ParseEvents parses the input from the reader according to the parser's options and delivers its
+// sections, settings and comments to the handler without validating them against the parser's
+// sections and fields or storing them, for very large inputs or for tools that transform ini
+// files.  Syntax errors are reported as for [Parser.Parse].
+func (parser *Parser) ParseEvents(r io.Reader, h EventHandler) error {
+	return parser.ParseEventsContext(context.Background(), r, h)
+}
+
+// ParseEventsContext is to [Parser.ParseEvents] what [Parser.ParseContext] is to [Parser.Parse].
+func (parser *Parser) ParseEventsContext(ctx context.Context, r io.Reader, h EventHandler) error {
+	return parser.scan(ctx, r, h)
+}
+
+// A listHandler is an EventHandler that takes the values of some settings as lists, which are
+// delivered to KeyValue with only blanks stripped so that their elements can be quoted
+// individually.  See [Parser.splitList].
+type listHandler interface {
+	isList(section, key string) bool
+}
+
+// An expandHandler is an EventHandler that decides whether variable references are expanded in
+// the values of some settings, instead of the parser's ExpandVars.
+type expandHandler interface {
+	expandVars(section, key string) bool
+}
+
+// A profileHandler is an EventHandler that distinguishes the start of a section for the active
+// profile from the start of a base section.
+type profileHandler interface {
+	profileSectionStart(line int, name string) error
+}
+
+// An errorCollector is an EventHandler that collects the errors of the input instead of stopping
+// at the first one.  Errors that leave the parser's state well-defined, such as invalid syntax and
+// errors returned by the handler, are passed to collect, and processing continues if it returns
+// true.
+type errorCollector interface {
+	collect(err error) bool
+}
+
+// scan splits the input into lines, classifies them, and delivers them to the handler.
+func (parser *Parser) scan(ctx context.Context, r io.Reader, h EventHandler) error {
+	return parser.process(ctx, parser.newScanner(r), "", h)
+}
+
+// newScanner returns a Scanner for the input with the parser's options, which stops with
+// errInputTooLarge if the input exceeds MaxInputSize and transcodes the input with the Decoder.
+func (parser *Parser) newScanner(r io.Reader) *Scanner {
+	if parser.MaxInputSize > 0 {
+		r = &limitedReader{r, parser.MaxInputSize}
+	}
+	if parser.Decoder != nil {
+		r = parser.Decoder(r)
+	}
+	return parser.configure(NewScanner(r))
+}
+
+// newStringScanner returns a Scanner for the input s, as for newScanner, that reads the lines of s
+// in place if it needs no transcoding.
+func (parser *Parser) newStringScanner(s string) *Scanner {
+	if parser.MaxInputSize > 0 && len(s) > parser.MaxInputSize || parser.Decoder != nil {
+		return parser.newScanner(strings.NewReader(s)) // Fails at the same place as a reader
+	}
+	return parser.newTextScanner(s)
+}
+
+// newTextScanner returns a Scanner with the parser's options that reads the lines of the UTF-8 text
+// s in place, without transcoding or checking its size.
+func (parser *Parser) newTextScanner(s string) *Scanner {
+	scanner := parser.configure(NewScanner(nil))
+	scanner.text = &s
+	return scanner
+}
+
+// configure sets the scanner's options from the parser's and returns the scanner.
+func (parser *Parser) configure(scanner *Scanner) *Scanner {
+	scanner.CommentChar = parser.CommentChar
+	scanner.MaxLineLen = parser.MaxLineLen
+	scanner.CRBreaks = parser.CRBreaks
+	scanner.LineHook = parser.LineHook
+	return scanner
+}
+
+// inputError returns the ParseError for an error that stopped a Scanner after line lineno.
+func (parser *Parser) inputError(err error, lineno int) *ParseError {
+	switch err {
+	case errInputTooLarge:
+		return parseFail(
+			lineno+1, "", "Input too large, the limit is %d bytes", parser.MaxInputSize).wrap(err)
+	case ErrLineTooLong:
+		return parseFail(
+			lineno+1, "", "Line too long, the limit is %d bytes", parser.MaxLineLen).wrap(err)
+	default:
+		return parseFail(lineno, "", "I/O error: %v", err).wrap(err)
+	}
+}
+
+// A tokenSource delivers a sequence of tokens, like a Scanner.
+type tokenSource interface {
+	Scan() bool
+	Token() Token
+	Err() error
+}
+
+// process processes the tokens from the source, which is the input of the given name or "" if the
+// name is not known, and delivers the resulting events to the handler.  Errors in included inputs
+// name the input.
+func (parser *Parser) process(
+	ctx context.Context,
+	src tokenSource,
+	name string,
+	h EventHandler,
+) error {
+	scanner := &includeStack{frames: []includeFrame{{src: src, name: name}}}
+	defer scanner.close()
+	if fh, ok := h.(fileHandler); ok {
+		scanner.onFile = fh.fileChanged
+	}
+	return scanner.attribute(parser.processTokens(ctx, scanner, h))
+}
+
+func (parser *Parser) processTokens(
+	ctx context.Context,
+	scanner *includeStack,
+	h EventHandler,
+) error {
+	var lineno, numSections, numSettings int
+	var sectName string
+	var skipping bool // In a section for an inactive profile
+	var conds conditionals
+	var headers map[string]bool // The section headers seen, if DuplicateSections is RejectSections
+	collector, _ := h.(errorCollector)
+	// recoverable returns nil if the error has been collected, otherwise the error
+	recoverable := func(err error) error {
+		if collector != nil && collector.collect(scanner.attribute(err)) {
+			return nil
+		}
+		return err
+	}
+	handled := func(err error) error {
+		if err == nil {
+			return nil
+		}
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			err = parseFail(lineno, sectName, "%v", err).wrap(err)
+		}
+		return recoverable(err)
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return parseFail(lineno+1, "", "Parsing stopped: %v", err).wrap(err)
+		}
+		if !scanner.Scan() {
+			break
+		}
+		tok := scanner.Token()
+		lineno = tok.Line
+		if parser.RequireUTF8 && !utf8.ValidString(tok.Text) {
+			if err := recoverable(parseFail(lineno, sectName, "Invalid UTF-8")); err != nil {
+				return err
+			}
+			continue
+		}
+		if tok.Kind == TokDirective && tok.Name == "include" {
+			if !conds.active() {
+				continue
+			}
+			if err := parser.include(tok, scanner, sectName); err != nil {
+				return err
+			}
+			continue
+		}
+		if tok.Kind == TokDirective && parser.Facts != nil {
+			if err := parser.directive(tok, &conds, sectName); err != nil {
+				return err
+			}
+			continue
+		}
+		if !conds.active() {
+			continue
+		}
+		switch tok.Kind {
+		case TokBlank:
+			continue
+		case TokComment:
+			if skipping {
+				continue
+			}
+			if err := handled(h.Comment(lineno, strings.TrimSpace(tok.Value))); err != nil {
+				return err
+			}
+			continue
+		case TokSection:
+			numSections++
+			if parser.MaxSections > 0 && numSections > parser.MaxSections {
+				return parseFail(lineno, "", "Too many sections, the limit is %d", parser.MaxSections)
+			}
+			sectName = tok.Name
+			skipping = tok.Profile != "" && tok.Profile != parser.Profile
+			if parser.DuplicateSections == RejectSections {
+				header := tok.Name
+				if tok.Profile != "" {
+					header += ":" + tok.Profile
+				}
+				if headers[header] {
+					err := recoverable(parseFail(lineno, "", "Duplicate section %s", header))
+					if err != nil {
+						return err
+					}
+				}
+				if headers == nil {
+					headers = make(map[string]bool)
+				}
+				headers[header] = true
+			}
+			if skipping {
+				continue
+			}
+			var err error
+			if ph, ok := h.(profileHandler); ok && tok.Profile != "" {
+				err = ph.profileSectionStart(lineno, tok.Name)
+			} else {
+				err = h.SectionStart(lineno, tok.Name)
+			}
+			if err := handled(err); err != nil {
+				return err
+			}
+			continue
+		case TokSetting:
+			numSettings++
+			if parser.MaxSettings > 0 && numSettings > parser.MaxSettings {
+				return parseFail(
+					lineno, sectName, "Too many settings, the limit is %d", parser.MaxSettings)
+			}
+			if skipping {
+				continue
+			}
+			l, ok := h.(listHandler)
+			list := ok && l.isList(sectName, tok.Name)
+			expand := parser.ExpandVars
+			if e, ok := h.(expandHandler); ok {
+				expand = e.expandVars(sectName, tok.Name)
+			}
+			value, ambiguous, err := parser.settingValue(tok.Value, list, expand, scanner.top().name)
+			if err != nil {
+				if err := recoverable(parseFail(lineno, sectName, "%v", err)); err != nil {
+					return err
+				}
+				continue
+			}
+			if ambiguous {
+				err := recoverable(parseFail(lineno, sectName,
+					"Value of %s must be quoted because it contains %s or =",
+					tok.Name, string(parser.CommentChar)))
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			err = handled(h.KeyValue(lineno, sectName, tok.Name, value))
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		var err error
+		if sectName == "" {
+			err = recoverable(parseFail(lineno, "", "Invalid syntax before first section"))
+		} else {
+			err = recoverable(parseFail(lineno, sectName, "Invalid syntax"))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return parser.inputError(err, lineno)
+	}
+	if len(conds) > 0 {
+		return parseFail(conds[len(conds)-1].line, "", "@if without @endif")
+	}
+	return handled(h.EOF(lineno))
+}
+
+// settingValue returns the value to deliver for the raw text of a setting's value in the named
+// file, with variable references expanded if expand is true (see expandedMembers), and true if
+// RequireQuotes is true and the value is ambiguous (see ambiguous) or is a list with an ambiguous
+// element, or an error if expansion fails.  The value of a list has only its blanks stripped, but
+// its elements are checked for expansion errors.
+func (parser *Parser) settingValue(
+	raw string,
+	list, expand bool,
+	file string,
+) (string, bool, error) {
+	if list {
+		value := strings.TrimSpace(raw)
+		_, structured := parser.structure(value)
+		if expand && parser.UnboundVars == UnboundError {
+			var err error
+			if structured {
+				_, err = parser.recordValues(value, expand, file)
+			} else {
+				_, err = parser.listValues(value, expand, file)
+			}
+			if err != nil {
+				return "", false, err
+			}
+		}
+		if structured {
+			return value, parser.RequireQuotes && parser.ambiguous(value), nil
+		}
+		return value, parser.RequireQuotes &&
+			slices.ContainsFunc(parser.splitList(value), parser.ambiguous), nil
+	}
+	value, err := parser.expandedMembers(raw, expand, file)
+	return value, parser.RequireQuotes && parser.ambiguous(raw), err
+}
+
+// value performs variable expansion according to ExpandVars, escape processing, and blank and
+// quote stripping on the raw text of a value in the named file, and returns an error if expansion
+// fails.
+func (parser *Parser) value(s, file string) (string, error) {
+	return parser.expandedValue(s, parser.ExpandVars, file)
+}
+
+// expandedValue is like value, but expands variable references if expand is true.
+func (parser *Parser) expandedValue(s string, expand bool, file string) (string, error) {
+	s = strings.TrimSpace(s)
+	if inner, quoted := stripQuotes(s, parser.LiteralQuoteChar); quoted {
+		return inner, nil
+	}
+	var err error
+	if expand {
+		s, err = parser.expandVars(s, file)
+		s = strings.TrimSpace(s)
+	}
+	if inner, quoted := stripQuotes(s, parser.QuoteChar); quoted {
+		s = inner
+		if parser.Escapes {
+			s = unescape(s)
+		}
+	}
+	return s, err
+}
+
+// ambiguous returns true if the raw text of a value is not quoted and contains the comment
+// character or `=`.  The text of a record, inline table or list of records (see structure) is
+// ambiguous only if one of its parts is, as its own `=` are part of its syntax.
+func (parser *Parser) ambiguous(s string) bool {
+	s = strings.TrimSpace(s)
+	if _, quoted := stripQuotes(s, parser.QuoteChar); quoted {
+		return false
+	}
+	if _, quoted := stripQuotes(s, parser.LiteralQuoteChar); quoted {
+		return false
+	}
+	if parts, ok := parser.structure(s); ok {
+		return slices.ContainsFunc(parts, parser.ambiguous)
+	}
+	return hasDelimiter(s, parser.CommentChar)
+}
+
+// hasDelimiter returns true if s contains `=` or the comment character.
+func hasDelimiter(s string, commentChar rune) bool {
+	return strings.ContainsRune(s, '=') || strings.ContainsRune(s, commentChar)
+}
+
+// stripQuotes returns s without its first and last characters and true if both are q, which is not
+// 0, otherwise s and false.
+func stripQuotes(s string, q rune) (string, bool) {
+	c := string(q)
+	if q != 0 && strings.HasPrefix(s, c) && strings.HasSuffix(s, c) {
+		return strings.TrimSuffix(strings.TrimPrefix(s, c), c), true
+	}
+	return s, false
+}
+
+// unescape replaces the escape sequences `\\`, `\n`, `\t`, and backslash followed by any other
+// character with the characters they denote.  A trailing backslash is kept.
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
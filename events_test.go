@@ -0,0 +1,77 @@
+package ini
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type recorder struct {
+	events []string
+	fail   string
+}
+
+func (r *recorder) SectionStart(line int, name string) error {
+	r.events = append(r.events, fmt.Sprintf("%d [%s]", line, name))
+	return nil
+}
+
+func (r *recorder) KeyValue(line int, section, key, value string) error {
+	if key == r.fail {
+		return errors.New("Bad key " + key)
+	}
+	r.events = append(r.events, fmt.Sprintf("%d %s.%s=%s", line, section, key, value))
+	return nil
+}
+
+func (r *recorder) Comment(line int, text string) error {
+	r.events = append(r.events, fmt.Sprintf("%d #%s", line, text))
+	return nil
+}
+
+func (r *recorder) EOF(line int) error {
+	r.events = append(r.events, fmt.Sprintf("%d EOF", line))
+	return nil
+}
+
+func TestParseEvents(t *testing.T) {
+	p := NewParser()
+	p.AddSection("known")
+	r := &recorder{}
+	err := p.ParseEvents(strings.NewReader(`global = 1
+# hello
+[ unknown ]
+ x = " quoted "
+
+[known]
+y=
+`), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{
+		"1 .global=1",
+		"2 #hello",
+		"3 [unknown]",
+		"4 unknown.x= quoted ",
+		"6 [known]",
+		"7 known.y=",
+		"7 EOF",
+	}
+	if strings.Join(r.events, "\n") != strings.Join(expect, "\n") {
+		t.Fatal(r.events)
+	}
+
+	r = &recorder{fail: "y"}
+	err = p.ParseEvents(strings.NewReader("[a]\nx=1\ny=2\n"), r)
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Line != 3 || pe.Section != "a" || pe.Irritant != "Bad key y" {
+		t.Fatal(err)
+	}
+
+	err = p.ParseEvents(strings.NewReader("[a]\nnonsense\n"), &recorder{})
+	if err == nil || err.Error() != "Line 2: In section a: Invalid syntax" {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,217 @@
+package ini
+
+import (
+	"bufio"
+	"io"
+	"slices"
+	"strings"
+)
+
+// FormatOptions control the output of [Format].
+type FormatOptions struct {
+	// CommentChar is the character that starts comment lines (default '#').
+	CommentChar rune
+
+	// QuoteChar is the character used for quoting values (default '"').  Quotes that are not
+	// needed to preserve a value are removed.  Set to 0 to leave quoted values alone.
+	QuoteChar rune
+
+	// LiteralQuoteChar and Escapes must be as for the Parser that will read the output, so that
+	// quotes that are needed for the values' meaning are not removed (default 0 and false).
+	LiteralQuoteChar rune
+	Escapes          bool
+
+	// ListDelim must be as for the Parser that will read the output (default ','), so that quotes
+	// around a value that contains it, which may be a list element, are not removed.
+	ListDelim rune
+
+	// QuoteAmbiguous, if true, quotes unquoted values that contain CommentChar or `=`, as required
+	// by a Parser whose RequireQuotes is true (default false).  It has no effect if QuoteChar is 0.
+	QuoteAmbiguous bool
+
+	// AlignValues, if true, pads setting names so that the `=` of all settings in a section are
+	// aligned (default false).
+	AlignValues bool
+
+	// SortKeys, if true, sorts the settings within each section by name (default false).  Comment
+	// lines preceding a setting move with the setting, and blank lines are removed.  Sections that
+	// contain directives are not sorted.
+	SortKeys bool
+}
+
+// NewFormatOptions returns a new FormatOptions with default settings.
+func NewFormatOptions() *FormatOptions {
+	return &FormatOptions{
+		CommentChar: '#',
+		QuoteChar:   '"',
+		ListDelim:   ',',
+	}
+}
+
+// A fmtSection is a section header (nil for the settings before the first header) and the tokens
+// of its body.
+type fmtSection struct {
+	header *Token
+	body   []Token
+}
+
+// Format reads an ini file from r and writes it to w in canonical form, without reference to any
+// schema, much as gofmt does for Go code.  If opts is nil then default options are used.
+//
+// Leading and trailing blanks are removed from all lines, section headers are written as `[name]`
+// and preceded by a blank line, settings are written as `name = value`, runs of blank lines are
+// collapsed, and comments and directives are preserved.  Nothing is written if the input has a
+// syntax error, which is returned as a [*ParseError].
+func Format(r io.Reader, w io.Writer, opts *FormatOptions) error {
+	if opts == nil {
+		opts = NewFormatOptions()
+	}
+	scanner := NewScanner(r)
+	scanner.CommentChar = opts.CommentChar
+	sections := []*fmtSection{{}}
+	for scanner.Scan() {
+		tok := scanner.Token()
+		switch tok.Kind {
+		case TokInvalid:
+			return parseFail(tok.Line, "", "Invalid syntax")
+		case TokSection:
+			sections = append(sections, &fmtSection{header: &tok})
+		default:
+			last := sections[len(sections)-1]
+			last.body = append(last.body, tok)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return parseFail(0, "", "I/O error: %v", err).wrap(err)
+	}
+
+	out := bufio.NewWriter(w)
+	first := true
+	for _, s := range sections {
+		body := trimBlanks(s.body)
+		if opts.SortKeys {
+			body = sortSettings(body)
+		}
+		if s.header != nil {
+			if !first {
+				out.WriteString("\n")
+			}
+			if s.header.Profile != "" {
+				out.WriteString("[" + s.header.Name + ":" + s.header.Profile + "]\n")
+			} else {
+				out.WriteString("[" + s.header.Name + "]\n")
+			}
+			first = false
+		}
+		width := 0
+		if opts.AlignValues {
+			for _, tok := range body {
+				if tok.Kind == TokSetting {
+					width = max(width, len(tok.Name))
+				}
+			}
+		}
+		for i, tok := range body {
+			switch tok.Kind {
+			case TokBlank:
+				if i > 0 && body[i-1].Kind != TokBlank {
+					out.WriteString("\n")
+				}
+			case TokComment:
+				out.WriteString(string(opts.CommentChar) + strings.TrimRight(tok.Value, " \t") + "\n")
+			case TokSetting:
+				out.WriteString(tok.Name + strings.Repeat(" ", max(width-len(tok.Name), 0)) + " =")
+				if v := formatValue(tok.Value, opts); v != "" {
+					out.WriteString(" " + v)
+				}
+				out.WriteString("\n")
+			case TokDirective:
+				out.WriteString(strings.TrimSpace("@" + tok.Name + " " + strings.TrimSpace(tok.Value)))
+				out.WriteString("\n")
+			}
+			first = false
+		}
+	}
+	return out.Flush()
+}
+
+// trimBlanks removes leading and trailing blank lines.
+func trimBlanks(body []Token) []Token {
+	for len(body) > 0 && body[0].Kind == TokBlank {
+		body = body[1:]
+	}
+	for len(body) > 0 && body[len(body)-1].Kind == TokBlank {
+		body = body[:len(body)-1]
+	}
+	return body
+}
+
+// sortSettings sorts the settings of a section body by name, carrying along the comments that
+// precede each setting.  Blank lines are removed, and any trailing comments are left at the end.
+// A body that contains directives is not sorted, as that would change their meaning.
+func sortSettings(body []Token) []Token {
+	if slices.ContainsFunc(body, func(tok Token) bool { return tok.Kind == TokDirective }) {
+		return body
+	}
+	var groups [][]Token
+	var pending []Token
+	for _, tok := range body {
+		switch tok.Kind {
+		case TokComment:
+			pending = append(pending, tok)
+		case TokSetting:
+			groups = append(groups, append(pending, tok))
+			pending = nil
+		}
+	}
+	slices.SortStableFunc(groups, func(a, b []Token) int {
+		return strings.Compare(a[len(a)-1].Name, b[len(b)-1].Name)
+	})
+	var result []Token
+	for _, g := range groups {
+		result = append(result, g...)
+	}
+	return append(result, pending...)
+}
+
+// formatValue strips blanks from a raw value and removes quotes that are not needed.  Quotes are
+// removed only from a value that is a single quoted token, as the value may be a list whose
+// elements are quoted individually, eg `"Smith, J", "Doe, J"`.
+func formatValue(raw string, opts *FormatOptions) string {
+	v := strings.TrimSpace(raw)
+	if opts.QuoteChar == 0 {
+		return v
+	}
+	q := string(opts.QuoteChar)
+	if len(v) >= 2*len(q) && strings.HasPrefix(v, q) && strings.HasSuffix(v, q) {
+		inner := v[len(q) : len(v)-len(q)]
+		if !needsQuotes(inner, opts) && !strings.ContainsRune(inner, opts.QuoteChar) &&
+			(opts.ListDelim == 0 || !strings.ContainsRune(inner, opts.ListDelim)) &&
+			(opts.LiteralQuoteChar == 0 || !strings.ContainsRune(inner, opts.LiteralQuoteChar)) {
+			return inner
+		}
+		return v
+	}
+	if _, literal := stripQuotes(v, opts.LiteralQuoteChar); literal {
+		return v
+	}
+	if opts.QuoteAmbiguous && hasDelimiter(v, opts.CommentChar) {
+		if opts.Escapes {
+			v = strings.ReplaceAll(v, `\`, `\\`)
+		}
+		return q + v + q
+	}
+	return v
+}
+
+// needsQuotes returns true if the value would not survive blank and quote stripping unquoted.
+func needsQuotes(v string, opts *FormatOptions) bool {
+	if v == "" {
+		return false
+	}
+	_, quoted := stripQuotes(v, opts.QuoteChar)
+	_, literal := stripQuotes(v, opts.LiteralQuoteChar)
+	ambiguous := opts.QuoteAmbiguous && hasDelimiter(v, opts.CommentChar)
+	return strings.TrimSpace(v) != v || quoted || literal || ambiguous ||
+		opts.Escapes && strings.Contains(v, `\`)
+}
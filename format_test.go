@@ -0,0 +1,167 @@
+package ini
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	input := `
+
+  # leading comment
+global=1
+   [ sect ]
+  zeta   =   "  spaced  "
+
+
+   # about alpha
+alpha="plain"
+mid =
+[ other : p ]
+x="""
+  @if  os == linux  
+y=1
+@endif`
+	var out bytes.Buffer
+	if err := Format(strings.NewReader(input), &out, nil); err != nil {
+		t.Fatal(err)
+	}
+	expect := `# leading comment
+global = 1
+
+[sect]
+zeta = "  spaced  "
+
+# about alpha
+alpha = plain
+mid =
+
+[other:p]
+x = """
+@if os == linux
+y = 1
+@endif
+`
+	if out.String() != expect {
+		t.Fatalf("Got\n%s", out.String())
+	}
+
+	opts := NewFormatOptions()
+	opts.AlignValues = true
+	opts.SortKeys = true
+	out.Reset()
+	if err := Format(strings.NewReader(input), &out, opts); err != nil {
+		t.Fatal(err)
+	}
+	expect = `# leading comment
+global = 1
+
+[sect]
+# about alpha
+alpha = plain
+mid   =
+zeta  = "  spaced  "
+
+[other:p]
+x = """
+@if os == linux
+y = 1
+@endif
+`
+	if out.String() != expect {
+		t.Fatalf("Got\n%s", out.String())
+	}
+
+	// Formatting is idempotent
+	again := out.String()
+	out.Reset()
+	if err := Format(strings.NewReader(again), &out, opts); err != nil || out.String() != again {
+		t.Fatal("Not idempotent", err)
+	}
+
+	out.Reset()
+	err := Format(strings.NewReader("[sect]\nnonsense\n"), &out, nil)
+	if err == nil || err.Error() != "Line 2: Invalid syntax" || out.Len() != 0 {
+		t.Fatal(err)
+	}
+}
+
+func TestFormatQuoteStyles(t *testing.T) {
+	input := "[s]\na = \"'x'\"\nb = \"c:\\\\dir\"\nc = \"plain\"\n"
+	var out bytes.Buffer
+	if err := Format(strings.NewReader(input), &out, nil); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "[s]\na = 'x'\nb = c:\\\\dir\nc = plain\n" {
+		t.Fatalf("Got\n%s", out.String())
+	}
+
+	opts := NewFormatOptions()
+	opts.LiteralQuoteChar = '\''
+	opts.Escapes = true
+	out.Reset()
+	if err := Format(strings.NewReader(input), &out, opts); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "[s]\na = \"'x'\"\nb = \"c:\\\\dir\"\nc = plain\n" {
+		t.Fatalf("Got\n%s", out.String())
+	}
+}
+
+func TestFormatListQuotes(t *testing.T) {
+	input := "[s]\nnames = \"Smith, J\", \"Doe, J\"\none = \"a, b\"\ntwo = \"a\", \"b\"\n" +
+		"three = \"x\"\n"
+	var out bytes.Buffer
+	if err := Format(strings.NewReader(input), &out, nil); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "[s]\nnames = \"Smith, J\", \"Doe, J\"\none = \"a, b\"\ntwo = \"a\", \"b\"\n"+
+		"three = x\n" {
+		t.Fatalf("Got\n%s", out.String())
+	}
+
+	// The output has the same values
+	p := NewParser()
+	s := p.AddSection("s")
+	names, one, two := s.AddStringList("names"), s.AddStringList("one"), s.AddStringList("two")
+	s.AddString("three")
+	store, err := p.Parse(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(names.StringListVal(store), []string{"Smith, J", "Doe, J"}) ||
+		!slices.Equal(one.StringListVal(store), []string{"a, b"}) ||
+		!slices.Equal(two.StringListVal(store), []string{"a", "b"}) {
+		t.Fatal(store.RedactedMap())
+	}
+}
+
+func TestFormatQuoteAmbiguous(t *testing.T) {
+	opts := NewFormatOptions()
+	opts.QuoteAmbiguous = true
+	opts.Escapes = true
+	var out bytes.Buffer
+	input := "[s]\na = x#y\nb = \"x=y\"\nc = c:\\dir=1\nd = plain\n"
+	if err := Format(strings.NewReader(input), &out, opts); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "[s]\na = \"x#y\"\nb = \"x=y\"\nc = \"c:\\\\dir=1\"\nd = plain\n" {
+		t.Fatalf("Got\n%s", out.String())
+	}
+
+	// The output satisfies a strict parser and has the same values
+	p := NewParser("RequireQuotes", true, "Escapes", true)
+	s := p.AddSection("s")
+	for _, name := range []string{"a", "b", "c", "d"} {
+		s.AddString(name)
+	}
+	store, err := p.Parse(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.GetString("s.a") != "x#y" || store.GetString("s.c") != `c:\dir=1` {
+		t.Fatal("Values")
+	}
+}
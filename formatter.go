@@ -0,0 +1,143 @@
+package ini
+
+import (
+	"cmp"
+	"encoding"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FormatValue renders a value of the field as ini text that the field's parser reads back as the
+// same value, quoting and escaping it as required by the parser's options.  The value is rendered
+// by the field's formatter (see [Field.Formatter] and [RegisterType]) if it has one, otherwise as
+// follows: strings as themselves, numbers and bools in the syntax their parse functions accept,
+// values that implement [encoding.TextMarshaler] or [fmt.Stringer] by those methods (eg
+// time.Duration), slices as comma-separated lists (see [ListOf]), maps as comma-separated
+// `key:value` lists ordered by key (see [MapOf]), and nil as the empty string.  The elements of
+// list and map fields, such as those added with [Section.AddStringList] or [AddMapOf], are
+// separated by ListDelim and quoted individually, and map elements are ordered by key.  A list
+// element that contains QuoteChar can only be represented if the parser has Escapes or a
+// LiteralQuoteChar.
+func (field *Field) FormatValue(v any) string {
+	parser := field.section.parser
+	expand := field.expands()
+	if elems, ok := listElems(v); ok && field.list && !field.records {
+		for i, e := range elems {
+			elems[i] = parser.quote(e, true, expand)
+		}
+		return strings.Join(elems, string(parser.ListDelim)+" ")
+	}
+	var s string
+	if field.format != nil {
+		s = field.format(v)
+	} else {
+		s = renderValue(v)
+	}
+	return parser.quote(s, false, expand)
+}
+
+// listElems renders the elements of a slice, or the `key:value` elements of a map ordered by key,
+// as unquoted text, and returns true, or returns false if v is neither.
+func listElems(v any) ([]string, bool) {
+	rv := reflect.ValueOf(v)
+	var elems []string
+	switch rv.Kind() {
+	case reflect.Slice:
+		for i := range rv.Len() {
+			elems = append(elems, renderValue(rv.Index(i).Interface()))
+		}
+	case reflect.Map:
+		for _, k := range rv.MapKeys() {
+			elems = append(elems,
+				renderValue(k.Interface())+":"+renderValue(rv.MapIndex(k).Interface()))
+		}
+		slices.Sort(elems)
+	default:
+		return nil, false
+	}
+	return elems, true
+}
+
+// renderValue renders v as unquoted text, see FormatValue.
+func renderValue(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case bool:
+		return strconv.FormatBool(x)
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(x), 'g', -1, 32)
+	case encoding.TextMarshaler:
+		if t, err := x.MarshalText(); err == nil {
+			return string(t)
+		}
+	case fmt.Stringer:
+		return x.String()
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		elems := make([]string, rv.Len())
+		for i := range elems {
+			elems[i] = renderValue(rv.Index(i).Interface())
+		}
+		return strings.Join(elems, ", ")
+	case reflect.Map:
+		keys := rv.MapKeys()
+		elems := make([]string, len(keys))
+		for i, k := range keys {
+			elems[i] = renderValue(k.Interface()) + ":" + renderValue(rv.MapIndex(k).Interface())
+		}
+		slices.SortFunc(elems, func(a, b string) int {
+			return cmp.Compare(a, b)
+		})
+		return strings.Join(elems, ", ")
+	}
+	return fmt.Sprint(v)
+}
+
+// quoteValue quotes s, if necessary and possible, so that the parser reads it back as s.  If elem
+// is true then s is a list element.
+func (parser *Parser) quoteValue(s string, elem bool) string {
+	return parser.quote(s, elem, parser.ExpandVars)
+}
+
+// quote is like quoteValue, but for a value in which variable references are expanded if expand
+// is true.
+func (parser *Parser) quote(s string, elem, expand bool) string {
+	_, quoted := stripQuotes(s, parser.QuoteChar)
+	_, literal := stripQuotes(s, parser.LiteralQuoteChar)
+	needed := quoted || literal || strings.TrimSpace(s) != s ||
+		strings.ContainsAny(s, "\n\r") ||
+		parser.RequireQuotes && parser.ambiguous(s)
+	if elem {
+		needed = needed || s == "" || strings.ContainsRune(s, parser.ListDelim) ||
+			parser.QuoteChar != 0 && strings.ContainsRune(s, parser.QuoteChar) ||
+			parser.LiteralQuoteChar != 0 && strings.ContainsRune(s, parser.LiteralQuoteChar)
+	}
+	if needed && parser.QuoteChar != 0 && elem && !parser.Escapes &&
+		strings.ContainsRune(s, parser.QuoteChar) && parser.LiteralQuoteChar != 0 &&
+		!strings.ContainsRune(s, parser.LiteralQuoteChar) {
+		// Literal quotes are the only way to protect the quote character
+		q := string(parser.LiteralQuoteChar)
+		return q + s + q
+	}
+	if expand {
+		s = parser.escapeVars(s)
+	}
+	if !needed || parser.QuoteChar == 0 {
+		return s
+	}
+	q := string(parser.QuoteChar)
+	if parser.Escapes {
+		s = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\t", `\t`, q, `\`+q).Replace(s)
+	}
+	return q + s + q
+}
@@ -0,0 +1,73 @@
+package ini
+
+import (
+	"net/netip"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatValue(t *testing.T) {
+	for _, opts := range [][]any{
+		{},
+		{"Escapes", true},
+		{"LiteralQuoteChar", '\''},
+		{"ExpandVars", true, "RequireQuotes", true, "ListDelim", ';'},
+	} {
+		p := NewParser(opts...)
+		s := p.AddSection("s")
+		str := s.AddString("str")
+		list := s.AddStringList("list")
+		for _, c := range []struct {
+			field *Field
+			v     any
+		}{
+			{list, []string{}},
+			{str, "plain"},
+			{str, " padded "},
+			{str, `"quoted"`},
+			{str, "a=b # c"},
+			{str, "$HOME"},
+			{str, ""},
+			{list, []string{"a", "b, c", "", " f", "g;h"}},
+		} {
+			if c.field == list && (p.Escapes || p.LiteralQuoteChar != 0) {
+				c.v = append(c.v.([]string), `d"e`)
+			}
+			text := c.field.FormatValue(c.v)
+			store, err := p.Parse(strings.NewReader("[s]\n" + c.field.Name() + " = " + text + "\n"))
+			if err != nil {
+				t.Fatalf("%v %q: %v", opts, text, err)
+			}
+			if v := c.field.Value(store); !reflect.DeepEqual(v, c.v) {
+				t.Fatalf("%v %q: %q", opts, text, v)
+			}
+		}
+	}
+
+	p := NewParser()
+	s := p.AddSection("s")
+	for _, c := range []struct {
+		field *Field
+		v     any
+		text  string
+	}{
+		{s.AddBool("b"), false, "false"},
+		{s.AddInt64("i"), int64(-3), "-3"},
+		{s.AddFloat64("f"), 0.1, "0.1"},
+		{s.AddTyped("u8", "uint8"), uint8(7), "7"},
+		{s.Add("d", TyUser, time.Duration(0), nil), 90 * time.Second, "1m30s"},
+		{s.Add("a", TyUser, nil, nil), netip.MustParseAddr("::1"), "::1"},
+		{s.Add("l", TyUser, nil, ListOf[int64](ParseInt64)), []int64{1, 2}, "1, 2"},
+		{s.Add("m", TyUser, nil, nil), map[string]int{"y": 2, "x": 1}, "x:1, y:2"},
+		{s.Add("o", TyUser, nil, Optional(ParseInt64)), nil, ""},
+		{s.AddInt64("h").Formatter(func(v any) string { return "0x" + strconv.FormatInt(v.(int64), 16) }),
+			int64(255), "0xff"},
+	} {
+		if text := c.field.FormatValue(c.v); text != c.text {
+			t.Fatalf("%s: %q", c.field.Name(), text)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package ini
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ParseGlob parses the files whose names match the pattern, as for filepath.Glob, as the fragments
+// of one configuration, eg the files of a conf.d directory.  The files are parsed concurrently, and
+// the stores are merged as for [Merge] in lexical order of the file names, so that a setting in a
+// later file overrides one in an earlier file, with the file names as the names of the layers (see
+// [Field.Source]).  The fields are required and the constraints between them are checked for the
+// merged store, not for each file, and the values of Var fields are stored if there are no errors.
+// If no files match, the store has only default values.
+//
+// If files cannot be read or parsed, the error is the join of the errors for each of them in
+// lexical order, and the File of each [*ParseError] among them names its file.  An error is also
+// returned if the pattern is malformed.
+func ParseGlob(parser *Parser, pattern string) (*Store, error) {
+	names, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(names)
+	return parser.parseFragments(names, false)
+}
+
+// ParseDir is like [ParseGlob] for the files in the directory whose names end in ".ini" or ".conf",
+// except hidden files, whose names start with ".".  An error is returned if the directory cannot
+// be read.
+func ParseDir(parser *Parser, dir string) (*Store, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && !strings.HasPrefix(name, ".") &&
+			(strings.HasSuffix(name, ".ini") || strings.HasSuffix(name, ".conf")) {
+			names = append(names, filepath.Join(dir, name))
+		}
+	}
+	return parser.parseFragments(names, false)
+}
+
+// parseFragments parses the named files concurrently and merges the stores in order, see ParseGlob,
+// appending the values of list fields if appendLists is true, see DropinOptions.
+func (parser *Parser) parseFragments(names []string, appendLists bool) (*Store, error) {
+	stores := make([]*Store, len(names))
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, runtime.GOMAXPROCS(0))
+	for i, name := range names {
+		wg.Add(1)
+		limit <- struct{}{}
+		go func() {
+			defer wg.Done()
+			stores[i], errs[i] = parser.parseFragment(name)
+			<-limit
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	store := newStore(parser)
+	if len(stores) > 0 {
+		layers := make([]Layer, len(stores))
+		for i, s := range stores {
+			layers[i] = Layer{names[i], s}
+		}
+		store = Merge(layers...)
+		if appendLists {
+			store.appendLists(stores)
+		}
+	}
+	if err := parser.finish(store, nil, func(err error) error { return err }); err != nil {
+		return nil, err
+	}
+	parser.assignVars(store)
+	return store, nil
+}
+
+// parseFragment parses the named file without computing defaults or checking required fields and
+// constraints, see ParseGlob.
+func (parser *Parser) parseFragment(name string) (*Store, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var src *Scanner
+	if parser.versionPath == "" {
+		src = parser.newScanner(f)
+	} else if src, err = parser.migrate(f); err != nil {
+		return nil, attributeFile(err, name)
+	}
+	sb := parser.newStoreBuilder(name, nil)
+	if err := parser.process(context.Background(), src, name, sb); err != nil {
+		return nil, attributeFile(err, name)
+	}
+	return sb.store, nil
+}
+
+// attributeFile sets the File of the ParseError in err, if any, to name if it is not set, and
+// returns err.
+func attributeFile(err error, name string) error {
+	var pe *ParseError
+	if errors.As(err, &pe) && pe.File == "" {
+		pe.File = name
+	}
+	return err
+}
@@ -0,0 +1,70 @@
+package ini
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseDir(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, text string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("10-base.conf", "[net]\nhost = a\nport = 80\n")
+	write("20-site.ini", "[net]\nport = 8080\n")
+	write("30-local.conf", "[log]\nlevel = debug\n")
+	write(".hidden.conf", "[net]\nport = 1\n")
+	write("README", "Not a fragment\n")
+
+	p := NewParser()
+	net := p.AddSection("net")
+	host := net.AddString("host").Required()
+	var port int64
+	net.AddInt64Var("port", &port)
+	level := p.AddSection("log").AddString("level")
+	store, err := ParseDir(p, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host.StringVal(store) != "a" || port != 8080 || level.StringVal(store) != "debug" {
+		t.Fatal(host.StringVal(store), port, level.StringVal(store))
+	}
+	if src := net.Field("port").Source(store); src != filepath.Join(dir, "20-site.ini") {
+		t.Fatal(src)
+	}
+
+	store, err = ParseGlob(p, filepath.Join(dir, "*.conf"))
+	if err != nil || port != 80 {
+		t.Fatal(err, port)
+	}
+
+	// Required fields are checked for the merged store
+	if _, err := ParseGlob(p, filepath.Join(dir, "2*")); err == nil ||
+		err.Error() != "In section net: Missing required field host" {
+		t.Fatal(err)
+	}
+	if _, err := ParseGlob(p, filepath.Join(dir, "none*")); err == nil { // Only defaults
+		t.Fatal("Expected an error")
+	}
+
+	// Errors are attributed to their files
+	write("15-bad.conf", "[net]\nport = x\n")
+	write("25-bad.conf", "[nope]\n")
+	_, err = ParseDir(p, dir)
+	var pe *ParseError
+	if err == nil || !errors.As(err, &pe) || pe.File != filepath.Join(dir, "15-bad.conf") {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(err.Error(), "\n"); len(lines) != 2 ||
+		!strings.HasSuffix(lines[1], "25-bad.conf: Undefined section nope") {
+		t.Fatal(err)
+	}
+	if _, err := ParseGlob(p, "["); err == nil {
+		t.Fatal("Expected an error")
+	}
+}
@@ -0,0 +1,99 @@
+package ini
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// A Holder holds the current Store of a configuration that can be reloaded, and notifies
+// subscribers of the changes to individual fields when it is.  Its methods can be called
+// concurrently.
+type Holder struct {
+	parser *Parser
+	store  atomic.Pointer[Store]
+
+	mu          sync.Mutex // Serializes updates and protects subscribers
+	subscribers []subscriber
+}
+
+type subscriber struct {
+	field *Field
+	fn    func(old, new any)
+}
+
+// NewHolder returns a new Holder for the parser's stores, holding store, which must have been
+// produced by the parser.
+func NewHolder(parser *Parser, store *Store) *Holder {
+	if store.parser != parser {
+		panic("Store is from a different parser")
+	}
+	h := &Holder{parser: parser}
+	h.store.Store(store)
+	return h
+}
+
+// Store returns the current store.
+func (h *Holder) Store() *Store {
+	return h.store.Load()
+}
+
+// OnChange registers fn to be called with the field's old and new values whenever an update
+// changes the field's value, as determined by [Field.Changed].  The field must belong to the
+// holder's parser.
+func (h *Holder) OnChange(field *Field, fn func(old, new any)) {
+	if field.section.parser != h.parser {
+		panic("Field is from a different parser")
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subscribers = append(h.subscribers, subscriber{field, fn})
+}
+
+// Update makes store, which must have been produced by the holder's parser, the current store, and
+// then calls the OnChange functions of the fields that changed, in the order they were registered
+// and on the calling goroutine.  Concurrent updates are serialized, but the functions are called
+// after the update is complete, so they can call the holder's methods, and the functions of
+// concurrent updates may run concurrently.
+func (h *Holder) Update(store *Store) {
+	if store.parser != h.parser {
+		panic("Store is from a different parser")
+	}
+	h.mu.Lock()
+	old := h.store.Swap(store)
+	var changed []subscriber
+	for _, s := range h.subscribers {
+		if s.field.Changed(old, store) {
+			changed = append(changed, s)
+		}
+	}
+	h.mu.Unlock()
+	for _, s := range changed {
+		s.fn(s.field.Value(old), s.field.Value(store))
+	}
+}
+
+// Reload parses the input from r with the holder's parser and, if that succeeds, updates the holder
+// with the result.  On failure the current store is kept and the error is returned.  The reload is
+// reported to the parser's Metrics, if any.
+func (h *Holder) Reload(r io.Reader) error {
+	return h.ReloadContext(context.Background(), r)
+}
+
+// ReloadContext is to [Holder.Reload] what [Parser.ParseContext] is to [Parser.Parse].
+func (h *Holder) ReloadContext(ctx context.Context, r io.Reader) error {
+	return h.reload(func() (*Store, error) { return h.parser.ParseContext(ctx, r) })
+}
+
+// reload updates the holder with the store from load, if any, and reports the reload.
+func (h *Holder) reload(load func() (*Store, error)) error {
+	store, err := load()
+	if err == nil {
+		h.Update(store)
+	}
+	if h.parser.Metrics != nil {
+		h.parser.Metrics.Reloaded(err)
+	}
+	return err
+}
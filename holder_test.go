@@ -0,0 +1,79 @@
+package ini
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestHolder(t *testing.T) {
+	p := NewParser()
+	s := p.AddSection("sect")
+	a := s.AddInt64("a")
+	b := s.AddString("b")
+	store, err := p.Parse(strings.NewReader("[sect]\na = 1\nb = x\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewHolder(p, store)
+	var changes []string
+	h.OnChange(a, func(old, new any) {
+		changes = append(changes, "a")
+		if old != int64(1) || new != int64(2) {
+			t.Fatal(old, new)
+		}
+	})
+	h.OnChange(b, func(old, new any) {
+		changes = append(changes, "b")
+	})
+
+	if err := h.Reload(strings.NewReader("[sect]\na = 2\nb = x\n")); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0] != "a" || h.Store().GetInt64("sect.a") != 2 {
+		t.Fatal(changes)
+	}
+	current := h.Store()
+	if err := h.Reload(strings.NewReader("[sect]\na = x\n")); err == nil || h.Store() != current {
+		t.Fatal("Should keep store")
+	}
+
+	// Concurrent readers and updaters
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				h.Store().GetString("sect.b")
+				h.Update(current)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The functions can call the holder's methods
+	h = NewHolder(p, store)
+	reconfigured := false
+	h.OnChange(a, func(old, new any) {
+		h.OnChange(b, func(old, new any) {})
+		if err := h.Reload(strings.NewReader("[sect]\na = 3\nb = y\n")); err != nil {
+			t.Error(err)
+		}
+		reconfigured = true
+	})
+	if err := h.Reload(strings.NewReader("[sect]\na = 2\n")); err != nil {
+		t.Fatal(err)
+	}
+	if !reconfigured || h.Store().GetString("sect.b") != "y" {
+		t.Fatal("Not reconfigured")
+	}
+
+	q := NewParser()
+	q.AddSection("sect")
+	other, _ := q.Parse(strings.NewReader(""))
+	expectPanic(t, "Store is from a different parser", func() { h.Update(other) })
+	expectPanic(t, "Field is from a different parser", func() {
+		h.OnChange(q.Section("sect").AddString("c"), nil)
+	})
+}
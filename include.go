@@ -0,0 +1,150 @@
+package ini
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// An Includer opens the input named by an `@include` directive, see the package comment.  The
+// reference is the text of the directive after quote stripping and variable expansion.  The
+// returned reader is closed when the included input has been read.
+type Includer interface {
+	Open(ref string) (io.ReadCloser, error)
+}
+
+// IncluderFunc adapts a function to the [Includer] interface, eg to fetch included inputs over
+// HTTP or from a configuration service.
+type IncluderFunc func(ref string) (io.ReadCloser, error)
+
+// Open calls f(ref).
+func (f IncluderFunc) Open(ref string) (io.ReadCloser, error) {
+	return f(ref)
+}
+
+// FSIncluder returns an [Includer] that opens references as paths in fsys, eg an embed.FS or the
+// result of os.DirFS.  References must be valid paths for fsys, see [fs.ValidPath].
+func FSIncluder(fsys fs.FS) Includer {
+	return IncluderFunc(func(ref string) (io.ReadCloser, error) {
+		return fsys.Open(ref)
+	})
+}
+
+// maxIncludeDepth is the maximum nesting of included inputs.
+const maxIncludeDepth = 32
+
+// An includeStack is a tokenSource that delivers the tokens of a stack of inputs, reading from the
+// innermost one, so that the lines of an included input take the place of its `@include`.
+type includeStack struct {
+	frames []includeFrame    // Innermost last
+	onFile func(name string) // Called with the name of the input when it changes, if not nil
+}
+
+type includeFrame struct {
+	src    tokenSource
+	name   string    // The name of the input, or "" if not known
+	closer io.Closer // nil for the outermost input
+}
+
+func (stack *includeStack) top() *includeFrame {
+	return &stack.frames[len(stack.frames)-1]
+}
+
+func (stack *includeStack) Scan() bool {
+	for {
+		top := stack.top()
+		if top.src.Scan() {
+			return true
+		}
+		if top.src.Err() != nil || len(stack.frames) == 1 {
+			return false
+		}
+		stack.pop()
+		if stack.onFile != nil {
+			stack.onFile(stack.top().name)
+		}
+	}
+}
+
+func (stack *includeStack) Token() Token {
+	return stack.top().src.Token()
+}
+
+func (stack *includeStack) Err() error {
+	return stack.top().src.Err()
+}
+
+func (stack *includeStack) pop() {
+	stack.top().closer.Close()
+	stack.frames = stack.frames[:len(stack.frames)-1]
+}
+
+// attribute sets the File of the ParseError in err, if any, to the name of the current input if
+// that is an included one, and returns err.
+func (stack *includeStack) attribute(err error) error {
+	var pe *ParseError
+	if errors.As(err, &pe) && pe.File == "" && len(stack.frames) > 1 {
+		pe.File = stack.top().name
+	}
+	return err
+}
+
+// close closes the included inputs that are still open.
+func (stack *includeStack) close() {
+	for len(stack.frames) > 1 {
+		stack.pop()
+	}
+}
+
+// A fileHandler is an EventHandler that wants to know the name of the input that subsequent
+// events come from when it changes because of `@include`.
+type fileHandler interface {
+	fileChanged(name string)
+}
+
+// include processes an `@include` directive by pushing the included input onto the stack.
+func (parser *Parser) include(tok Token, stack *includeStack, sectName string) error {
+	ref, err := parser.value(tok.Value, stack.top().name)
+	if err != nil {
+		return parseFail(tok.Line, sectName, "%v", err)
+	}
+	if ref == "" {
+		return parseFail(tok.Line, sectName, "Missing name after @include")
+	}
+	if len(stack.frames) > maxIncludeDepth {
+		return parseFail(tok.Line, sectName, "Includes nested too deeply, the limit is %d",
+			maxIncludeDepth)
+	}
+	name := ref
+	var r io.ReadCloser
+	switch {
+	case parser.Includer != nil:
+		r, err = parser.Includer.Open(ref)
+	case stack.frames[0].name != "" && !isURL(stack.frames[0].name):
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(filepath.Dir(stack.top().name), name)
+		}
+		r, err = os.Open(name)
+	default:
+		return parseFail(tok.Line, sectName, "@include requires an Includer for input that is not a file")
+	}
+	if err != nil {
+		return parseFail(tok.Line, sectName, "Cannot include %s: %v", ref, err).wrap(err)
+	}
+	if slices.ContainsFunc(stack.frames, func(f includeFrame) bool { return f.name == name }) {
+		r.Close()
+		return parseFail(tok.Line, sectName, "Include cycle through %s", ref)
+	}
+	stack.frames = append(stack.frames, includeFrame{
+		src:    parser.newScanner(r),
+		name:   name,
+		closer: r,
+	})
+	if stack.onFile != nil {
+		stack.onFile(name)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package ini
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestInclude(t *testing.T) {
+	fsys := fstest.MapFS{
+		"db.ini":    {Data: []byte("host = h\n@include port.ini\n")},
+		"port.ini":  {Data: []byte("port = 5432\n")},
+		"other.ini": {Data: []byte("[other]\nx = 1\n")},
+		"loop.ini":  {Data: []byte("@include loop.ini\n")},
+		"bad.ini":   {Data: []byte("host = 1\n?\n")},
+	}
+	newParser := func(options ...any) *Parser {
+		p := NewParser(append(options, WithIncluder(FSIncluder(fsys)))...)
+		db := p.AddSection("db")
+		db.AddString("host")
+		db.AddInt64("port")
+		p.AddSection("other").AddInt64("x")
+		return p
+	}
+
+	p := newParser()
+	store, err := p.Parse(strings.NewReader("[db]\n@include \"db.ini\"\n[other]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.GetString("db.host") != "h" || store.GetInt64("db.port") != 5432 {
+		t.Fatal(store.GetString("db.host"), store.GetInt64("db.port"))
+	}
+	if o := p.Section("db").Field("port").Origin(store); o.File != "port.ini" || o.Line != 1 {
+		t.Fatal(o)
+	}
+
+	// Includes in inactive conditional regions are skipped
+	p = newParser(WithFacts(map[string]string{"os": "linux"}))
+	store, err = p.Parse(strings.NewReader(
+		"@if os == windows\n@include missing.ini\n@else\n@include other.ini\n@endif\n"))
+	if err != nil || store.GetInt64("other.x") != 1 {
+		t.Fatal(err)
+	}
+
+	p = newParser()
+	for _, tc := range []struct{ input, want string }{
+		{"@include loop.ini\n", "Line 1 of loop.ini: Include cycle through loop.ini"},
+		{"[db]\n@include bad.ini\n", "Line 2 of bad.ini: In section db: Invalid syntax"},
+		{"[db]\n@include\n", "Line 2: In section db: Missing name after @include"},
+	} {
+		_, err := p.Parse(strings.NewReader(tc.input))
+		if err == nil || err.Error() != tc.want {
+			t.Fatal(tc.input, err)
+		}
+	}
+	_, err = p.Parse(strings.NewReader("@include missing.ini\n"))
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Line != 1 || !errors.Is(err, fs.ErrNotExist) {
+		t.Fatal(err)
+	}
+
+	// Without an Includer only files can include
+	_, err = NewParser().Parse(strings.NewReader("@include x.ini\n"))
+	if err == nil || err.Error() != "Line 1: @include requires an Includer for input that is not a file" {
+		t.Fatal(err)
+	}
+}
+
+func TestIncludeFiles(t *testing.T) {
+	dir := t.TempDir()
+	os.Mkdir(filepath.Join(dir, "conf.d"), 0o755)
+	os.WriteFile(filepath.Join(dir, "main.ini"), []byte("[a]\n@include conf.d/x.ini\ny = 2\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "conf.d", "x.ini"), []byte("@include z.ini\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "conf.d", "z.ini"), []byte("x = 1\n"), 0o644)
+
+	p := NewParser()
+	a := p.AddSection("a")
+	a.AddInt64("x")
+	a.AddInt64("y")
+	store, err := p.ParseFile(filepath.Join(dir, "main.ini"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.GetInt64("a.x") != 1 || store.GetInt64("a.y") != 2 {
+		t.Fatal(store.GetInt64("a.x"), store.GetInt64("a.y"))
+	}
+	o := a.Field("x").Origin(store)
+	if o.File != filepath.Join(dir, "conf.d", "z.ini") || o.Line != 1 {
+		t.Fatal(o)
+	}
+	if o := a.Field("y").Origin(store); o.File != filepath.Join(dir, "main.ini") || o.Line != 3 {
+		t.Fatal(o)
+	}
+}
+
+func TestIncludeClosed(t *testing.T) {
+	var opened, closed int
+	inc := IncluderFunc(func(ref string) (io.ReadCloser, error) {
+		opened++
+		return &closeCounter{strings.NewReader("[a]\n"), &closed}, nil
+	})
+	p := NewParser(WithIncluder(inc))
+	p.AddSection("a")
+	if _, err := p.Parse(strings.NewReader("@include x\n@include y\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Parse(strings.NewReader("@include x\n?\n")); err == nil {
+		t.Fatal("Expected an error")
+	}
+	if opened != 3 || closed != 3 {
+		t.Fatal(opened, closed)
+	}
+}
+
+type closeCounter struct {
+	io.Reader
+	closed *int
+}
+
+func (c *closeCounter) Close() error {
+	*c.closed++
+	return nil
+}
@@ -2,59 +2,128 @@
 //
 // # Syntax
 //
-// An ini file is line oriented.  It has a number of sections, each starting with a `[section-name]`
-// header.  Within each section is a sequence of field settings, each on the form name=value.
-// Blank lines are skipped.  Lines whose first nonblank is CommentChar (default `#`) are skipped.
-// There can be blanks at the beginning and end of all lines and on either side of the `=`, and
-// inside the brackets of the header. Section and field names must conform to `[-a-zA-Z0-9_$]+`, and
-// are case-sensitive.
+// An ini file is line oriented, with lines ending in `\n`, `\r\n`, or (unless CRBreaks is false) a
+// lone `\r`; the line breaks are never part of the lines.  It has a number of sections, each
+// starting with a `[section-name]` header.  Within each section is a sequence of field settings,
+// each on the form name=value.  Blank lines are skipped.  Lines whose first nonblank is
+// CommentChar (default `#`) are skipped.  There can be blanks at the beginning and end of all
+// lines and on either side of the `=`, and inside the brackets of the header. Section and field
+// names must conform to `[-a-zA-Z0-9_$]+`, and are case-sensitive.  A header can also have the
+// form `[section-name:profile]` to start a profile section, whose settings override those of the
+// section for the active Profile and are ignored for other profiles.  A header can be repeated,
+// and the settings that follow it continue the section, unless DuplicateSections is
+// RejectSections.  The input is UTF-8, unless a Decoder transcodes it, blanks are any Unicode
+// white space, and a byte order mark at the start of the input is ignored.
+//
+// If Facts is not nil (default nil) then lines can be made conditional with directives that test
+// the facts, typically properties of the platform:
+//
+//	@if os == linux
+//	path = /usr/local/lib
+//	@elif os != windows
+//	path = /opt/lib
+//	@else
+//	path = C:\Lib
+//	@endif
+//
+// The lines following a directive are processed only if its condition is the first true one in
+// its `@if`, and conditionals can be nested.  A condition has the form `fact == value` or
+// `fact != value`, where a fact that is not in Facts has the value "" and the value can be quoted.
+//
+// The directive `@include name` reads the named input in its place, as if its lines appeared
+// there, so an included input can continue the current section.  The name can be quoted and is
+// subject to variable expansion.  By default the name is a file name relative to the directory of
+// the including file, but an [Includer] can take the inputs from anywhere, eg an embed.FS (see
+// [FSIncluder]) or an HTTP server.  Includes can be nested, but not recursive.
 //
 // The fields are typed, the value must conform to the type, though blank values are accepted for
-// strings (empty string) and booleans (true).  All values can be quoted with matching quotes
-// according to QuoteChar (default `"`), the quotes are stripped.  Set QuoteChar to 0 to disable all
-// quote stripping.  Leading and trailing blanks of the value (outside any quotes) are always
-// stripped.
+// strings (empty string) and booleans (true).  Booleans are `true` or `false`, or if BoolSynonyms
+// is true (default false) also `yes`/`no`, `on`/`off`, or `1`/`0`, in any case.  Numbers are
+// written as in Go, or if NumberFormat is set (default none) also with its decimal and thousands
+// separators, eg `3,14`.  In the values of path fields, added with [Section.AddPath], a leading `~`
+// or `~user` stands for the home directory, as in the shell: `cache_dir = ~/.cache/app`, and
+// relative paths can be resolved against the directory of the file, see [Field.NormalizePath].  All
+// values can be quoted with matching quotes according to QuoteChar (default `"`), the quotes are
+// stripped.  Set QuoteChar to 0 to disable all quote stripping.  Leading and trailing blanks of the
+// value (outside any quotes) are always stripped.  If Escapes is true (default false), backslash
+// escapes are processed in values quoted with QuoteChar.  Values can also be quoted with
+// LiteralQuoteChar (default none, but `'` is a natural choice), which makes them completely
+// literal, like single quotes in the shell.
+//
+// The values of list fields are sequences of elements separated by ListDelim (default `,`), eg
+// `names = a, b, c`.  Quoting applies to each element, not to the value as a whole, so an element
+// that contains ListDelim must be quoted: `names = "Smith, J", "Doe, J"`.  The values of map fields
+// are lists of `key:value` elements, eg `limits = cpu:2, mem:512`.  List and map fields exist for
+// all the primitive types, eg [Section.AddInt64List] and [Section.AddBoolMap], and for any other
+// element type with [AddListOf] and [AddMapOf].  Maps can also be written as inline tables, eg
+// `limits = { cpu = 2, mem = 512 }`, and so can the values of record fields, which are added with
+// [AddRecord] and have a fixed set of settings.  The elements of record list fields, added with
+// [AddRecordList], are records too: `servers = {host = a, port = 1}, {host = b}`.
 //
 // Environment variable references in the values will be expanded if ExpandVars is true (default
-// false).  Variables match the syntax `$[a-zA-Z0-9_]+` or `${[^}]+}`, e.g. `$HOME` or `${HOME AGAIN?}`.
-// Variables that are not bound in the environment are replaced by the empty string.  A `$` can be
-// doubled to remove its metacharacter meaning: `$$HOME` expands to `$HOME`.  Replacement text is not
-// subject to further expansion.  Expansion takes place before blank and quote stripping and value
-// interpretation, and is not affected by quoting.
+// false).  Variables match the syntax `$[a-zA-Z0-9_]+` or `${[^}]+}`, e.g. `$HOME` or
+// `${HOME AGAIN?}`.  Variables that are not bound in the environment are replaced by the empty
+// string, unless UnboundVars says to leave the reference as it is or to report an error.  The
+// built-in variables `__FILE__` and `__DIR__`, the name and directory of the file that contains the
+// value (unbound if the input is not a file), `__HOSTNAME__`, and `__PID__`, the process ID, take
+// precedence over the environment.  A `$` can be doubled to remove its metacharacter meaning:
+// `$$HOME` expands to `$HOME`.  Replacement text is not subject to further expansion unless
+// RecursiveVars is true, in which case references in the values of variables are expanded in turn,
+// to a depth of 8.  If VarSyntax is PercentVars then references instead have the form `%NAME%`, as
+// in Windows files, and `%%` is a literal `%`.  Expansion takes place before blank and quote
+// stripping and value interpretation, and is not affected by QuoteChar quoting, but values quoted
+// with LiteralQuoteChar are not expanded.  Expansion can be enabled or disabled for the fields of a
+// section with [Section.ExpandVars] and for a single field with [Field.ExpandVars].
 //
 // # Usage
 //
-// Create an ini parser with [NewParser] and customize any variables.  Then add a new [Section] to
-// it with [Parser.AddSection].  Add a new [Field] to the section with `Section.Add<Type>()` for
-// pre-defined types, eg [Section.AddString], or the general [Section.Add] for user-defined types or
-// non-standard default values or parsing.
+// Create an ini parser with [NewParser], passing [Option] values such as [WithCommentChar], or
+// customize any variables.  Then add a new [Section] to it with [Parser.AddSection].  Add a new
+// [Field] to the section with `Section.Add<Type>()` for pre-defined types, eg [Section.AddString],
+// or the general [Section.Add] for user-defined types or non-standard default values or parsing.
+// Types registered with [RegisterType], and the builtin types, can also be added by name with
+// [Section.AddTyped].  Alternatively, define a section and its fields with chained calls on the
+// [SectionBuilder] returned by [Parser.Define], or load a whole schema from a JSON description
+// with [LoadSchema].
 //
 // Parse an input stream with [Parser.Parse].  This will return a [Store] (or an error).  Access
-// field values via the Field objects on the Store, or directly on the Store itself.
+// field values via the Field objects on the Store, or directly on the Store itself.  For simple
+// programs, fields added with `Section.Add<Type>Var()` instead have their values stored directly
+// into program variables, much as for the flag package.  To process very large inputs, or to
+// transform ini files, use [Parser.ParseEvents], which delivers the input's contents to a handler
+// without checking them against the sections and fields or storing them.  To edit ini files
+// without losing their comments and layout, use [Parser.ParseDocument].
+//
+// Configuration that comes from several files, eg system-wide and per-user files, can be combined
+// with [Merge], which records the source of each value.  Configuration served over HTTP can be
+// loaded with [LoadURL], and kept up to date in a [Holder] with a [Poller].  Use [Preview] to
+// check a new configuration and see what it would change before reloading it.
+//
+// Configuration files that outlive their schemas can carry a version number, see
+// [Parser.Version], and old files are then upgraded by the migrations added with
+// [Parser.AddMigration] as they are parsed.
 //
 // # Errors
 //
 // Errors during creation of the parser are considered programming errors and uniformly result in a
 // panic.  Errors during parsing are considered input errors and are surfaced as an error return
-// from [Parser.Parse].
+// from [Parser.Parse].  If the parser's Lenient is true then undefined sections and fields in the
+// input are not errors, but are kept in the store for the program to warn about or pass on, see
+// [Store.Unknown].
 package ini
 
 import (
-	"bufio"
+	"context"
+	"encoding"
+	"flag"
 	"fmt"
 	"io"
-	"maps"
 	"os"
-	"regexp"
+	"reflect"
 	"slices"
 	"strconv"
 	"strings"
-)
-
-var (
-	nameRe = regexp.MustCompile(`^[-a-zA-Z0-9_$]+$`)
-	valRe  = regexp.MustCompile(`^\s*([-a-zA-Z0-9_$]+)\s*=(.*)$`)
-	varRe  = regexp.MustCompile(`\$\$|\$[a-zA-Z0-9_]+|\$\{[^}]*\}`)
+	"time"
 )
 
 // A FieldTy describes the type of the field.
@@ -69,11 +138,22 @@ const (
 	TyUser                       // The field is a user-defined type (for this and higher values)
 )
 
+// A DuplicateSections value selects how the parser treats a section header that repeats an earlier
+// one.
+type DuplicateSections int
+
+const (
+	MergeSections  DuplicateSections = iota // The settings continue to fill the same section
+	RejectSections                          // The repeated header is a parse error
+)
+
 // A ParseError describes an error encountered during parsing with its location and nature.
 type ParseError struct {
-	Line     int    // The line number in the input where the error was discovered
+	File     string // The name of the included input where the error was discovered, or ""
+	Line     int    // The line number in the input where the error was discovered, or 0
 	Section  string // The section name context, if not ""
 	Irritant string // Informative text and context
+	Err      error  // The underlying error, if the parse failed because of I/O or cancellation
 }
 
 func parseFail(line int, section string, format string, args ...any) *ParseError {
@@ -84,11 +164,45 @@ func parseFail(line int, section string, format string, args ...any) *ParseError
 	}
 }
 
+func (pe *ParseError) wrap(err error) *ParseError {
+	pe.Err = err
+	return pe
+}
+
+// Unwrap returns the underlying error, if any.
+func (pe *ParseError) Unwrap() error {
+	return pe.Err
+}
+
 func (pe *ParseError) Error() string {
+	var where string
+	if pe.Line != 0 {
+		where = fmt.Sprintf("Line %d: ", pe.Line)
+		if pe.File != "" {
+			where = fmt.Sprintf("Line %d of %s: ", pe.Line, pe.File)
+		}
+	}
 	if pe.Section != "" {
-		return fmt.Sprintf("Line %d: In section %s: %s", pe.Line, pe.Section, pe.Irritant)
+		return fmt.Sprintf("%sIn section %s: %s", where, pe.Section, pe.Irritant)
 	}
-	return fmt.Sprintf("Line %d: %s", pe.Line, pe.Irritant)
+	return where + pe.Irritant
+}
+
+// A Resolver maps values in the input to the values that are parsed for their fields, typically
+// by resolving references to external secret stores, eg `secret://vault/path#key` or
+// `file:/run/secrets/db_password`.  Resolve is called with the value after variable expansion and
+// blank and quote stripping, and must return the value unchanged if it is not a reference the
+// resolver handles.  An error return is reported as a parse error for the field.
+type Resolver interface {
+	Resolve(value string) (string, error)
+}
+
+// ResolverFunc is an adapter that allows an ordinary function to be used as a [Resolver].
+type ResolverFunc func(value string) (string, error)
+
+// Resolve calls f(value).
+func (f ResolverFunc) Resolve(value string) (string, error) {
+	return f(value)
 }
 
 // A Parser holds the structure of the ini file and its parsing options, and performs parsing.
@@ -102,28 +216,198 @@ type Parser struct {
 	// stripping to happen).  Set to 0 to disable quote stripping.
 	QuoteChar rune
 
+	// LiteralQuoteChar is a second character for quoting values (default 0, meaning none): values
+	// whose first and last nonblank match LiteralQuoteChar are stripped of those chars and are
+	// otherwise taken literally, with no variable expansion or escape processing, like single
+	// quotes in the shell.
+	LiteralQuoteChar rune
+
+	// Escapes controls backslash escapes in values quoted with QuoteChar (default false): if true,
+	// `\n` and `\t` denote newline and tab, and a backslash followed by any other character, such
+	// as QuoteChar or backslash, denotes that character.
+	Escapes bool
+
+	// RequireQuotes controls whether values that contain CommentChar or `=` must be quoted (default
+	// false): if true, such values are a parse error unless quoted.  Neither character has any
+	// special meaning in a value, but a value like `a#b` looks like it might.  In a record or an
+	// inline table in braces, or a list of records, the `=` are part of the syntax and only the
+	// names and values within it must be quoted.
+	RequireQuotes bool
+
+	// ListDelim is the character that separates the elements of the values of list fields, such as
+	// those added with AddStringList (default ',').  See [Section.AddStringList].
+	ListDelim rune
+
+	// IntPrefixes controls the syntax of the values of fields added with AddInt64 and AddUint64
+	// (default false): if true, they accept base prefixes and digit separators, see
+	// [ParseInt64Prefixed].
+	IntPrefixes bool
+
+	// BoolSynonyms controls the syntax of the values of fields added with AddBool and AddBoolVar
+	// (default false): if true, they also accept yes/no, on/off and 1/0, and all words are
+	// case-insensitive, see [ParseBoolSynonyms].
+	BoolSynonyms bool
+
+	// NumberFormat gives the decimal and thousands separators that the values of numeric fields may
+	// use, eg NumbersComma for input like `3,14` (default the zero value, meaning Go's syntax
+	// only).  Values that are not valid in the format are parsed as if it were the zero value, so
+	// that `3.14` is accepted too unless '.' is the thousands separator.  A ListDelim that is a
+	// separator of the format must be changed for the elements of numeric lists to be read.
+	NumberFormat NumberFormat
+
 	// ExpandVars controls the expansion of environment variables in values (default false): if
-	// true, environment variable references are replaced by their values.
+	// true, environment variable references are replaced by their values.  Sections and fields can
+	// override it, see [Section.ExpandVars] and [Field.ExpandVars].
 	ExpandVars bool
 
+	// UnboundVars selects the treatment of references to variables that are not bound (default
+	// UnboundEmpty), see [UnboundVars].
+	UnboundVars UnboundVars
+
+	// VarSyntax is the syntax of variable references (default ShellVars), see [VarSyntax].
+	VarSyntax VarSyntax
+
+	// RecursiveVars controls whether the values of variables are themselves expanded (default
+	// false).  If true, references in the values are expanded, to a limited depth.
+	RecursiveVars bool
+
+	// Profile is the active profile (default ""): the settings of profile sections of the form
+	// `[name:profile]` override those of the section `[name]` if the profile is the active one, and
+	// are ignored otherwise.
+	Profile string
+
+	// Facts enables conditional directives if not nil (default nil), and holds the facts that
+	// their conditions test.  See the package comment.
+	Facts map[string]string
+
+	// Includer, if not nil, opens the inputs named by `@include` directives (default nil, meaning
+	// that they are files relative to the including file, and are errors in inputs that are not
+	// files).  See [Includer].
+	Includer Includer
+
+	// CaptureComments controls whether the comments adjacent to settings and section headers are
+	// kept in the store (default false): if true, see [Store.CommentFor].
+	CaptureComments bool
+
+	// LineHook, if not nil, is called with the number and text of every input line before it is
+	// interpreted (default nil), and can rewrite or drop the line, see [Scanner].
+	LineHook func(lineno int, line string) (string, bool)
+
+	// OnUnknownSection, if not nil, is called during parsing with the name of a section that is
+	// not defined (default nil).  It can define the section with AddSection and add fields to it,
+	// eg to synthesize the schema for `[plugin-foo]` when plugin foo is loaded, and return it,
+	// or return nil to leave the section undefined, which is an error.  A parser that defines
+	// sections this way must not be used for concurrent parses.
+	OnUnknownSection func(name string) *Section
+
+	// OnOverride, if not nil, is called during parsing when a setting assigns a field that an
+	// earlier setting in the input has assigned (default nil), with the line of the later setting
+	// and the old and new values as they appear in the input after variable expansion and quote
+	// stripping, or [Redacted] for a secret field.  Such a setting silently replaces the earlier
+	// value if OnOverride is nil.  OnOverride can log a warning, or return an error to reject the
+	// input.  Settings in a profile section override those in its base section without calling
+	// OnOverride.
+	OnOverride func(field *Field, line int, old, new string) error
+
+	// Metrics, if not nil, receives reports of every parse and reload (default nil).  See
+	// [Metrics].
+	Metrics Metrics
+
+	// Lenient controls whether undefined sections and fields are accepted (default false).  If
+	// true, they are not errors but are kept in the store, see [Store.Unknown].  Invalid values
+	// of defined fields are still errors.
+	Lenient bool
+
+	// Resolver, if not nil, is applied to every value of fields that do not have their own
+	// resolver (default nil).  See [Resolver].
+	Resolver Resolver
+
+	// CronValidator, if not nil, validates the values of cron fields, added with AddCron, in place
+	// of ParseCron (default nil): it returns an error if the expression, with its blanks
+	// normalized, is not valid.  Use it to accept the extensions of the scheduler that runs the
+	// jobs, eg by calling the scheduler's parser.
+	CronValidator func(expr string) error
+
+	// MaxLineLen is the maximum length in bytes of an input line, not counting the line break
+	// (default 0, meaning no limit).  Longer lines are a parse error.
+	MaxLineLen int
+
+	// MaxInputSize is the maximum number of bytes that will be read from the input (default 0,
+	// meaning no limit).  Larger inputs are a parse error.
+	MaxInputSize int
+
+	// MaxSections is the maximum number of section headers in the input (default 0, meaning no
+	// limit).  More headers are a parse error.
+	MaxSections int
+
+	// MaxSettings is the maximum number of field settings in the input (default 0, meaning no
+	// limit).  More settings are a parse error.
+	MaxSettings int
+
+	// CRBreaks controls whether a lone carriage return, as used by classic Mac OS, ends a line
+	// (default true).  "\n" and "\r\n" always end lines, and line breaks are never part of values.
+	// If false, a lone "\r" is part of the line.
+	CRBreaks bool
+
+	// DuplicateSections selects the treatment of a section header that repeats an earlier one
+	// (default MergeSections): with MergeSections the settings that follow it are added to the
+	// section as if the header had not been repeated, with RejectSections the header is an error.
+	// A profile section does not repeat its base section.
+	DuplicateSections DuplicateSections
+
+	// Decoder, if not nil, transcodes the input to UTF-8 (default nil, meaning the input is UTF-8):
+	// it is called with each input and returns a reader of the transcoded text.  DecodeLatin1 and
+	// DecodeWindows1252 handle common legacy encodings, and the Reader method of a
+	// golang.org/x/text/encoding Decoder handles many others.  MaxInputSize applies to the input
+	// before transcoding.  Documents (see [Parser.ParseDocument]) are transcoded too and are
+	// written as UTF-8.
+	Decoder func(r io.Reader) io.Reader
+
+	// RequireUTF8 controls whether lines that are not valid UTF-8, after any transcoding by Decoder,
+	// are a parse error (default false).  If false then such lines are accepted, and any values in
+	// them are not valid UTF-8.
+	RequireUTF8 bool
+
+	// NameMapper, if not nil, maps the names of untagged struct members to the names of fields and
+	// sections for [Store.Decode] and [Section.Decode] (default nil).  If nil, a member matches the
+	// field or section whose name equals the member's name when case and the characters `_` and `-`
+	// are ignored, so that a member LogLevel matches log_level, log-level and loglevel.  SnakeCase
+	// and KebabCase map names to the common conventions.
+	NameMapper func(member string) string
+
 	sections map[string]*Section
+	order    []*Section // The sections in declaration order
+
+	constraints []constraint // See MutuallyExclusive and Requires
+
+	versionPath string // The version field, see Version
+	version     int    // The current schema version
+	migrations  map[int]*migration
 }
 
 // Make a new, empty parser with default settings.  If options are present they are used to alter
-// the settings.  Each option is a pair: a string keyword and a value of the appropriate type.  The
-// keywords are the exact option member names, eg, "CommentChar".
+// the settings, in order.  Each option is either an [Option], eg WithCommentChar(';'), or a pair: a
+// string keyword and a value of the appropriate type.  The keywords are the exact option member
+// names, eg, "CommentChar".  Prefer the Options, whose mistakes are caught by the compiler.
 func NewParser(options ...any) *Parser {
 	p := &Parser{
 		CommentChar: '#',
 		QuoteChar:   '"',
+		ListDelim:   ',',
 		ExpandVars:  false,
+		CRBreaks:    true,
 		sections:    make(map[string]*Section),
 	}
-	if len(options)%2 != 0 {
-		panic("Bad options: must be keyword / value pairs")
-	}
 	i := 0
 	for i < len(options) {
+		if opt, ok := options[i].(Option); ok {
+			opt(p)
+			i++
+			continue
+		}
+		if i+1 == len(options) {
+			panic("Bad options: must be Options or keyword / value pairs")
+		}
 		k := options[i]
 		v := options[i+1]
 		i += 2
@@ -139,11 +423,155 @@ func NewParser(options ...any) *Parser {
 					p.QuoteChar = val
 					continue
 				}
+			case "ListDelim":
+				if val, ok := v.(rune); ok {
+					p.ListDelim = val
+					continue
+				}
+			case "LiteralQuoteChar":
+				if val, ok := v.(rune); ok {
+					p.LiteralQuoteChar = val
+					continue
+				}
+			case "Escapes":
+				if val, ok := v.(bool); ok {
+					p.Escapes = val
+					continue
+				}
+			case "RequireQuotes":
+				if val, ok := v.(bool); ok {
+					p.RequireQuotes = val
+					continue
+				}
+			case "IntPrefixes":
+				if val, ok := v.(bool); ok {
+					p.IntPrefixes = val
+					continue
+				}
+			case "BoolSynonyms":
+				if val, ok := v.(bool); ok {
+					p.BoolSynonyms = val
+					continue
+				}
+			case "NumberFormat":
+				if val, ok := v.(NumberFormat); ok {
+					p.NumberFormat = val
+					continue
+				}
+			case "Profile":
+				if val, ok := v.(string); ok {
+					p.Profile = val
+					continue
+				}
+			case "Facts":
+				if val, ok := v.(map[string]string); ok {
+					p.Facts = val
+					continue
+				}
+			case "CaptureComments":
+				if val, ok := v.(bool); ok {
+					p.CaptureComments = val
+					continue
+				}
+			case "LineHook":
+				if val, ok := v.(func(int, string) (string, bool)); ok {
+					p.LineHook = val
+					continue
+				}
+			case "OnOverride":
+				if val, ok := v.(func(*Field, int, string, string) error); ok {
+					p.OnOverride = val
+					continue
+				}
+			case "OnUnknownSection":
+				if val, ok := v.(func(string) *Section); ok {
+					p.OnUnknownSection = val
+					continue
+				}
+			case "Metrics":
+				if val, ok := v.(Metrics); ok {
+					p.Metrics = val
+					continue
+				}
+			case "Lenient":
+				if val, ok := v.(bool); ok {
+					p.Lenient = val
+					continue
+				}
 			case "ExpandVars":
 				if val, ok := v.(bool); ok {
 					p.ExpandVars = val
 					continue
 				}
+			case "RecursiveVars":
+				if val, ok := v.(bool); ok {
+					p.RecursiveVars = val
+					continue
+				}
+			case "CRBreaks":
+				if val, ok := v.(bool); ok {
+					p.CRBreaks = val
+					continue
+				}
+			case "UnboundVars":
+				if val, ok := v.(UnboundVars); ok {
+					p.UnboundVars = val
+					continue
+				}
+			case "VarSyntax":
+				if val, ok := v.(VarSyntax); ok {
+					p.VarSyntax = val
+					continue
+				}
+			case "DuplicateSections":
+				if val, ok := v.(DuplicateSections); ok {
+					p.DuplicateSections = val
+					continue
+				}
+			case "Decoder":
+				if val, ok := v.(func(io.Reader) io.Reader); ok {
+					p.Decoder = val
+					continue
+				}
+			case "RequireUTF8":
+				if val, ok := v.(bool); ok {
+					p.RequireUTF8 = val
+					continue
+				}
+			case "NameMapper":
+				if val, ok := v.(func(string) string); ok {
+					p.NameMapper = val
+					continue
+				}
+			case "Includer":
+				if val, ok := v.(Includer); ok {
+					p.Includer = val
+					continue
+				}
+			case "CronValidator":
+				if val, ok := v.(func(string) error); ok {
+					p.CronValidator = val
+					continue
+				}
+			case "Resolver":
+				if val, ok := v.(Resolver); ok {
+					p.Resolver = val
+					continue
+				}
+			case "MaxLineLen", "MaxInputSize", "MaxSections", "MaxSettings":
+				if val, ok := v.(int); ok && val >= 0 {
+					switch kwd {
+					case "MaxLineLen":
+						p.MaxLineLen = val
+					case "MaxInputSize":
+						p.MaxInputSize = val
+					case "MaxSections":
+						p.MaxSections = val
+					case "MaxSettings":
+						p.MaxSettings = val
+					}
+					continue
+				}
 			}
 		}
 		panic(fmt.Sprintf("Bad keyword / value combination %T %v / %T %v", k, k, v, v))
@@ -155,15 +583,16 @@ func NewParser(options ...any) *Parser {
 // not be present in the section already, and the name must be syntactically valid (see the package
 // documentation).
 func (parser *Parser) AddSection(name string) *Section {
-	if !nameRe.MatchString(name) {
+	if !isName(name) {
 		panic("Invalid section name " + name)
 	}
 	if parser.sections[name] != nil {
 		panic("Duplicated section name " + name)
 	}
 	fields := make(map[string]*Field)
-	s := &Section{parser, name, fields}
+	s := &Section{parser: parser, name: name, fields: fields}
 	parser.sections[name] = s
+	parser.order = append(parser.order, s)
 	return s
 }
 
@@ -172,18 +601,35 @@ func (parser *Parser) Section(name string) *Section {
 	return parser.sections[name]
 }
 
+// Sections returns the parser's sections in the order they were added.
+func (parser *Parser) Sections() []*Section {
+	return slices.Clone(parser.order)
+}
+
 // A Section is a named container for a set of fields.
 type Section struct {
 	parser *Parser
 	name   string
 	fields map[string]*Field
+	order  []*Field // The fields in declaration order
+	expand *bool    // The setting of ExpandVars, if not the parser's
+
+	required bool
 }
 
 // AddBool adds a new boolean field of the given name to the section.  The name must not be present
 // in the section and must be syntactically valid (see package comments).  ParseBool describes the
-// accepted values.  The default value is false.
+// accepted values, or ParseBoolSynonyms if the parser's BoolSynonyms is true.  The default value is
+// false.
 func (section *Section) AddBool(name string) *Field {
-	return section.Add(name, TyBool, false, ParseBool)
+	return section.Add(name, TyBool, false, section.parseBool)
+}
+
+func (section *Section) parseBool(s string) (any, bool) {
+	if section.parser.BoolSynonyms {
+		return ParseBoolSynonyms(s)
+	}
+	return ParseBool(s)
 }
 
 // ParseBool accepts any string representing a bool value, returning the value and a validity flag.
@@ -199,6 +645,19 @@ func ParseBool(s string) (any, bool) {
 	}
 }
 
+// ParseBoolSynonyms is like ParseBool but also accepts "yes", "on" and "1" as true values and "no",
+// "off" and "0" as false values, and ignores the case of letters.
+func ParseBoolSynonyms(s string) (any, bool) {
+	switch strings.ToLower(s) {
+	case "true", "yes", "on", "1", "":
+		return true, true
+	case "false", "no", "off", "0":
+		return false, true
+	default:
+		return false, false
+	}
+}
+
 // AddString adds a new string field of the given name to the section.  The name must not be present
 // in the section and must be syntactically valid (see package comments).  ParseString describes the
 // accepted values.  The default value is the empty string.
@@ -213,9 +672,17 @@ func ParseString(s string) (any, bool) {
 
 // AddInt64 adds a new int64 field of the given name to the section.  The name must not be present
 // in the section and must be syntactically valid (see package comments).  ParseInt64 describes the
-// accepted values.  The default value is zero.
+// accepted values, or ParseInt64Prefixed if the parser's IntPrefixes is true, and they may also be
+// written in the parser's NumberFormat.  The default value is zero.
 func (section *Section) AddInt64(name string) *Field {
-	return section.Add(name, TyInt64, int64(0), ParseInt64)
+	return section.Add(name, TyInt64, int64(0), section.parseInt64)
+}
+
+func (section *Section) parseInt64(s string) (any, bool) {
+	if section.parser.IntPrefixes {
+		return section.parser.parseNumber(s, ParseInt64Prefixed)
+	}
+	return section.parser.parseNumber(s, ParseInt64)
 }
 
 // ParseInt64 accepts any string representing a signed, decimal integer in the range of int64,
@@ -228,11 +695,29 @@ func ParseInt64(s string) (any, bool) {
 	return v, true
 }
 
+// ParseInt64Prefixed is like ParseInt64 but also accepts the base prefixes `0b`, `0o` or `0`, and
+// `0x` for binary, octal, and hexadecimal integers, and `_` between digits, as for Go literals.
+func ParseInt64Prefixed(s string) (any, bool) {
+	v, err := strconv.ParseInt(s, 0, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
 // AddUint64 adds a new uint64 field of the given name to the section.  The name must not be present
 // in the section and must be syntactically valid (see package comments).  ParseUint64 describes the
-// accepted values.  The default value is zero.
+// accepted values, or ParseUint64Prefixed if the parser's IntPrefixes is true, and they may also
+// be written in the parser's NumberFormat.  The default value is zero.
 func (section *Section) AddUint64(name string) *Field {
-	return section.Add(name, TyUint64, uint64(0), ParseUint64)
+	return section.Add(name, TyUint64, uint64(0), section.parseUint64)
+}
+
+func (section *Section) parseUint64(s string) (any, bool) {
+	if section.parser.IntPrefixes {
+		return section.parser.parseNumber(s, ParseUint64Prefixed)
+	}
+	return section.parser.parseNumber(s, ParseUint64)
 }
 
 // ParseUint64 accepts any string representing an unsigned, decimal integer in the range of uint64,
@@ -245,11 +730,26 @@ func ParseUint64(s string) (any, bool) {
 	return v, true
 }
 
+// ParseUint64Prefixed is like ParseUint64 but also accepts the base prefixes `0b`, `0o` or `0`, and
+// `0x` for binary, octal, and hexadecimal integers, and `_` between digits, as for Go literals.
+func ParseUint64Prefixed(s string) (any, bool) {
+	v, err := strconv.ParseUint(s, 0, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
 // AddFloat64 adds a new float64 field of the given name to the section.  The name must not be
 // present in the section and must be syntactically valid (see package comments).  ParseFloat64
-// describes the accepted values.  The default value is zero.
+// describes the accepted values, and they may also be written in the parser's NumberFormat.  The
+// default value is zero.
 func (section *Section) AddFloat64(name string) *Field {
-	return section.Add(name, TyFloat64, 0.0, ParseFloat64)
+	return section.Add(name, TyFloat64, 0.0, section.parseFloat64)
+}
+
+func (section *Section) parseFloat64(s string) (any, bool) {
+	return section.parser.parseNumber(s, ParseFloat64)
 }
 
 // ParseFloat64 accepts any string representing a signed, decimal floating-point value in the range
@@ -262,6 +762,60 @@ func ParseFloat64(s string) (any, bool) {
 	return v, true
 }
 
+// AddTextUnmarshaler adds a new field of the given name to the section whose value is parsed by
+// the UnmarshalText method of a fresh value obtained from newVal.  The name must not be present in
+// the section and must be syntactically valid (see package comments).  The field has type TyUser
+// and its value is the encoding.TextUnmarshaler returned by newVal, typically a pointer.  The
+// default value is a value returned by newVal that has not been unmarshaled into.
+//
+// This makes types such as netip.Addr, big.Int, or user-defined enums usable without writing a
+// parsing function, eg
+//
+//	s.AddTextUnmarshaler("addr", func() encoding.TextUnmarshaler { return new(netip.Addr) })
+func (section *Section) AddTextUnmarshaler(
+	name string,
+	newVal func() encoding.TextUnmarshaler,
+) *Field {
+	return section.Add(name, TyUser, newVal(), func(s string) (any, bool) {
+		v := newVal()
+		if err := v.UnmarshalText([]byte(s)); err != nil {
+			return nil, false
+		}
+		return v, true
+	})
+}
+
+// AddFlagValue adds a new field of the given name to the section whose values are parsed by the Set
+// method of v's type, so that custom flag types can be reused in the configuration, and arranges
+// for every successful parse to store the field's value in v.  The name must not be present in the
+// section and must be syntactically valid (see package comments).  The field has type TyUser and
+// its values are flag.Values of v's type, which must be a pointer type: each value is parsed into a
+// new zero value of the pointed-to type, as the flag package does to compute defaults, so Set must
+// accept such values, and is stored by copying it into *v.  The default value is a copy of *v at
+// the time of the call.
+func (section *Section) AddFlagValue(name string, v flag.Value) *Field {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		panic("AddFlagValue with a non-pointer value for field " + name)
+	}
+	newVal := func() reflect.Value {
+		return reflect.New(rv.Type().Elem())
+	}
+	defaultValue := newVal()
+	defaultValue.Elem().Set(rv.Elem())
+	field := section.Add(name, TyUser, defaultValue.Interface(), func(s string) (any, bool) {
+		val := newVal().Interface().(flag.Value)
+		if err := val.Set(s); err != nil {
+			return nil, false
+		}
+		return val, true
+	})
+	field.dest = func(val any) {
+		rv.Elem().Set(reflect.ValueOf(val).Elem())
+	}
+	return field
+}
+
 // Add adds a field of the given name to the section.  The name must not be present in the section
 // and must be syntactically valid (see package comments).  The defaultValue will be used if the
 // field is not present in the input.  The ty can be a pre-defined type tag if that is the
@@ -278,7 +832,7 @@ func (section *Section) Add(
 	defaultValue any,
 	valid func(s string) (any, bool),
 ) *Field {
-	if !nameRe.MatchString(name) {
+	if !isName(name) {
 		panic("Invalid field name " + name)
 	}
 	if ty < 1 {
@@ -287,11 +841,60 @@ func (section *Section) Add(
 	if section.fields[name] != nil {
 		panic("Duplicated field name " + name + " in section " + section.name)
 	}
-	f := &Field{section, name, ty, defaultValue, valid}
+	f := &Field{
+		section:      section,
+		name:         name,
+		ty:           ty,
+		defaultValue: defaultValue,
+		valid:        valid,
+	}
 	section.fields[name] = f
+	section.order = append(section.order, f)
 	return f
 }
 
+// AddBoolVar adds a new boolean field of the given name to the section, as for AddBool, and
+// arranges for every successful parse to store the field's value in *p.  The default value is the
+// value of *p at the time of the call.
+func (section *Section) AddBoolVar(name string, p *bool) *Field {
+	return addVar(section.Add(name, TyBool, *p, section.parseBool), p)
+}
+
+// AddStringVar adds a new string field of the given name to the section, as for AddString, and
+// arranges for every successful parse to store the field's value in *p.  The default value is the
+// value of *p at the time of the call.
+func (section *Section) AddStringVar(name string, p *string) *Field {
+	return addVar(section.Add(name, TyString, *p, ParseString), p)
+}
+
+// AddInt64Var adds a new int64 field of the given name to the section, as for AddInt64, and
+// arranges for every successful parse to store the field's value in *p.  The default value is the
+// value of *p at the time of the call.
+func (section *Section) AddInt64Var(name string, p *int64) *Field {
+	return addVar(section.Add(name, TyInt64, *p, section.parseInt64), p)
+}
+
+// AddUint64Var adds a new uint64 field of the given name to the section, as for AddUint64, and
+// arranges for every successful parse to store the field's value in *p.  The default value is the
+// value of *p at the time of the call.
+func (section *Section) AddUint64Var(name string, p *uint64) *Field {
+	return addVar(section.Add(name, TyUint64, *p, section.parseUint64), p)
+}
+
+// AddFloat64Var adds a new float64 field of the given name to the section, as for AddFloat64, and
+// arranges for every successful parse to store the field's value in *p.  The default value is the
+// value of *p at the time of the call.
+func (section *Section) AddFloat64Var(name string, p *float64) *Field {
+	return addVar(section.Add(name, TyFloat64, *p, section.parseFloat64), p)
+}
+
+func addVar[T any](field *Field, p *T) *Field {
+	field.dest = func(v any) {
+		*p = v.(T)
+	}
+	return field
+}
+
 // Name returns the name of the section.
 func (section *Section) Name() string {
 	return section.name
@@ -302,6 +905,32 @@ func (section *Section) Field(name string) *Field {
 	return section.fields[name]
 }
 
+// Fields returns the section's fields in the order they were added.
+func (section *Section) Fields() []*Field {
+	return slices.Clone(section.order)
+}
+
+// ExpandVars sets whether environment variable references are expanded in the values of the
+// section's fields, overriding the parser's ExpandVars for the section.  Fields can override it in
+// turn with [Field.ExpandVars].  Returns the section.
+func (section *Section) ExpandVars(b bool) *Section {
+	section.expand = &b
+	return section
+}
+
+// Required marks the section as required: it is a parse error if no header for the section is
+// present in the input, unless the section is skipped by [Parser.ParseSections].  Returns the
+// section.
+func (section *Section) Required() *Section {
+	section.required = true
+	return section
+}
+
+// IsRequired returns true if the section has been marked by [Section.Required].
+func (section *Section) IsRequired() bool {
+	return section.required
+}
+
 // Present returns true if the section was present in the input (even if it contained no settings).
 func (section *Section) Present(store *Store) bool {
 	return store.lookupSect(section)
@@ -315,6 +944,27 @@ type Field struct {
 	ty           FieldTy
 	defaultValue any
 	valid        func(s string) (any, bool)
+	dest         func(v any)
+	defaultFunc  func() any
+	defaultEnv   string
+	secret       bool
+	resolver     Resolver
+	list         bool
+	typeName     string
+	format       func(v any) string
+	required     bool
+	help         string
+	choices      []string
+	expand       *bool        // The setting of ExpandVars, if not the section's
+	path         *PathOptions // The normalization of the values of a path field, or nil
+	maxLen       int          // The limit set by MaxLen, or 0
+	maxItems     int          // The limit set by MaxItems, or 0
+	bounds       *[2]float64  // The interval set by Range, or nil
+
+	// For a list field, the function that parses the processed elements of a value, returning the
+	// value or the indices of the invalid elements
+	parseElems func(elems []string) (any, []int)
+	records    bool // The elements are records, see AddRecordList
 }
 
 // Name returns the field's name.
@@ -327,12 +977,120 @@ func (field *Field) Type() FieldTy {
 	return field.ty
 }
 
+// DefaultFunc sets a function that computes the field's default value, for values that can't be
+// known when the schema is defined, eg the number of CPUs or the host name.  The function is
+// called once per parse, at the end of Parse, if the field was not present in the input.  It must
+// return a value of the same type as the field's static default value, which it overrides.  Returns
+// the field.
+func (field *Field) DefaultFunc(f func() any) *Field {
+	field.defaultFunc = f
+	return field
+}
+
+// DefaultFromEnv names an environment variable that supplies the field's default value.  If the
+// field is not present in the input but the variable is set (even to the empty string), its value
+// is parsed as for the field and used as the value, taking precedence over any DefaultFunc and the
+// static default.  An invalid value is a parse error.  This is distinct from ExpandVars, which
+// operates on values present in the input.  Returns the field.
+func (field *Field) DefaultFromEnv(name string) *Field {
+	field.defaultEnv = name
+	return field
+}
+
+// Secret marks the field as holding sensitive data, such as a password.  The values of secret
+// fields are redacted in error messages and by every facility that displays or exports values,
+// such as [Store.RedactedMap].  Returns the field.
+func (field *Field) Secret() *Field {
+	field.secret = true
+	return field
+}
+
+// IsSecret returns true if the field has been marked by [Field.Secret].
+func (field *Field) IsSecret() bool {
+	return field.secret
+}
+
+// Redacted is the text that replaces the values of secret fields in output and error messages.
+const Redacted = "<redacted>"
+
+func (field *Field) redact(s string) string {
+	if field.secret {
+		return Redacted
+	}
+	return s
+}
+
+// Resolver sets a [Resolver] for the field's values that is used in place of the parser's
+// Resolver.  Returns the field.
+func (field *Field) Resolver(r Resolver) *Field {
+	field.resolver = r
+	return field
+}
+
+// ExpandVars sets whether environment variable references are expanded in the field's values,
+// overriding the ExpandVars of the section and the parser, eg to keep the `$` in a password
+// pattern literal.  Returns the field.
+func (field *Field) ExpandVars(b bool) *Field {
+	field.expand = &b
+	return field
+}
+
+// expands returns true if variable references are expanded in the field's values.
+func (field *Field) expands() bool {
+	switch {
+	case field.expand != nil:
+		return *field.expand
+	case field.section.expand != nil:
+		return *field.section.expand
+	default:
+		return field.section.parser.ExpandVars
+	}
+}
+
+// expands returns true if variable references are expanded in the values of the setting of key in
+// the section, which may be the name of a profile section, whether or not they are defined.
+func (parser *Parser) expands(sectName, key string) bool {
+	sectName, _, _ = strings.Cut(sectName, ":")
+	section := parser.sections[sectName]
+	switch {
+	case section == nil:
+		return parser.ExpandVars
+	case section.fields[key] != nil:
+		return section.fields[key].expands()
+	case section.expand != nil:
+		return *section.expand
+	default:
+		return parser.ExpandVars
+	}
+}
+
+// Formatter sets the function that renders the field's values as text, used by [Field.FormatValue]
+// and everything that writes values back out.  The text must be accepted by the field's valid
+// function and should not be quoted.  Returns the field.
+func (field *Field) Formatter(format func(v any) string) *Field {
+	field.format = format
+	return field
+}
+
 // Present returns true if the field was present in the input.
 func (field *Field) Present(store *Store) bool {
 	_, found := store.lookupVal(field.section, field)
 	return found
 }
 
+// Default returns the field's static default value, as given when the field was added.  The
+// default value used for a store may instead come from [Field.DefaultFunc] or
+// [Field.DefaultFromEnv], see [Field.Value].  A slice or map value is a copy.
+func (field *Field) Default() any {
+	return cloneValue(field.defaultValue)
+}
+
+// IsDefault returns true if the field was not present in the input, so that its value in the store
+// is a default value.
+func (field *Field) IsDefault(store *Store) bool {
+	return !field.Present(store)
+}
+
 // BoolVal returns a boolean field's value in the input, or the default if the field was not
 // present.
 func (field *Field) BoolVal(store *Store) bool {
@@ -370,14 +1128,96 @@ func getValue[T any](name string, ty FieldTy, field *Field, store *Store) T {
 	if v, found := store.lookupVal(field.section, field); found {
 		return v.(T)
 	}
-	return field.defaultValue.(T)
+	return field.defaultIn(store).(T)
 }
 
 // Value returns field's value in the input as an any, or the default value if the field was not
-// present.
+// present.  A slice or map value is a copy.
 func (field *Field) Value(store *Store) any {
 	v, found := store.lookupVal(field.section, field)
-	if found {
+	if !found {
+		v = field.defaultIn(store)
+	}
+	return cloneValue(v)
+}
+
+// cloneValue returns a shallow copy of v if it is a slice or map, otherwise v.
+func cloneValue(v any) any {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice:
+		if rv.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+		reflect.Copy(c, rv)
+		return c.Interface()
+	case reflect.Map:
+		if rv.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+		for iter := rv.MapRange(); iter.Next(); {
+			c.SetMapIndex(iter.Key(), iter.Value())
+		}
+		return c.Interface()
+	}
+	return v
+}
+
+// ValueOf returns the field's value in the input, or the default value if the field was not
+// present, with the type T.  The field's values must have type T.
+func ValueOf[T any](field *Field, store *Store) T {
+	v, ok := field.Value(store).(T)
+	if !ok {
+		panic("ValueOf accessor on differently typed field " + field.name)
+	}
+	return v
+}
+
+// OptionalOf returns the field's value in the input or from its [Field.DefaultFromEnv] variable,
+// with the type T, and true, or the zero value of T and false if the field was not set by either.
+// Use it to tell an absent field from one that is set to the zero value, eg to let "timeout = 0"
+// mean no timeout while an absent timeout means the caller's own default.  The field's values must
+// have type T.
+func OptionalOf[T any](field *Field, store *Store) (T, bool) {
+	var zero T
+	if _, ok := field.defaultValue.(T); !ok {
+		panic("OptionalOf accessor on differently typed field " + field.name)
+	}
+	if !field.isSet(store) {
+		return zero, false
+	}
+	return field.Value(store).(T), true
+}
+
+func (field *Field) computeDefault(store *Store) error {
+	if field.defaultEnv != "" {
+		if s, found := os.LookupEnv(field.defaultEnv); found {
+			val, valid := field.valid(s)
+			if !valid {
+				return parseFail(
+					0, field.section.name,
+					"Value '%s' of environment variable %s is not valid for field %s",
+					field.redact(s), field.defaultEnv, field.name)
+			}
+			if err := field.checkLimits(val); err != nil {
+				return parseFail(0, field.section.name, "In environment variable %s: %v",
+					field.defaultEnv, err)
+			}
+			store.defaults[field] = val
+			store.origins[field] = Origin{Kind: OriginEnv, Env: field.defaultEnv}
+			return nil
+		}
+	}
+	if field.defaultFunc != nil {
+		store.defaults[field] = field.defaultFunc()
+	}
+	return nil
+}
+
+func (field *Field) defaultIn(store *Store) any {
+	if v, found := store.defaults[field]; found {
 		return v
 	}
 	return field.defaultValue
@@ -385,12 +1225,198 @@ func (field *Field) Value(store *Store) any {
 
 // A Store holds the result of a successful parse.  It is passed as an argument to methods on
 // individual Fields to retrieve those fields' values.
+//
+// A Store is immutable, and so is safe for concurrent use by any number of goroutines without
+// locking.  Operations that derive a store from others, such as [Store.WithDefaults] and [Merge],
+// return new stores, and [Holder] swaps whole stores atomically.  Values of slice and map types,
+// such as lists, are copied when they are returned, so modifying them does not change the store,
+// but the values they hold, and values of pointer types, are shared and must not be modified.
 type Store struct {
+	parser   *Parser
 	sections map[string]*sectStore
+	defaults map[*Field]any
+	origins  map[*Field]Origin // The origins of values other than the static defaults
+	file     string            // The name of the input file, if known
+	order    []*Section        // The present sections in the order they were first seen
+
+	// Captured comments, see CaptureComments
+	comments     map[*Field]string
+	sectComments map[*Section]string
+
+	unknown []RawSetting      // The undefined sections and settings, see Lenient
+	sources map[*Field]string // The layers that supplied the values, see Merge
+
+	occurrences map[*Field][]Occurrence // The settings of the fields, see Field.Occurrences
+}
+
+func newStore(parser *Parser) *Store {
+	return &Store{
+		parser:   parser,
+		sections: make(map[string]*sectStore),
+		defaults: make(map[*Field]any),
+		origins:  make(map[*Field]Origin),
+	}
+}
+
+// RedactedMap returns a map from `section.field` to the field's value in the input, or its default
+// value if it was not present, for every field of every section in the parser.  The values of
+// secret fields are replaced by the string [Redacted], so the map is safe to log.
+func (store *Store) RedactedMap() map[string]any {
+	m := make(map[string]any)
+	for _, section := range store.parser.order {
+		for _, field := range section.order {
+			var v any = Redacted
+			if !field.secret {
+				v = field.Value(store)
+			}
+			m[section.name+"."+field.name] = v
+		}
+	}
+	return m
+}
+
+// AllSettings returns a nested map from section name to field name to the field's value in the
+// input, for generic processing of the configuration, eg by templates.  If withDefaults is true
+// then every field of every section in the parser is included, with its default value if it was not
+// present, otherwise only the sections and fields that were present in the input.  Note that the
+// values of secret fields are not redacted, see [Store.RedactedMap].
+func (store *Store) AllSettings(withDefaults bool) map[string]any {
+	m := make(map[string]any)
+	for _, section := range store.parser.order {
+		if !withDefaults && !section.Present(store) {
+			continue
+		}
+		values := make(map[string]any)
+		for _, field := range section.order {
+			if withDefaults || field.Present(store) {
+				values[field.name] = field.Value(store)
+			}
+		}
+		m[section.name] = values
+	}
+	return m
+}
+
+// Sections returns the sections that were present in the input, in the order of their first
+// headers.
+func (store *Store) Sections() []*Section {
+	return slices.Clone(store.order)
+}
+
+// Fields returns the fields that were present in the input, section by section in the order of
+// [Store.Sections], and within each section in the order of their first settings.
+func (store *Store) Fields() []*Field {
+	var fields []*Field
+	for _, section := range store.order {
+		for _, name := range store.sections[section.name].order {
+			fields = append(fields, section.fields[name])
+		}
+	}
+	return fields
+}
+
+// Unknown returns the undefined sections and settings that were accepted because the parser's
+// Lenient was true, in input order, so that they can be warned about or passed on.  An undefined
+// section is reported with its first header, as a RawSetting whose Name is "", followed by its
+// settings.  The Value of a setting is as for [Parser.ParseEvents].
+func (store *Store) Unknown() []RawSetting {
+	return slices.Clone(store.unknown)
+}
+
+// CommentFor returns the text of the comment lines immediately preceding the setting that gave the
+// field its value, without the comment characters and joined by newlines, or "" if there is no
+// such comment or the parser's CaptureComments was false.
+func (store *Store) CommentFor(field *Field) string {
+	return store.comments[field]
+}
+
+// SectionComment returns the text of the comment lines immediately preceding the section's
+// header, as for [Store.CommentFor].  If the header appears more than once then the last comment
+// is returned.
+func (store *Store) SectionComment(section *Section) string {
+	return store.sectComments[section]
+}
+
+// WithDefaults returns a new store in which every field of every section in the parser is present,
+// with its value in the store if it was present in the input and its default value otherwise.  It
+// is the effective configuration with the distinction between explicit and default values erased,
+// eg for serialization or comparison with [Store.Equal].  The store is not changed.
+func (store *Store) WithDefaults() *Store {
+	result := newStore(store.parser)
+	result.file = store.file
+	result.unknown = store.unknown
+	result.sources = store.sources
+	result.occurrences = store.occurrences
+	for _, section := range store.parser.order {
+		values := result.ensure(section)
+		for _, field := range section.order {
+			values.set(field.name, field.Value(store))
+			result.origins[field] = field.Origin(store)
+		}
+	}
+	return result
+}
+
+// GetBool returns the value of the boolean field named by path, on the form `section.field`, as for
+// [Field.BoolVal].  The field must exist.
+func (store *Store) GetBool(path string) bool {
+	return store.lookupPath(path).BoolVal(store)
+}
+
+// GetString returns the value of the string field named by path, on the form `section.field`, as
+// for [Field.StringVal].  The field must exist.
+func (store *Store) GetString(path string) string {
+	return store.lookupPath(path).StringVal(store)
+}
+
+// GetInt64 returns the value of the int64 field named by path, on the form `section.field`, as for
+// [Field.Int64Val].  The field must exist.
+func (store *Store) GetInt64(path string) int64 {
+	return store.lookupPath(path).Int64Val(store)
+}
+
+// GetUint64 returns the value of the uint64 field named by path, on the form `section.field`, as
+// for [Field.Uint64Val].  The field must exist.
+func (store *Store) GetUint64(path string) uint64 {
+	return store.lookupPath(path).Uint64Val(store)
+}
+
+// GetFloat64 returns the value of the float64 field named by path, on the form `section.field`, as
+// for [Field.Float64Val].  The field must exist.
+func (store *Store) GetFloat64(path string) float64 {
+	return store.lookupPath(path).Float64Val(store)
+}
+
+// Get returns the value of the field named by path, on the form `section.field`, as for
+// [Field.Value], but with the type T.  The field must exist and its values must have type T.
+func Get[T any](store *Store, path string) T {
+	v, ok := store.lookupPath(path).Value(store).(T)
+	if !ok {
+		panic("Get accessor on differently typed field " + path)
+	}
+	return v
+}
+
+func (store *Store) lookupPath(path string) *Field {
+	sname, fname, _ := strings.Cut(path, ".")
+	if section := store.parser.sections[sname]; section != nil {
+		if field := section.fields[fname]; field != nil {
+			return field
+		}
+	}
+	panic("No field " + path)
 }
 
 type sectStore struct {
 	values map[string]any
+	order  []string // The names of the fields in the order they were first set
+}
+
+func (ss *sectStore) set(name string, v any) {
+	if _, found := ss.values[name]; !found {
+		ss.order = append(ss.order, name)
+	}
+	ss.values[name] = v
 }
 
 func (store *Store) lookupSect(section *Section) bool {
@@ -410,93 +1436,425 @@ func (store *Store) ensure(section *Section) *sectStore {
 	sProbe := store.sections[section.name]
 	if sProbe == nil {
 		sProbe = &sectStore{
-			values: make(map[string]any),
+			values: make(map[string]any, len(section.fields)),
 		}
 		store.sections[section.name] = sProbe
+		store.order = append(store.order, section)
 	}
 	return sProbe
 }
 
-func (store *Store) set(section *Section, field *Field, val any) {
-	store.ensure(section).values[field.name] = val
-}
-
 // Parse parses the input from the reader, returning a [Store] with information about field presence
-// and values.  Errors in field parsing result in a [*ParseError] being returned with no store.
+// and values.  Errors in field parsing result in a [*ParseError] being returned with no store.  If
+// the parse succeeds, the values of fields added with `Section.Add<Type>Var()` are stored into
+// their variables; if it fails, those variables are not touched.
+//
 // Concurrent parsing is safe, but no sections or fields may be added while the parser is in use for
-// parsing in any goroutine.
+// parsing in any goroutine, and concurrent parses race on the variables of any Var fields.
 func (parser *Parser) Parse(r io.Reader) (*Store, error) {
-	names := slices.Collect(maps.Keys(parser.sections))
-	sectionRe := regexp.MustCompile(`^\s*\[\s*(` + strings.Join(names, "|") + `)\s*\]\s*$`)
-	blankRe := regexp.MustCompile(fmt.Sprintf(`^\s*(:?\x{%x}.*)?$`, parser.CommentChar))
+	return parser.ParseContext(context.Background(), r)
+}
 
-	store := &Store{
-		sections: make(map[string]*sectStore),
+// ParseString is like [Parser.Parse] but parses the text s, which it reads in place without the
+// copying that reading from an io.Reader requires, so it is faster for input that is already in
+// memory.  The values in the store may share memory with s.
+func (parser *Parser) ParseString(s string) (*Store, error) {
+	if parser.versionPath != "" {
+		return parser.Parse(strings.NewReader(s)) // Migration rewrites the input
 	}
-	scanner := bufio.NewScanner(r)
-	var lineno int
-	var sect *Section
-	for scanner.Scan() {
-		l := scanner.Text()
-		lineno++
-		if blankRe.MatchString(l) {
-			continue
+	return parser.build(context.Background(), parser.newStringScanner(s), "", nil, time.Now())
+}
+
+// ParseBytes is like [Parser.ParseString] but parses the text in b, which it copies once so that
+// the store does not depend on b.
+func (parser *Parser) ParseBytes(b []byte) (*Store, error) {
+	return parser.ParseString(string(b))
+}
+
+// ParseContext is like [Parser.Parse] but checks ctx before each line of input and stops parsing if
+// ctx is canceled or its deadline is exceeded, returning a [*ParseError] that wraps ctx.Err().  The
+// check does not interrupt a read that is blocked in r.
+func (parser *Parser) ParseContext(ctx context.Context, r io.Reader) (*Store, error) {
+	return parser.parse(ctx, r, "", nil)
+}
+
+// ParseSections is like [Parser.Parse] but only validates and stores the settings of the named
+// sections: the settings of all other sections, including undefined ones, are skipped without
+// error, and the store is as if those sections were not present in the input.  The input must
+// still be syntactically valid.  ParseSections panics if a name is not a defined section.
+func (parser *Parser) ParseSections(r io.Reader, names ...string) (*Store, error) {
+	only := make(map[string]bool, len(names))
+	for _, name := range names {
+		if parser.sections[name] == nil {
+			panic("No section " + name)
 		}
-		if m := sectionRe.FindStringSubmatch(l); m != nil {
-			probe := parser.sections[m[1]]
-			if probe == nil {
-				return nil, parseFail(lineno, "", "Undefined section %s", m[1])
-			}
-			sect = probe
-			store.ensure(sect)
-			continue
+		only[name] = true
+	}
+	return parser.parse(context.Background(), r, "", only)
+}
+
+// ParseFile opens the named file and parses it as for [Parser.Parse].  The file name is recorded as
+// the origin of the values in the store, see [Field.Origin].
+func (parser *Parser) ParseFile(name string) (*Store, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parser.parse(context.Background(), f, name, nil)
+}
+
+// parse parses the input from r, which is the file with the given name if that is not "".  If only
+// is not nil then the settings of sections not in only are skipped.
+func (parser *Parser) parse(
+	ctx context.Context,
+	r io.Reader,
+	name string,
+	only map[string]bool,
+) (*Store, error) {
+	start := time.Now()
+	if parser.versionPath == "" {
+		return parser.build(ctx, parser.newScanner(r), name, only, start)
+	}
+	src, err := parser.migrate(r)
+	if err != nil {
+		if parser.Metrics != nil {
+			parser.report(start, 0, nil, err)
 		}
-		if m := valRe.FindStringSubmatch(l); m != nil {
-			if sect == nil {
-				return nil, parseFail(lineno, "", "Setting %s outside section", m[1])
-			}
-			field := sect.fields[m[1]]
-			if field == nil {
-				return nil, parseFail(lineno, sect.name, "No field %s", m[1])
-			}
-			s := m[2]
-			if parser.ExpandVars {
-				s = varRe.ReplaceAllStringFunc(s, func(m string) string {
-					if m == "$$" {
-						return "$"
-					}
-					var name string
-					if m[1] == '{' {
-						name = m[2 : len(m)-1]
-					} else {
-						name = m[1:]
+		return nil, err
+	}
+	return parser.build(ctx, src, name, only, start)
+}
+
+// build builds a store from the tokens of the source, as for parse, and reports the parse that
+// started at start to the parser's Metrics.
+func (parser *Parser) build(
+	ctx context.Context,
+	src tokenSource,
+	name string,
+	only map[string]bool,
+	start time.Time,
+) (*Store, error) {
+	if parser.Metrics == nil {
+		return parser.buildStore(ctx, src, name, only)
+	}
+	counter := &countingSource{src: src}
+	store, err := parser.buildStore(ctx, counter, name, only)
+	parser.report(start, counter.lines, store, err)
+	return store, err
+}
+
+func (parser *Parser) buildStore(
+	ctx context.Context,
+	src tokenSource,
+	name string,
+	only map[string]bool,
+) (*Store, error) {
+	sb := parser.newStoreBuilder(name, only)
+	if err := parser.fill(ctx, src, sb); err != nil {
+		return nil, err
+	}
+	parser.assignVars(sb.store)
+	return sb.store, nil
+}
+
+// newStoreBuilder returns a storeBuilder for a new store for the input of the given name, see
+// parse.
+func (parser *Parser) newStoreBuilder(name string, only map[string]bool) *storeBuilder {
+	store := newStore(parser)
+	store.file = name
+	return &storeBuilder{parser: parser, store: store, only: only, skip: only != nil, file: name}
+}
+
+// fill fills the builder's store from the tokens of the source, and then computes the default
+// values of the fields that were not present and checks that the required fields are and that
+// the constraints between fields hold.
+func (parser *Parser) fill(ctx context.Context, src tokenSource, sb *storeBuilder) error {
+	if err := parser.process(ctx, src, sb.store.file, sb); err != nil {
+		return err
+	}
+	return parser.finish(sb.store, sb.only, sb.fail)
+}
+
+// finish computes the default values of the fields that were not present in the store and checks
+// that the required fields are and that the constraints between fields hold, considering only the
+// sections in only if only is not nil, passing the errors to fail and returning the first error
+// that fail returns.
+func (parser *Parser) finish(store *Store, only map[string]bool, fail func(error) error) error {
+	for _, section := range parser.order {
+		for _, field := range section.order {
+			if !field.Present(store) {
+				if err := field.computeDefault(store); err != nil {
+					if err := fail(err); err != nil {
+						return err
 					}
-					return os.Getenv(name)
-				})
-			}
-			s = strings.TrimSpace(s)
-			if parser.QuoteChar != 0 {
-				c := string(parser.QuoteChar)
-				if strings.HasPrefix(s, c) && strings.HasSuffix(s, c) {
-					s = strings.TrimSuffix(strings.TrimPrefix(s, c), c)
 				}
 			}
-			val, valid := field.valid(s)
-			if !valid {
-				return nil, parseFail(
-					lineno, sect.name, "Value '%s' is not valid for field %s", s, m[1])
+		}
+	}
+	if err := parser.checkRequired(store, only, fail); err != nil {
+		return err
+	}
+	return parser.checkConstraints(store, only, fail)
+}
+
+// assignVars stores the values of the Var fields in their variables.
+func (parser *Parser) assignVars(store *Store) {
+	for _, section := range parser.order {
+		for _, field := range section.order {
+			if field.dest != nil {
+				field.dest(field.Value(store))
 			}
-			store.set(sect, field, val)
-			continue
 		}
-		if sect == nil {
-			return nil, parseFail(lineno, "", "Invalid syntax before first section")
+	}
+}
+
+// storeBuilder is the EventHandler that populates a Store from the input.
+type storeBuilder struct {
+	parser  *Parser
+	store   *Store
+	section *Section   // The current section
+	values  *sectStore // The store for the current section
+	profile bool       // True if the current section is a profile section
+	skip    bool       // True if the current section's settings are skipped
+	only    map[string]bool
+	file    string   // The name of the current input, which differs from the store's when included
+	errs    *[]error // If not nil, the errors of the input are collected here, see errorCollector
+
+	// The fields set in profile sections, whose settings in base sections are ignored
+	overridden map[*Field]bool
+
+	// The values of the settings of fields in base and profile sections, if OnOverride is not nil
+	assigned map[assignment]string
+
+	// The text of the comment lines immediately preceding the current line, see CaptureComments
+	comment     []string
+	commentLine int // The line of the last comment
+}
+
+// An assignment identifies the settings of a field in base sections or in profile sections.
+type assignment struct {
+	field   *Field
+	profile bool
+}
+
+func (sb *storeBuilder) fileChanged(name string) {
+	sb.file = name
+}
+
+// collect records the error and returns true if the builder collects errors, see errorCollector.
+func (sb *storeBuilder) collect(err error) bool {
+	if sb.errs == nil {
+		return false
+	}
+	*sb.errs = append(*sb.errs, err)
+	return true
+}
+
+// fail returns err, or nil if the builder has collected it.
+func (sb *storeBuilder) fail(err error) error {
+	if sb.collect(err) {
+		return nil
+	}
+	return err
+}
+
+// takeComment returns the comment that immediately precedes the line, if any, and clears it.
+func (sb *storeBuilder) takeComment(line int) string {
+	var text string
+	if sb.commentLine == line-1 {
+		text = strings.Join(sb.comment, "\n")
+	}
+	sb.comment = sb.comment[:0]
+	return text
+}
+
+func (sb *storeBuilder) SectionStart(line int, name string) error {
+	comment := sb.takeComment(line)
+	sb.skip = sb.only != nil && !sb.only[name]
+	if sb.skip {
+		return nil
+	}
+	section := sb.parser.sections[name]
+	if section == nil && sb.parser.OnUnknownSection != nil {
+		section = sb.parser.OnUnknownSection(name)
+		if section != nil && sb.parser.sections[name] != section {
+			panic("OnUnknownSection must return a section named " + name + " in the parser")
 		}
-		return nil, parseFail(lineno, sect.name, "Invalid syntax")
 	}
-	if err := scanner.Err(); err != nil {
-		return nil, parseFail(lineno, "", "I/O error: "+err.Error())
+	if section == nil && sb.parser.Lenient {
+		sb.section = nil
+		if !slices.ContainsFunc(sb.store.unknown, func(rs RawSetting) bool {
+			return rs.Section == name && rs.Name == ""
+		}) {
+			sb.store.unknown = append(sb.store.unknown, RawSetting{Line: line, Section: name})
+		}
+		return nil
+	}
+	if section == nil {
+		sb.skip = true
+		return parseFail(line, "", "Undefined section %s", name)
+	}
+	sb.section = section
+	sb.values = sb.store.ensure(section)
+	sb.profile = false
+	if comment != "" {
+		if sb.store.sectComments == nil {
+			sb.store.sectComments = make(map[*Section]string)
+		}
+		sb.store.sectComments[section] = comment
+	}
+	return nil
+}
+
+func (sb *storeBuilder) profileSectionStart(line int, name string) error {
+	if err := sb.SectionStart(line, name); err != nil {
+		return err
 	}
+	sb.profile = true
+	return nil
+}
+
+func (sb *storeBuilder) KeyValue(line int, sectName, key, value string) error {
+	comment := sb.takeComment(line)
+	if sb.skip {
+		return nil
+	}
+	var field *Field
+	if sb.section != nil {
+		field = sb.section.fields[key]
+	}
+	if field == nil && sb.parser.Lenient {
+		sb.store.unknown = append(sb.store.unknown, RawSetting{line, sectName, key, value})
+		return nil
+	}
+	if sb.section == nil {
+		return parseFail(line, "", "Setting %s outside section", key)
+	}
+	if field == nil {
+		return parseFail(line, sectName, "No field %s", key)
+	}
+	input := value
+	resolver := field.resolver
+	if resolver == nil {
+		resolver = sb.parser.Resolver
+	}
+	if resolver != nil {
+		var err error
+		value, err = resolver.Resolve(value)
+		if err != nil {
+			return parseFail(line, sectName, "Could not resolve value for field %s: %v", key, err)
+		}
+	}
+	var val any
+	var valid bool
+	if field.list {
+		var elems []string
+		var bad []int
+		var err error
+		val, elems, bad, err = field.parseListElems(value, sb.file)
+		if bad != nil {
+			return sb.elementErrors(line, sectName, field, elems, bad)
+		}
+		valid = err == nil
+	} else {
+		val, valid = field.valid(value)
+	}
+	if !valid {
+		if field.numberHint(value) {
+			return parseFail(line, sectName, "Value '%s' is not valid for field %s: numbers are "+
+				"written with a decimal point and without thousands separators", value, key)
+		}
+		return parseFail(
+			line, sectName, "Value '%s' is not valid for field %s", field.redact(value), key)
+	}
+	if err := field.checkLimits(val); err != nil {
+		return parseFail(line, sectName, "%v", err)
+	}
+	if sb.parser.OnOverride != nil {
+		a := assignment{field, sb.profile}
+		if old, found := sb.assigned[a]; found {
+			err := sb.parser.OnOverride(field, line, field.redact(old), field.redact(input))
+			if err != nil {
+				return err
+			}
+		}
+		if sb.assigned == nil {
+			sb.assigned = make(map[assignment]string)
+		}
+		sb.assigned[a] = input
+	}
+	if field.path != nil {
+		val = field.normalizePath(val.(string), sb.file)
+	}
+	if sb.profile {
+		if sb.overridden == nil {
+			sb.overridden = make(map[*Field]bool)
+		}
+		sb.overridden[field] = true
+	} else if sb.overridden[field] {
+		return nil
+	}
+	sb.values.set(field.name, val)
+	sb.store.origins[field] = Origin{Kind: OriginInput, File: sb.file, Line: line}
+	if sb.store.occurrences == nil {
+		sb.store.occurrences = make(map[*Field][]Occurrence)
+	}
+	sb.store.occurrences[field] = append(sb.store.occurrences[field],
+		Occurrence{sb.file, line, field.redact(input), val})
+	if sb.parser.CaptureComments {
+		if sb.store.comments == nil {
+			sb.store.comments = make(map[*Field]string)
+		}
+		sb.store.comments[field] = comment
+	}
+	return nil
+}
+
+// elementErrors returns the error for the first of the invalid elements of a list field's value on
+// the line, whose indices are in bad, or if the builder collects errors, collects the errors for
+// all but the last and returns the last.
+func (sb *storeBuilder) elementErrors(
+	line int,
+	sectName string,
+	field *Field,
+	elems []string,
+	bad []int,
+) error {
+	for n, i := range bad {
+		err := parseFail(line, sectName, "Element %d '%s' is not valid for field %s", i+1,
+			field.redact(elems[i]), field.name)
+		if n == len(bad)-1 || sb.errs == nil {
+			return err
+		}
+		if sb.file != sb.store.file {
+			err.File = sb.file // As the parser attributes the errors it collects
+		}
+		sb.collect(err)
+	}
+	return nil
+}
+
+func (sb *storeBuilder) isList(sectName, key string) bool {
+	return !sb.skip && sb.section != nil && sb.section.fields[key] != nil &&
+		sb.section.fields[key].list
+}
+
+func (sb *storeBuilder) expandVars(sectName, key string) bool {
+	return sb.parser.expands(sectName, key)
+}
+
+func (sb *storeBuilder) Comment(line int, text string) error {
+	if sb.parser.CaptureComments {
+		if sb.commentLine != line-1 {
+			sb.comment = sb.comment[:0]
+		}
+		sb.comment = append(sb.comment, text)
+		sb.commentLine = line
+	}
+	return nil
+}
 
-	return store, nil
+func (sb *storeBuilder) EOF(line int) error {
+	return nil
 }
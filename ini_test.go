@@ -7,7 +7,14 @@
 package ini
 
 import (
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"net/netip"
 	"os"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -274,6 +281,48 @@ func TestOptions(t *testing.T) {
 	}
 }
 
+func TestExpandVarsPerField(t *testing.T) {
+	t.Setenv("INI_TEST_X", "x")
+	p := NewParser()
+	a := p.AddSection("a").ExpandVars(true)
+	expanded, literal := a.AddString("e"), a.AddString("l").ExpandVars(false)
+	list := a.AddStringList("list")
+	b := p.AddSection("b")
+	plain, forced := b.AddString("p"), b.AddString("f").ExpandVars(true)
+	input := "[a]\ne = $INI_TEST_X\nl = pa$$w$INI_TEST_X\nlist = $INI_TEST_X, y\n" +
+		"[b]\np = $INI_TEST_X\nf = ${INI_TEST_X}\n"
+	store, err := p.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expanded.StringVal(store) != "x" || literal.StringVal(store) != "pa$$w$INI_TEST_X" ||
+		!slices.Equal(list.StringListVal(store), []string{"x", "y"}) ||
+		plain.StringVal(store) != "$INI_TEST_X" || forced.StringVal(store) != "x" {
+		t.Fatal(expanded.StringVal(store), literal.StringVal(store), list.StringListVal(store),
+			plain.StringVal(store), forced.StringVal(store))
+	}
+
+	var out strings.Builder
+	if err := store.Write(&out, nil); err != nil {
+		t.Fatal(err)
+	}
+	back, err := p.Parse(strings.NewReader(out.String()))
+	if err != nil || !back.Equal(store) {
+		t.Fatal(err, out.String())
+	}
+
+	doc, err := p.ParseDocument(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := doc.Get("a", "l"); v != "pa$$w$INI_TEST_X" {
+		t.Fatal(v)
+	}
+	if v, _ := doc.Get("b", "f"); v != "x" {
+		t.Fatal(v)
+	}
+}
+
 func TestVar(t *testing.T) {
 	p := NewParser("ExpandVars", true)
 	s := p.AddSection("sect")
@@ -303,3 +352,1035 @@ n = ${S}37$S
 		t.Fatal(s.Field("n").Int64Val(store))
 	}
 }
+
+func TestTextUnmarshaler(t *testing.T) {
+	p := NewParser()
+	s := p.AddSection("net")
+	addr := s.AddTextUnmarshaler("addr", func() encoding.TextUnmarshaler { return new(netip.Addr) })
+	other := s.AddTextUnmarshaler("other", func() encoding.TextUnmarshaler { return new(netip.Addr) })
+	if addr.Type() != TyUser {
+		t.Fatal("Type")
+	}
+	store, err := p.Parse(strings.NewReader(`
+[net]
+addr = 192.168.0.1
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x := *addr.Value(store).(*netip.Addr); x != netip.MustParseAddr("192.168.0.1") {
+		t.Fatal("addr", x)
+	}
+	if x := *other.Value(store).(*netip.Addr); x.IsValid() {
+		t.Fatal("other", x)
+	}
+
+	_, err = p.Parse(strings.NewReader(`
+[net]
+addr = 192.168.0
+`))
+	if err == nil {
+		t.Fatal("Should fail")
+	}
+}
+
+// testTags is a flag.Value whose Set accumulates values, as for repeated flags.
+type testTags []string
+
+func (tags *testTags) String() string {
+	return strings.Join(*tags, "+")
+}
+
+func (tags *testTags) Set(s string) error {
+	if s == "" {
+		return errors.New("Empty tag")
+	}
+	*tags = append(*tags, strings.Split(s, "+")...)
+	return nil
+}
+
+func TestFlagValue(t *testing.T) {
+	tags := testTags{"x"}
+	p := NewParser()
+	s := p.AddSection("s")
+	f := s.AddFlagValue("tags", &tags)
+	if f.Type() != TyUser || f.Default().(*testTags).String() != "x" {
+		t.Fatal("Type or default")
+	}
+	for _, input := range []string{"[s]\ntags = a+b\n", "[s]\ntags = a+b\n"} {
+		store, err := p.Parse(strings.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tags.String() != "a+b" || f.FormatValue(f.Value(store)) != "a+b" {
+			t.Fatal(tags)
+		}
+	}
+	if _, err := p.Parse(strings.NewReader("[s]\ntags =\n")); err == nil || tags.String() != "a+b" {
+		t.Fatal(err, tags)
+	}
+	if _, err := p.Parse(strings.NewReader("")); err != nil || tags.String() != "x" {
+		t.Fatal(err, tags)
+	}
+	expectPanic(t, "AddFlagValue with a non-pointer value for field n", func() {
+		var nilTags *testTags
+		s.AddFlagValue("n", nilTags)
+	})
+}
+
+func TestVarFields(t *testing.T) {
+	var (
+		b bool
+		s = "default"
+		i int64
+		u uint64 = 7
+		f float64
+	)
+	p := NewParser()
+	sect := p.AddSection("sect")
+	sect.AddBoolVar("b", &b)
+	sect.AddStringVar("s", &s)
+	sect.AddInt64Var("i", &i)
+	sect.AddUint64Var("u", &u)
+	fld := sect.AddFloat64Var("f", &f)
+	if fld.Type() != TyFloat64 {
+		t.Fatal("Type")
+	}
+	store, err := p.Parse(strings.NewReader(`
+[sect]
+b = true
+i = -3
+f = 1.5
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b || s != "default" || i != -3 || u != 7 || f != 1.5 {
+		t.Fatal("Values", b, s, i, u, f)
+	}
+	if fld.Float64Val(store) != 1.5 {
+		t.Fatal("Store value")
+	}
+
+	// A failed parse leaves the variables alone
+	_, err = p.Parse(strings.NewReader(`
+[sect]
+s = changed
+i = x
+`))
+	if err == nil {
+		t.Fatal("Should fail")
+	}
+	if s != "default" || i != -3 {
+		t.Fatal("Values changed", s, i)
+	}
+}
+
+func TestDefaultFunc(t *testing.T) {
+	p := NewParser()
+	s := p.AddSection("sect")
+	calls := 0
+	n := s.AddInt64("n").DefaultFunc(func() any {
+		calls++
+		return int64(calls * 10)
+	})
+	store, err := p.Parse(strings.NewReader("[sect]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x := n.Int64Val(store); x != 10 {
+		t.Fatal("n", x)
+	}
+	if x := n.Value(store); x != int64(10) {
+		t.Fatal("n", x)
+	}
+
+	// Evaluated per parse, and not at all when the field is present
+	store, err = p.Parse(strings.NewReader("[sect]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x := n.Int64Val(store); x != 20 {
+		t.Fatal("n", x)
+	}
+	store, err = p.Parse(strings.NewReader("[sect]\nn=5\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x := n.Int64Val(store); x != 5 || calls != 2 {
+		t.Fatal("n", x, calls)
+	}
+}
+
+func TestDefaultFromEnv(t *testing.T) {
+	p := NewParser()
+	s := p.AddSection("sect")
+	port := s.Add("port", TyUint64, uint64(80), ParseUint64).DefaultFromEnv("INI_TEST_PORT")
+	host := s.AddString("host").
+		DefaultFunc(func() any { return "computed" }).
+		DefaultFromEnv("INI_TEST_HOST")
+
+	os.Unsetenv("INI_TEST_PORT")
+	os.Unsetenv("INI_TEST_HOST")
+	store, err := p.Parse(strings.NewReader("[sect]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port.Uint64Val(store) != 80 || host.StringVal(store) != "computed" {
+		t.Fatal("Fallbacks", port.Uint64Val(store), host.StringVal(store))
+	}
+
+	t.Setenv("INI_TEST_PORT", "8080")
+	t.Setenv("INI_TEST_HOST", "envhost")
+	store, err = p.Parse(strings.NewReader("[sect]\nhost=filehost\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port.Uint64Val(store) != 8080 || host.StringVal(store) != "filehost" {
+		t.Fatal("Env", port.Uint64Val(store), host.StringVal(store))
+	}
+
+	t.Setenv("INI_TEST_PORT", "eighty")
+	_, err = p.Parse(strings.NewReader("[sect]\n"))
+	if err == nil {
+		t.Fatal("Should fail")
+	}
+	if err.Error() != "In section sect: Value 'eighty' of environment variable INI_TEST_PORT is not valid for field port" {
+		t.Fatal(err)
+	}
+}
+
+func TestOptionalOf(t *testing.T) {
+	p := NewParser()
+	s := p.AddSection("sect")
+	timeout := s.AddInt64("timeout")
+	retries := s.Add("retries", TyInt64, int64(3), ParseInt64).DefaultFromEnv("INI_TEST_RETRIES")
+
+	os.Unsetenv("INI_TEST_RETRIES")
+	store, err := p.Parse(strings.NewReader("[sect]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := OptionalOf[int64](timeout, store); ok || v != 0 {
+		t.Fatal("Absent", v, ok)
+	}
+	if v, ok := OptionalOf[int64](retries, store); ok || v != 0 {
+		t.Fatal("Default", v, ok)
+	}
+
+	t.Setenv("INI_TEST_RETRIES", "5")
+	store, err = p.Parse(strings.NewReader("[sect]\ntimeout = 0\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := OptionalOf[int64](timeout, store); !ok || v != 0 {
+		t.Fatal("Zero", v, ok)
+	}
+	if v, ok := OptionalOf[int64](retries, store); !ok || v != 5 {
+		t.Fatal("Env", v, ok)
+	}
+	expectPanic(t, "OptionalOf accessor on differently typed field timeout", func() {
+		OptionalOf[string](timeout, store)
+	})
+}
+
+func TestSecret(t *testing.T) {
+	p := NewParser()
+	s := p.AddSection("db")
+	s.AddString("user")
+	pw := s.AddString("password").Secret()
+	pin := s.AddInt64("pin").Secret()
+	if !pw.IsSecret() || !pin.IsSecret() || s.Field("user").IsSecret() {
+		t.Fatal("IsSecret")
+	}
+	store, err := p.Parse(strings.NewReader(`
+[db]
+user = joe
+password = hunter2
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pw.StringVal(store) != "hunter2" {
+		t.Fatal("Value")
+	}
+	m := store.RedactedMap()
+	if len(m) != 3 || m["db.user"] != "joe" || m["db.password"] != Redacted || m["db.pin"] != Redacted {
+		t.Fatal(m)
+	}
+
+	_, err = p.Parse(strings.NewReader(`
+[db]
+pin = abc123
+`))
+	if err == nil || strings.Contains(err.Error(), "abc123") {
+		t.Fatal(err)
+	}
+}
+
+func TestAllSettings(t *testing.T) {
+	p := NewParser()
+	db := p.AddSection("db")
+	db.AddString("user")
+	db.AddInt64("port").DefaultFunc(func() any { return int64(5432) })
+	db.AddStringList("hosts")
+	p.AddSection("log").AddBool("verbose")
+	store, err := p.Parse(strings.NewReader("[db]\nuser = joe\nhosts = a, b\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"db": map[string]any{"user": "joe", "hosts": []string{"a", "b"}}}
+	if m := store.AllSettings(false); !reflect.DeepEqual(m, want) {
+		t.Fatal(m)
+	}
+	want = map[string]any{
+		"db":  map[string]any{"user": "joe", "port": int64(5432), "hosts": []string{"a", "b"}},
+		"log": map[string]any{"verbose": false},
+	}
+	if m := store.AllSettings(true); !reflect.DeepEqual(m, want) {
+		t.Fatal(m)
+	}
+}
+
+func TestResolver(t *testing.T) {
+	secrets := map[string]string{"db": "hunter2"}
+	vault := ResolverFunc(func(v string) (string, error) {
+		if name, found := strings.CutPrefix(v, "secret://"); found {
+			if s, found := secrets[name]; found {
+				return s, nil
+			}
+			return "", errors.New("no such secret")
+		}
+		return v, nil
+	})
+	p := NewParser("Resolver", vault)
+	s := p.AddSection("db")
+	user := s.AddString("user")
+	pw := s.AddString("password")
+	raw := s.AddString("raw").Resolver(ResolverFunc(func(v string) (string, error) {
+		return v, nil
+	}))
+	store, err := p.Parse(strings.NewReader(`
+[db]
+user = joe
+password = secret://db
+raw = secret://db
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.StringVal(store) != "joe" || pw.StringVal(store) != "hunter2" || raw.StringVal(store) != "secret://db" {
+		t.Fatal("Values", user.StringVal(store), pw.StringVal(store), raw.StringVal(store))
+	}
+
+	_, err = p.Parse(strings.NewReader(`
+[db]
+password = secret://nope
+`))
+	if err == nil || !strings.Contains(err.Error(), "no such secret") {
+		t.Fatal(err)
+	}
+}
+
+func TestGetPath(t *testing.T) {
+	p := NewParser()
+	s := p.AddSection("sect")
+	s.AddBool("b")
+	s.AddString("s")
+	s.AddInt64("i")
+	s.Add("u", TyUint64, uint64(9), ParseUint64)
+	s.AddFloat64("f")
+	store, err := p.Parse(strings.NewReader(`
+[sect]
+b = true
+s = hi
+i = -1
+f = 2.5
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !store.GetBool("sect.b") || store.GetString("sect.s") != "hi" || store.GetInt64("sect.i") != -1 ||
+		store.GetUint64("sect.u") != 9 || store.GetFloat64("sect.f") != 2.5 {
+		t.Fatal("Get")
+	}
+	if Get[string](store, "sect.s") != "hi" || Get[uint64](store, "sect.u") != 9 {
+		t.Fatal("Generic get")
+	}
+	expectPanic(t, "No field sect.zappa", func() { store.GetString("sect.zappa") })
+	expectPanic(t, "No field sect", func() { store.GetString("sect") })
+	expectPanic(t, "Get accessor on differently typed field sect.s", func() { Get[int64](store, "sect.s") })
+}
+
+func expectPanic(t *testing.T, msg string, f func()) {
+	t.Helper()
+	defer func() {
+		t.Helper()
+		if x := recover(); x != msg {
+			t.Fatalf("Expected panic %q, got %v", msg, x)
+		}
+	}()
+	f()
+}
+
+func TestParseString(t *testing.T) {
+	p := NewParser(WithMaxLineLen(12))
+	s := p.AddSection("s")
+	s.AddString("a")
+	s.AddStringList("b")
+	p.AddSection("t").AddInt64("n")
+	for _, input := range []string{
+		"",
+		"[s]",
+		"\uFEFF[s]\na = x\n",
+		"[s]\r\na = x\r\n\r\nb = 1, 2\r[t]\rn = 3",
+		"[s]\na = x\r",
+		"[s]\na = xxxxxxxxxx\n",
+		"[s]\na = x\n[t]\nn = x\n",
+	} {
+		want, wantErr := p.Parse(strings.NewReader(input))
+		for _, parse := range []func() (*Store, error){
+			func() (*Store, error) { return p.ParseString(input) },
+			func() (*Store, error) { return p.ParseBytes([]byte(input)) },
+		} {
+			got, err := parse()
+			if fmt.Sprint(err) != fmt.Sprint(wantErr) || err == nil && !got.Equal(want) {
+				t.Fatalf("%q: %v %v", input, err, wantErr)
+			}
+		}
+	}
+
+	p.CRBreaks = false
+	p.MaxInputSize = 10
+	for _, input := range []string{"[s]\ra = x\n", "[s]\na = xxxxxxxx\n"} {
+		want, wantErr := p.Parse(strings.NewReader(input))
+		got, err := p.ParseString(input)
+		if fmt.Sprint(err) != fmt.Sprint(wantErr) || err == nil && !got.Equal(want) {
+			t.Fatalf("%q: %v %v", input, err, wantErr)
+		}
+	}
+}
+
+func TestParseContext(t *testing.T) {
+	p := NewParser()
+	s := p.AddSection("sect")
+	s.AddInt64("x")
+	ctx, cancel := context.WithCancel(context.Background())
+	store, err := p.ParseContext(ctx, strings.NewReader("[sect]\nx=1\n"))
+	if err != nil || s.Field("x").Int64Val(store) != 1 {
+		t.Fatal(err)
+	}
+
+	cancel()
+	_, err = p.ParseContext(ctx, strings.NewReader("[sect]\nx=1\n"))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Line != 1 {
+		t.Fatal(err)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	p := NewParser("MaxLineLen", 10, "MaxSections", 2, "MaxSettings", 3)
+	s := p.AddSection("sect")
+	s.AddString("s")
+	input := "[sect]\ns=123456\n[sect]\ns=1\ns=2\n"
+	if _, err := p.Parse(strings.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+
+	expectErr := func(input, msg string) {
+		t.Helper()
+		_, err := p.Parse(strings.NewReader(input))
+		if err == nil || err.Error() != msg {
+			t.Fatalf("Expected %q, got %v", msg, err)
+		}
+	}
+	expectErr("[sect]\ns=123456789\n", "Line 2: Line too long, the limit is 10 bytes")
+	expectErr(input+"[sect]\n", "Line 6: Too many sections, the limit is 2")
+	expectErr(input+"s=3\n", "Line 6: In section sect: Too many settings, the limit is 3")
+
+	p = NewParser("MaxInputSize", 20)
+	p.AddSection("sect").AddString("s")
+	if _, err := p.Parse(strings.NewReader("[sect]\ns=0123456789\n")); err != nil {
+		t.Fatal(err)
+	}
+	expectErr("[sect]\ns=01234567890\n", "Line 1: Input too large, the limit is 20 bytes")
+}
+
+func TestLongLines(t *testing.T) {
+	p := NewParser()
+	s := p.AddSection("sect")
+	s.AddString("s")
+	long := strings.Repeat("x", 200000)
+	store, err := p.Parse(strings.NewReader("[sect]\r\ns=" + long + "\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Field("s").StringVal(store) != long {
+		t.Fatal("Long value")
+	}
+
+	p.MaxLineLen = 100000
+	_, err = p.Parse(strings.NewReader("[sect]\ns=" + long))
+	if err == nil || err.Error() != "Line 2: Line too long, the limit is 100000 bytes" {
+		t.Fatal(err)
+	}
+}
+
+func TestUndefinedSection(t *testing.T) {
+	p := NewParser()
+	p.AddSection("sect").AddString("s")
+	_, err := p.Parse(strings.NewReader("[sect]\ns=1\n[other]\n"))
+	if err == nil || err.Error() != "Line 3: Undefined section other" {
+		t.Fatal(err)
+	}
+
+	// Sections added after a parse are recognized by the next parse
+	p.AddSection("other")
+	if _, err := p.Parse(strings.NewReader("[sect]\ns=1\n[other]\n")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Names containing characters that are regexp metacharacters, which once broke header matching.
+
+func TestMetacharNames(t *testing.T) {
+	p := NewParser()
+	names := []string{"$", "a$", "$b", "-", "a-b", "x_$-9"}
+	for _, name := range names {
+		p.AddSection(name).AddString(name)
+	}
+	var input strings.Builder
+	for _, name := range names {
+		input.WriteString("[" + name + "]\n" + name + " = in " + name + "\n")
+	}
+	store, err := p.Parse(strings.NewReader(input.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		if x := store.GetString(name + "." + name); x != "in "+name {
+			t.Fatal(name, x)
+		}
+	}
+
+	expectPanic(t, "Invalid section name a.b", func() { p.AddSection("a.b") })
+	expectPanic(t, "Invalid section name ", func() { p.AddSection("") })
+	expectPanic(t, "Invalid field name a|b", func() { p.Section("$").AddString("a|b") })
+}
+
+func TestQuoteStyles(t *testing.T) {
+	p := NewParser("LiteralQuoteChar", '\'', "Escapes", true, "ExpandVars", true)
+	s := p.AddSection("sect")
+	for _, name := range []string{"dq", "sq", "bare", "esc", "sqesc"} {
+		s.AddString(name)
+	}
+	t.Setenv("INI_TEST_X", "x")
+	store, err := p.Parse(strings.NewReader(`
+[sect]
+dq = "a $INI_TEST_X\t\"b\"\\"
+sq = 'a $INI_TEST_X\t\"b\"'
+bare = a\tb
+esc = "\q\"
+sqesc = '"a"'
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]string{
+		"dq":    "a x\t\"b\"\\",
+		"sq":    `a $INI_TEST_X\t\"b\"`,
+		"bare":  `a\tb`,
+		"esc":   `q\`,
+		"sqesc": `"a"`,
+	}
+	for name, val := range expect {
+		if x := s.Field(name).StringVal(store); x != val {
+			t.Fatalf("%s: %q", name, x)
+		}
+	}
+}
+
+func TestRequireQuotes(t *testing.T) {
+	p := NewParser("RequireQuotes", true, "LiteralQuoteChar", '\'')
+	s := p.AddSection("sect")
+	s.AddString("a")
+	s.AddString("b")
+	store, err := p.Parse(strings.NewReader("[sect]\na = \"x#y\"\nb = 'x=y'\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.GetString("sect.a") != "x#y" || store.GetString("sect.b") != "x=y" {
+		t.Fatal("Values")
+	}
+	for _, input := range []string{"[sect]\na = x#y\n", "[sect]\na = x=y\n"} {
+		_, err := p.Parse(strings.NewReader(input))
+		if err == nil || err.Error() != "Line 2: In section sect: Value of a must be quoted because it contains # or =" {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestIntPrefixes(t *testing.T) {
+	p := NewParser()
+	s := p.AddSection("sect")
+	i := s.AddInt64("i")
+	u := s.AddUint64("u")
+	input := "[sect]\ni = -0x1_0\nu = 0o17\n"
+	if _, err := p.Parse(strings.NewReader(input)); err == nil {
+		t.Fatal("Should fail")
+	}
+	p.IntPrefixes = true
+	store, err := p.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i.Int64Val(store) != -16 || u.Uint64Val(store) != 15 {
+		t.Fatal(i.Int64Val(store), u.Uint64Val(store))
+	}
+	for _, c := range []struct {
+		s string
+		v uint64
+	}{{"0b101", 5}, {"017", 15}, {"1_000", 1000}, {"0xFF", 255}} {
+		if v, ok := ParseUint64Prefixed(c.s); !ok || v != c.v {
+			t.Fatal(c.s, v)
+		}
+	}
+	if _, ok := ParseInt64Prefixed("0x"); ok {
+		t.Fatal("0x")
+	}
+}
+
+func TestBoolSynonyms(t *testing.T) {
+	p := NewParser()
+	s := p.AddSection("sect")
+	a := s.AddBool("a")
+	var b bool
+	s.AddBoolVar("b", &b)
+	if _, err := p.Parse(strings.NewReader("[sect]\na = yes\n")); err == nil {
+		t.Fatal("Should fail")
+	}
+	p = NewParser("BoolSynonyms", true)
+	s = p.AddSection("sect")
+	a = s.AddBool("a")
+	s.AddBoolVar("b", &b)
+	for _, c := range []struct {
+		a, b   string
+		va, vb bool
+	}{
+		{"yes", "no", true, false},
+		{"ON", "Off", true, false},
+		{"1", "0", true, false},
+		{"True", "FALSE", true, false},
+		{"", "false", true, false},
+	} {
+		store, err := p.Parse(strings.NewReader("[sect]\na=" + c.a + "\nb=" + c.b + "\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.BoolVal(store) != c.va || b != c.vb {
+			t.Fatal(c)
+		}
+	}
+	if _, err := p.Parse(strings.NewReader("[sect]\na = y\n")); err == nil {
+		t.Fatal("Should fail")
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	input := `
+[server:production]
+port = 443
+[server]
+host = localhost
+port = 8080
+[server:test]
+port = 1234
+bogus = 1
+`
+	p := NewParser()
+	s := p.AddSection("server")
+	s.AddString("host")
+	s.AddInt64("port")
+	store, err := p.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.GetInt64("server.port") != 8080 {
+		t.Fatal("Base")
+	}
+
+	p.Profile = "production"
+	store, err = p.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.GetInt64("server.port") != 443 || store.GetString("server.host") != "localhost" {
+		t.Fatal("Production")
+	}
+
+	p.Profile = "test"
+	if _, err := p.Parse(strings.NewReader(input)); err == nil ||
+		err.Error() != "Line 9: In section server: No field bogus" {
+		t.Fatal(err)
+	}
+	if _, err := p.Parse(strings.NewReader("[client:test]\n")); err == nil {
+		t.Fatal("Should fail")
+	}
+}
+
+func TestDirectives(t *testing.T) {
+	input := `
+[sect]
+@if os == linux
+path = linux
+  @if arch != "arm64"
+  arch = other
+  @else
+  arch = arm64
+  @endif
+@elif os == darwin
+path = darwin
+@elif os == darwin
+path = never
+@else
+path = other
+[unknown]
+@endif
+`
+	p := NewParser()
+	s := p.AddSection("sect")
+	s.AddString("path")
+	s.AddString("arch")
+	if _, err := p.Parse(strings.NewReader(input)); err == nil {
+		t.Fatal("Should fail without Facts")
+	}
+	for _, c := range []struct {
+		facts      map[string]string
+		path, arch string
+	}{
+		{map[string]string{"os": "linux", "arch": "arm64"}, "linux", "arm64"},
+		{map[string]string{"os": "linux", "arch": "amd64"}, "linux", "other"},
+		{map[string]string{"os": "darwin", "arch": "arm64"}, "darwin", ""},
+	} {
+		p.Facts = c.facts
+		store, err := p.Parse(strings.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if store.GetString("sect.path") != c.path || store.GetString("sect.arch") != c.arch {
+			t.Fatal(c.facts, store.RedactedMap())
+		}
+	}
+	p.Facts = map[string]string{}
+	if _, err := p.Parse(strings.NewReader(input)); err == nil ||
+		err.Error() != "Line 16: Undefined section unknown" {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct{ input, msg string }{
+		{"@if os\n@endif", "Line 1: Invalid condition: os"},
+		{"@if a b == c\n@endif", "Line 1: Invalid condition: a b == c"},
+		{"[sect]\n@endif", "Line 2: In section sect: @endif without @if"},
+		{"@if a == b\n@else\n@elif a == c\n@endif", "Line 3: @elif after @else"},
+		{"@if a == b\n@else x\n@endif", "Line 2: Unexpected text after @else"},
+		{"@iff a == b", "Line 1: Unknown directive @iff"},
+		{"@if a == b\n@if a == c\n@endif", "Line 1: @if without @endif"},
+	} {
+		if _, err := p.Parse(strings.NewReader(c.input)); err == nil || err.Error() != c.msg {
+			t.Fatalf("%q: %v", c.input, err)
+		}
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	p := NewParser()
+	s := p.AddSection("sect")
+	a := s.Add("a", TyInt64, int64(7), ParseInt64)
+	b := s.AddString("b").DefaultFunc(func() any { return "computed" })
+	c := s.AddString("c")
+	store, err := p.Parse(strings.NewReader("[sect]\nc = set\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Default() != int64(7) || b.Default() != "" {
+		t.Fatal("Default")
+	}
+	if !a.IsDefault(store) || !b.IsDefault(store) || c.IsDefault(store) {
+		t.Fatal("IsDefault")
+	}
+	full := store.WithDefaults()
+	if a.IsDefault(full) || b.IsDefault(full) || c.IsDefault(full) {
+		t.Fatal("WithDefaults presence")
+	}
+	if a.Int64Val(full) != 7 || b.StringVal(full) != "computed" || c.StringVal(full) != "set" {
+		t.Fatal(full.RedactedMap())
+	}
+	if !a.IsDefault(store) || store.Equal(full) {
+		t.Fatal("Store changed")
+	}
+}
+
+func TestParseSections(t *testing.T) {
+	p := NewParser()
+	mine := p.AddSection("mine")
+	mine.AddInt64("a")
+	theirs := p.AddSection("theirs")
+	theirs.AddInt64("b")
+	input := `
+stray = 1
+[theirs]
+b = not a number
+[foreign]
+whatever = x
+[mine]
+a = 1
+`
+	if _, err := p.Parse(strings.NewReader(input)); err == nil {
+		t.Fatal("Should fail")
+	}
+	store, err := p.ParseSections(strings.NewReader(input), "mine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.GetInt64("mine.a") != 1 || theirs.Field("b").Present(store) {
+		t.Fatal(store.RedactedMap())
+	}
+	if _, err := p.ParseSections(strings.NewReader(input+"[mine]\na = x\n"), "mine"); err == nil {
+		t.Fatal("Should fail")
+	}
+	if _, err := p.ParseSections(strings.NewReader("[foreign]\n?\n"), "mine"); err == nil {
+		t.Fatal("Should fail on syntax")
+	}
+	expectPanic(t, "No section foreign", func() {
+		p.ParseSections(strings.NewReader(input), "foreign")
+	})
+}
+
+func TestOnUnknownSection(t *testing.T) {
+	plugins := map[string]bool{"plugin-foo": true}
+	p := NewParser()
+	p.OnUnknownSection = func(name string) *Section {
+		if !plugins[name] {
+			return nil
+		}
+		s := p.AddSection(name)
+		s.AddInt64("level")
+		return s
+	}
+	store, err := p.Parse(strings.NewReader("[plugin-foo]\nlevel = 3\n[plugin-foo]\nlevel = 4\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.GetInt64("plugin-foo.level") != 4 {
+		t.Fatal(store.RedactedMap())
+	}
+	if _, err := p.Parse(strings.NewReader("[plugin-bar]\n")); err == nil ||
+		err.Error() != "Line 1: Undefined section plugin-bar" {
+		t.Fatal(err)
+	}
+
+	q := NewParser("OnUnknownSection", func(name string) *Section { return p.Section("plugin-foo") })
+	expectPanic(t, "OnUnknownSection must return a section named x in the parser", func() {
+		q.Parse(strings.NewReader("[x]\n"))
+	})
+}
+
+func TestCommentFor(t *testing.T) {
+	input := `# about the section
+# second line
+[sect]
+# managed by ansible
+a = 1
+
+# detached
+
+b = 2
+# for c
+c = 3
+# new c wins
+c = 4
+`
+	p := NewParser()
+	s := p.AddSection("sect")
+	a := s.AddInt64("a")
+	b := s.AddInt64("b")
+	c := s.AddInt64("c")
+	store, err := p.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.CommentFor(a) != "" || store.SectionComment(s) != "" {
+		t.Fatal("Not captured by default")
+	}
+	p.CaptureComments = true
+	store, err = p.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x := store.SectionComment(s); x != "about the section\nsecond line" {
+		t.Fatalf("%q", x)
+	}
+	if store.CommentFor(a) != "managed by ansible" || store.CommentFor(b) != "" ||
+		store.CommentFor(c) != "new c wins" {
+		t.Fatal(store.comments)
+	}
+	var out strings.Builder
+	if err := store.WriteEffective(&out, &EffectiveOptions{HideOrigins: true}); err != nil {
+		t.Fatal(err)
+	}
+	expect := `# about the section
+# second line
+[sect]
+# managed by ansible
+a = 1
+b = 2
+# new c wins
+c = 4
+`
+	if out.String() != expect {
+		t.Fatalf("Got\n%s", out.String())
+	}
+}
+
+func TestOrder(t *testing.T) {
+	p := NewParser()
+	for _, sectName := range []string{"zeta", "alpha", "mid"} {
+		s := p.AddSection(sectName)
+		for _, name := range []string{"z", "a", "m"} {
+			s.AddString(name)
+		}
+	}
+	names := func(sections []*Section) (result []string) {
+		for _, s := range sections {
+			result = append(result, s.Name())
+		}
+		return
+	}
+	paths := func(fields []*Field) (result []string) {
+		for _, f := range fields {
+			result = append(result, f.section.Name()+"."+f.Name())
+		}
+		return
+	}
+	if s := names(p.Sections()); !slices.Equal(s, []string{"zeta", "alpha", "mid"}) {
+		t.Fatal(s)
+	}
+	if f := paths(p.Section("mid").Fields()); !slices.Equal(f, []string{"mid.z", "mid.a", "mid.m"}) {
+		t.Fatal(f)
+	}
+
+	store, err := p.Parse(strings.NewReader("[mid]\nm=1\n[alpha]\na=1\n[mid]\nz=1\nm=2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := names(store.Sections()); !slices.Equal(s, []string{"mid", "alpha"}) {
+		t.Fatal(s)
+	}
+	if f := paths(store.Fields()); !slices.Equal(f, []string{"mid.m", "mid.z", "alpha.a"}) {
+		t.Fatal(f)
+	}
+	if f := paths(store.WithDefaults().Fields()); len(f) != 9 || f[0] != "zeta.z" || f[8] != "mid.m" {
+		t.Fatal(f)
+	}
+}
+
+func TestLenient(t *testing.T) {
+	p := NewParser(WithLenient(true))
+	s := p.AddSection("sect")
+	s.AddInt64("n")
+	input := "top = 1\n[sect]\nn = 2\nextra = \" x \"\n[other]\na = b\n[other]\n[sect]\nm =\n"
+	store, err := p.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []RawSetting{
+		{1, "", "top", "1"},
+		{4, "sect", "extra", " x "},
+		{5, "other", "", ""},
+		{6, "other", "a", "b"},
+		{9, "sect", "m", ""},
+	}
+	if u := store.Unknown(); !slices.Equal(u, expect) || store.GetInt64("sect.n") != 2 {
+		t.Fatal(u)
+	}
+	if _, err := p.Parse(strings.NewReader("[sect]\nn = x\n")); err == nil {
+		t.Fatal("Invalid values are still errors")
+	}
+
+	// The unknown settings are preserved by Write
+	var out strings.Builder
+	if err := store.Write(&out, nil); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "top = 1\n\n[sect]\nn = 2\nextra = \" x \"\nm =\n\n[other]\na = b\n" {
+		t.Fatalf("Got\n%s", out.String())
+	}
+	again, err := p.Parse(strings.NewReader(out.String()))
+	if err != nil || !again.Equal(store) || len(again.Unknown()) != len(expect) {
+		t.Fatal(err)
+	}
+
+	p.Lenient = false
+	if _, err := p.Parse(strings.NewReader(input)); err == nil {
+		t.Fatal("Should fail")
+	}
+}
+
+func TestDuplicateSections(t *testing.T) {
+	input := "[a]\nx = 1\n[b]\n[a:p]\n[a]\ny = 2\n"
+	p := NewParser()
+	a := p.AddSection("a")
+	x, y := a.AddInt64("x"), a.AddInt64("y")
+	p.AddSection("b")
+	store, err := p.Parse(strings.NewReader(input))
+	if err != nil || x.Int64Val(store) != 1 || y.Int64Val(store) != 2 {
+		t.Fatal(err)
+	}
+
+	p.DuplicateSections = RejectSections
+	if _, err := p.Parse(strings.NewReader(input)); err == nil ||
+		err.Error() != "Line 5: Duplicate section a" {
+		t.Fatal(err)
+	}
+	if _, err := p.Parse(strings.NewReader("[a]\n[a:p]\n[b]\n[a:p]\n")); err == nil ||
+		err.Error() != "Line 4: Duplicate section a:p" {
+		t.Fatal(err)
+	}
+	if _, err := p.Parse(strings.NewReader("[a]\n[a:p]\n[a:q]\n")); err != nil {
+		t.Fatal(err)
+	}
+	if NewParser(WithDuplicateSections(RejectSections)).DuplicateSections != RejectSections {
+		t.Fatal("Option")
+	}
+}
+
+func TestOnOverride(t *testing.T) {
+	var warnings []string
+	p := NewParser(WithProfile("p"), WithOnOverride(func(field *Field, line int, old, new string) error {
+		if new == "bad" {
+			return errors.New("Conflicting settings")
+		}
+		warnings = append(warnings, fmt.Sprintf("%d %s %s %s", line, field.Name(), old, new))
+		return nil
+	}))
+	a := p.AddSection("a")
+	x := a.AddString("x")
+	a.AddString("y")
+	a.AddString("z").Secret()
+	store, err := p.Parse(strings.NewReader(
+		"[a]\nx = 1\ny = 1\nz = hunter2\n[a:p]\nx = 2\n[a]\nx = \"3\"\nz = hunter3\n"))
+	if err != nil || x.StringVal(store) != "2" {
+		t.Fatal(err)
+	}
+	if strings.Join(warnings, "|") != "8 x 1 3|9 z <redacted> <redacted>" {
+		t.Fatal(warnings)
+	}
+	_, err = p.Parse(strings.NewReader("[a]\nx = 1\nx = bad\n"))
+	if err == nil || err.Error() != "Line 3: In section a: Conflicting settings" {
+		t.Fatal(err)
+	}
+}
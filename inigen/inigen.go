@@ -0,0 +1,188 @@
+// Package inigen generates Go code that declares an ini schema from a sample ini file.
+//
+// The generated code defines a struct type with a nested struct for each section and a member for
+// each setting, and a function that returns an [ini.Parser] whose Var fields store the parsed
+// values into such a struct.  The type of each field is inferred from its value in the sample:
+// bool for `true` and `false`, int64 for decimal integers, float64 for other numbers, and string
+// otherwise.  The command inigen is a front end for this package.
+package inigen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/lars-t-hansen/ini"
+)
+
+// Options control the generated code.
+type Options struct {
+	Package  string // The package name (default "config")
+	TypeName string // The name of the struct type (default "Config")
+	FuncName string // The name of the parser constructor (default "NewParser")
+}
+
+type section struct {
+	name   string
+	fields []*field
+	byName map[string]*field
+}
+
+type field struct {
+	name string
+	ty   string // "bool", "int64", "float64" or "string"
+}
+
+type collector struct {
+	sections []*section
+	byName   map[string]*section
+	current  *section
+}
+
+func (c *collector) SectionStart(line int, name string) error {
+	s := c.byName[name]
+	if s == nil {
+		s = &section{name: name, byName: make(map[string]*field)}
+		c.byName[name] = s
+		c.sections = append(c.sections, s)
+	}
+	c.current = s
+	return nil
+}
+
+func (c *collector) KeyValue(line int, sectName, key, value string) error {
+	if c.current == nil {
+		return fmt.Errorf("Setting %s outside section", key)
+	}
+	ty := inferType(value)
+	if f := c.current.byName[key]; f != nil {
+		if f.ty != ty {
+			f.ty = "string"
+		}
+		return nil
+	}
+	f := &field{name: key, ty: ty}
+	c.current.byName[key] = f
+	c.current.fields = append(c.current.fields, f)
+	return nil
+}
+
+func (c *collector) Comment(line int, text string) error {
+	return nil
+}
+
+func (c *collector) EOF(line int) error {
+	return nil
+}
+
+func inferType(value string) string {
+	if value == "true" || value == "false" {
+		return "bool"
+	}
+	if _, ok := ini.ParseInt64(value); ok {
+		return "int64"
+	}
+	if _, ok := ini.ParseFloat64(value); ok {
+		return "float64"
+	}
+	return "string"
+}
+
+// Generate reads a sample ini file from r, which is parsed with the parser's options, and returns
+// formatted Go source code that declares its schema.  If parser is nil then a parser with default
+// options is used.
+func Generate(r io.Reader, parser *ini.Parser, opts Options) ([]byte, error) {
+	if parser == nil {
+		parser = ini.NewParser()
+	}
+	if opts.Package == "" {
+		opts.Package = "config"
+	}
+	if opts.TypeName == "" {
+		opts.TypeName = "Config"
+	}
+	if opts.FuncName == "" {
+		opts.FuncName = "NewParser"
+	}
+	c := &collector{byName: make(map[string]*section)}
+	if err := parser.ParseEvents(r, c); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by inigen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", opts.Package)
+	fmt.Fprintf(&b, "import \"github.com/lars-t-hansen/ini\"\n\n")
+	fmt.Fprintf(&b, "// %s holds the values of the configuration.\n", opts.TypeName)
+	fmt.Fprintf(&b, "type %s struct {\n", opts.TypeName)
+	sectIdents := identifiers(c.sections, func(s *section) string { return s.name })
+	for i, s := range c.sections {
+		fmt.Fprintf(&b, "%s struct {\n", sectIdents[i])
+		fieldIdents := identifiers(s.fields, func(f *field) string { return f.name })
+		for j, f := range s.fields {
+			fmt.Fprintf(&b, "%s %s\n", fieldIdents[j], f.ty)
+		}
+		fmt.Fprintf(&b, "}\n")
+	}
+	fmt.Fprintf(&b, "}\n\n")
+	fmt.Fprintf(&b, "// %s returns a parser for the configuration that stores the values into cfg.\n",
+		opts.FuncName)
+	fmt.Fprintf(&b, "func %s(cfg *%s) *ini.Parser {\n", opts.FuncName, opts.TypeName)
+	fmt.Fprintf(&b, "p := ini.NewParser()\n")
+	for i, s := range c.sections {
+		if len(s.fields) == 0 {
+			fmt.Fprintf(&b, "p.AddSection(%q)\n", s.name)
+			continue
+		}
+		fmt.Fprintf(&b, "{\ns := p.AddSection(%q)\n", s.name)
+		fieldIdents := identifiers(s.fields, func(f *field) string { return f.name })
+		for j, f := range s.fields {
+			fmt.Fprintf(&b, "s.Add%sVar(%q, &cfg.%s.%s)\n",
+				methodName[f.ty], f.name, sectIdents[i], fieldIdents[j])
+		}
+		fmt.Fprintf(&b, "}\n")
+	}
+	fmt.Fprintf(&b, "return p\n}\n")
+	return format.Source(b.Bytes())
+}
+
+var methodName = map[string]string{
+	"bool":    "Bool",
+	"int64":   "Int64",
+	"float64": "Float64",
+	"string":  "String",
+}
+
+// identifiers returns distinct exported Go identifiers for the names of the elements of xs.
+func identifiers[T any](xs []T, name func(T) string) []string {
+	idents := make([]string, len(xs))
+	used := make(map[string]bool)
+	for i, x := range xs {
+		base := identifier(name(x))
+		id := base
+		for n := 2; used[id]; n++ {
+			id = fmt.Sprintf("%s%d", base, n)
+		}
+		used[id] = true
+		idents[i] = id
+	}
+	return idents
+}
+
+// identifier converts an ini name to an exported Go identifier, eg "max-conns" to "MaxConns".
+func identifier(name string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool {
+		return r == '-' || r == '_' || r == '$'
+	}) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	id := b.String()
+	if id == "" || !unicode.IsLetter(rune(id[0])) {
+		id = "X" + id
+	}
+	return id
+}
@@ -0,0 +1,47 @@
+package inigen
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	code, err := Generate(strings.NewReader(`
+[server]
+host = localhost
+port = 8080
+ratio = 1.5
+verbose = true
+mixed = 1
+mixed = x
+max-conns = 1
+max_conns = 2
+[2fa]
+`), nil, Options{Package: "cfg", TypeName: "Settings"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := strings.Join(strings.Fields(string(code)), " ")
+	if _, err := parser.ParseFile(token.NewFileSet(), "gen.go", code, 0); err != nil {
+		t.Fatal(err, src)
+	}
+	for _, want := range []string{
+		"package cfg ",
+		"type Settings struct {",
+		"func NewParser(cfg *Settings) *ini.Parser {",
+		"Host string Port int64 Ratio float64 Verbose bool Mixed string MaxConns int64 MaxConns2 int64",
+		"X2fa struct {",
+		`s.AddInt64Var("max_conns", &cfg.Server.MaxConns2)`,
+		`p.AddSection("2fa")`,
+	} {
+		if !strings.Contains(src, want) {
+			t.Fatalf("Missing %q in\n%s", want, src)
+		}
+	}
+
+	if _, err := Generate(strings.NewReader("x = 1\n"), nil, Options{}); err == nil {
+		t.Fatal("Should fail")
+	}
+}
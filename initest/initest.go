@@ -0,0 +1,101 @@
+// Package initest provides utilities for testing code that uses package ini: checking expected
+// parse errors, comparing output with golden files, and injecting I/O errors into the input.
+package initest
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lars-t-hansen/ini"
+)
+
+// ParseError returns the *ini.ParseError expected for an error at the line, which is 0 if the
+// error has no line, in the section, which is "" if the error has no section, with the irritant.
+// Use it with [CheckParseError].
+func ParseError(line int, section, irritant string) *ini.ParseError {
+	return &ini.ParseError{Line: line, Section: section, Irritant: irritant}
+}
+
+// CheckParseError reports a test error unless err is or wraps an *ini.ParseError with the Line,
+// Section and Irritant of want.  An empty Irritant in want matches any irritant, and want's Err is
+// not compared.
+func CheckParseError(t testing.TB, err error, want *ini.ParseError) {
+	t.Helper()
+	var pe *ini.ParseError
+	if !errors.As(err, &pe) {
+		t.Errorf("Got error %v, want ParseError %q", err, want.Error())
+		return
+	}
+	if pe.Line != want.Line || pe.Section != want.Section ||
+		want.Irritant != "" && pe.Irritant != want.Irritant {
+		t.Errorf("Got ParseError %q, want %q", pe.Error(), want.Error())
+	}
+}
+
+// UpdateEnv is the environment variable that makes [Golden] update the golden files: run the tests
+// with INITEST_UPDATE=1 after checking that the new output is right.
+const UpdateEnv = "INITEST_UPDATE"
+
+// Golden reports a test error unless got equals the contents of the golden file
+// testdata/name.golden, relative to the test's directory, eg the output of [ini.Format] or
+// [ini.Store.WriteEffective] for some test input.  If the environment variable UpdateEnv is set to
+// a nonempty value then the file is instead created or replaced with got.
+func Golden(t testing.TB, name string, got []byte) {
+	t.Helper()
+	golden(t, "testdata", name, got)
+}
+
+// golden is Golden for the golden files in dir.
+func golden(t testing.TB, dir, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join(dir, name+".golden")
+	if os.Getenv(UpdateEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("%v (set %s=1 to create it)", err, UpdateEnv)
+		return
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Output differs from %s:\n--- got\n%s\n--- want\n%s", path, got, want)
+	}
+}
+
+// ErrorAfter returns a reader that reads from r until n bytes have been read and then fails with
+// err, or with io.ErrUnexpectedEOF if err is nil, to exercise the handling of I/O errors that
+// occur in the middle of the input.  If r ends before n bytes the reader ends with it.
+func ErrorAfter(r io.Reader, n int, err error) io.Reader {
+	if err == nil {
+		err = io.ErrUnexpectedEOF
+	}
+	return &errorReader{r: r, n: n, err: err}
+}
+
+type errorReader struct {
+	r   io.Reader
+	n   int
+	err error
+}
+
+func (er *errorReader) Read(p []byte) (int, error) {
+	if er.n <= 0 {
+		return 0, er.err
+	}
+	if len(p) > er.n {
+		p = p[:er.n]
+	}
+	k, err := er.r.Read(p)
+	er.n -= k
+	return k, err
+}
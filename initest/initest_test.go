@@ -0,0 +1,99 @@
+package initest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lars-t-hansen/ini"
+)
+
+// recorder is a testing.TB that records failures instead of reporting them.
+type recorder struct {
+	testing.TB
+	failed bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failed = true
+}
+
+func newParser() *ini.Parser {
+	p := ini.NewParser()
+	p.AddSection("s").AddInt64("n")
+	return p
+}
+
+func TestCheckParseError(t *testing.T) {
+	_, err := newParser().Parse(strings.NewReader("[s]\nn = x\n"))
+	CheckParseError(t, err, ParseError(2, "s", ""))
+	CheckParseError(t, fmt.Errorf("wrapped: %w", err), ParseError(2, "s", ""))
+	for _, want := range []*ini.ParseError{
+		ParseError(1, "s", ""),
+		ParseError(2, "", ""),
+		ParseError(2, "s", "Something else"),
+	} {
+		r := &recorder{TB: t}
+		CheckParseError(r, err, want)
+		if !r.failed {
+			t.Fatal(want)
+		}
+	}
+	r := &recorder{TB: t}
+	CheckParseError(r, errors.New("plain"), ParseError(0, "", ""))
+	if !r.failed {
+		t.Fatal("Not a ParseError")
+	}
+}
+
+func TestGolden(t *testing.T) {
+	t.Setenv(UpdateEnv, "")
+	var out strings.Builder
+	if err := ini.Format(strings.NewReader("  [s]\nn=1\n"), &out, nil); err != nil {
+		t.Fatal(err)
+	}
+	Golden(t, "format", []byte(out.String()))
+
+	r := &recorder{TB: t}
+	Golden(r, "format", []byte("[s]\nn = 2\n"))
+	if !r.failed {
+		t.Fatal("Should differ")
+	}
+	r = &recorder{TB: t}
+	Golden(r, "missing", nil)
+	if !r.failed {
+		t.Fatal("Should be missing")
+	}
+
+	dir := t.TempDir()
+	t.Setenv(UpdateEnv, "1")
+	golden(t, filepath.Join(dir, "testdata"), "new", []byte("data"))
+	if data, err := os.ReadFile(filepath.Join(dir, "testdata", "new.golden")); err != nil ||
+		string(data) != "data" {
+		t.Fatal(err)
+	}
+}
+
+func TestErrorAfter(t *testing.T) {
+	boom := errors.New("boom")
+	_, err := newParser().Parse(ErrorAfter(strings.NewReader("[s]\nn = 1\nn = 2\n"), 6, boom))
+	if !errors.Is(err, boom) {
+		t.Fatal(err)
+	}
+	CheckParseError(t, err, ParseError(1, "", "I/O error: boom"))
+
+	data, err := io.ReadAll(ErrorAfter(strings.NewReader("abc"), 2, nil))
+	if string(data) != "ab" || err != io.ErrUnexpectedEOF {
+		t.Fatal(string(data), err)
+	}
+	data, err = io.ReadAll(ErrorAfter(strings.NewReader("abc"), 10, nil))
+	if string(data) != "abc" || err != nil {
+		t.Fatal(string(data), err)
+	}
+}
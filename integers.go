@@ -0,0 +1,80 @@
+package ini
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// Integer is the set of Go integer types, the types accepted by [AddIntOf].
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// AddIntOf adds a new field of the given name to the section whose values are of the integer type
+// T.  The name must not be present in the section and must be syntactically valid (see package
+// comments).  The accepted values are as for ParseInt64 or ParseUint64, depending on the signedness
+// of T, or as for their Prefixed variants if the parser's IntPrefixes is true, and in the parser's
+// NumberFormat, but they must also be in the range of T.  The field has type TyUser and the default
+// value is T(0).  Use [ValueOf] to access the value with its type.
+func AddIntOf[T Integer](section *Section, name string) *Field {
+	return section.Add(name, TyUser, T(0), func(s string) (any, bool) {
+		base := 10
+		if section.parser.IntPrefixes {
+			base = 0
+		}
+		return section.parser.parseNumber(s, func(s string) (any, bool) {
+			return parseIntOf[T](s, base)
+		})
+	})
+}
+
+// parseIntOf parses s as an integer of type T in the given base, as for strconv.ParseInt.
+func parseIntOf[T Integer](s string, base int) (any, bool) {
+	ty := reflect.TypeFor[T]()
+	if ty.Kind() >= reflect.Int && ty.Kind() <= reflect.Int64 {
+		v, err := strconv.ParseInt(s, base, ty.Bits())
+		return T(v), err == nil
+	}
+	v, err := strconv.ParseUint(s, base, ty.Bits())
+	return T(v), err == nil
+}
+
+// AddInt adds a new int field of the given name to the section, see [AddIntOf].
+func (section *Section) AddInt(name string) *Field {
+	return AddIntOf[int](section, name)
+}
+
+// AddInt8 adds a new int8 field of the given name to the section, see [AddIntOf].
+func (section *Section) AddInt8(name string) *Field {
+	return AddIntOf[int8](section, name)
+}
+
+// AddInt16 adds a new int16 field of the given name to the section, see [AddIntOf].
+func (section *Section) AddInt16(name string) *Field {
+	return AddIntOf[int16](section, name)
+}
+
+// AddInt32 adds a new int32 field of the given name to the section, see [AddIntOf].
+func (section *Section) AddInt32(name string) *Field {
+	return AddIntOf[int32](section, name)
+}
+
+// AddUint adds a new uint field of the given name to the section, see [AddIntOf].
+func (section *Section) AddUint(name string) *Field {
+	return AddIntOf[uint](section, name)
+}
+
+// AddUint8 adds a new uint8 field of the given name to the section, see [AddIntOf].
+func (section *Section) AddUint8(name string) *Field {
+	return AddIntOf[uint8](section, name)
+}
+
+// AddUint16 adds a new uint16 field of the given name to the section, see [AddIntOf].
+func (section *Section) AddUint16(name string) *Field {
+	return AddIntOf[uint16](section, name)
+}
+
+// AddUint32 adds a new uint32 field of the given name to the section, see [AddIntOf].
+func (section *Section) AddUint32(name string) *Field {
+	return AddIntOf[uint32](section, name)
+}
@@ -0,0 +1,59 @@
+package ini
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIntegers(t *testing.T) {
+	type Port uint16
+	p := NewParser()
+	s := p.AddSection("sect")
+	i := s.AddInt("i")
+	i8 := s.AddInt8("i8")
+	i16 := s.AddInt16("i16")
+	i32 := s.AddInt32("i32")
+	u := s.AddUint("u")
+	u8 := s.AddUint8("u8")
+	u16 := s.AddUint16("u16")
+	u32 := s.AddUint32("u32")
+	port := AddIntOf[Port](s, "port")
+	if port.Type() != TyUser {
+		t.Fatal("Type")
+	}
+	store, err := p.Parse(strings.NewReader(`
+[sect]
+i = -1
+i8 = -128
+i16 = 32767
+i32 = -2147483648
+u = 1
+u8 = 255
+u16 = 65535
+u32 = 4294967295
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ValueOf[int](i, store) != -1 || ValueOf[int8](i8, store) != -128 ||
+		ValueOf[int16](i16, store) != 32767 || ValueOf[int32](i32, store) != -2147483648 ||
+		ValueOf[uint](u, store) != 1 || ValueOf[uint8](u8, store) != 255 ||
+		ValueOf[uint16](u16, store) != 65535 || ValueOf[uint32](u32, store) != 4294967295 ||
+		ValueOf[Port](port, store) != 0 {
+		t.Fatal("Values")
+	}
+	expectPanic(t, "ValueOf accessor on differently typed field port", func() {
+		ValueOf[uint16](port, store)
+	})
+
+	for _, input := range []string{"i8 = 128", "u8 = 256", "u8 = -1", "port = 65536", "port = 0x10"} {
+		if _, err := p.Parse(strings.NewReader("[sect]\n" + input)); err == nil {
+			t.Fatal("Should fail", input)
+		}
+	}
+	p.IntPrefixes = true
+	store, err = p.Parse(strings.NewReader("[sect]\nport = 0x1F90\n"))
+	if err != nil || ValueOf[Port](port, store) != 8080 {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,399 @@
+package ini
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// AddListOf adds a new list field of the given name to the section whose values are of type []T.
+// The name must not be present in the section and must be syntactically valid (see package
+// comments).  The elements of the list are separated by the parser's ListDelim, eg
+// `names = a, b, c`.  Each element is subject to blank stripping, variable expansion and quote
+// stripping as for any other value, and must be quoted if it contains ListDelim.  The element
+// values are then parsed by elem, which must produce values of type T, and the error for a value
+// with invalid elements identifies the first of them by its position in the list, counting from 1.
+// The empty value is the empty list.  The field has type TyUser and the default value is the empty
+// list.
+func AddListOf[T any](section *Section, name string, elem func(s string) (any, bool)) *Field {
+	return section.addList(name, []T{}, func(elems []string) (any, []int) {
+		result := make([]T, len(elems))
+		var bad []int
+		for i, e := range elems {
+			v, ok := elem(e)
+			if ok {
+				result[i], ok = v.(T)
+			}
+			if !ok {
+				bad = append(bad, i)
+			}
+		}
+		if bad != nil {
+			return nil, bad
+		}
+		return result, nil
+	})
+}
+
+// AddMapOf adds a new map field of the given name to the section whose values are of type
+// map[string]V.  The name must not be present in the section and must be syntactically valid (see
+// package comments).  The value is a list of `key:value` elements, as for [AddListOf], eg
+// `limits = cpu:2, mem:512`, where each element is split at its first `:` and blanks around the
+// key and value are stripped.  The values are parsed by val, which must produce values of type V.
+// A key may not appear more than once, and its second element is then invalid.  The empty value
+// is the empty map.  The value can also be written as an inline table, a list of `key = value`
+// elements in braces, eg `limits = { cpu = 2, mem = 512 }`, where a key can't contain `:` and the
+// value part of each element is quoted as a whole value rather than as a list element, and need
+// not be quoted as a whole if RequireQuotes is true.  The field has type TyUser and the default
+// value is the empty map.
+func AddMapOf[V any](section *Section, name string, val func(s string) (any, bool)) *Field {
+	return section.addList(name, map[string]V{}, func(elems []string) (any, []int) {
+		result := make(map[string]V, len(elems))
+		var bad []int
+		for i, e := range elems {
+			k, vs, found := strings.Cut(e, ":")
+			k = strings.TrimSpace(k)
+			_, dup := result[k]
+			var v any
+			ok := found && !dup
+			if ok {
+				v, ok = val(strings.TrimSpace(vs))
+			}
+			if ok {
+				result[k], ok = v.(V)
+			}
+			if !ok {
+				bad = append(bad, i)
+			}
+		}
+		if bad != nil {
+			return nil, bad
+		}
+		return result, nil
+	})
+}
+
+// addList adds a list field with the default value whose values are produced by parse from the
+// processed elements, or the indices of the invalid elements.
+func (section *Section) addList(
+	name string,
+	defaultValue any,
+	parse func(elems []string) (any, []int),
+) *Field {
+	var field *Field
+	field = section.Add(name, TyUser, defaultValue, func(s string) (any, bool) {
+		return field.parseList(s, "")
+	})
+	field.list = true
+	field.parseElems = parse
+	return field
+}
+
+// parseList parses the raw text of a value of the list field, where file is the name of the input
+// file that contains it, or "" if not known.
+func (field *Field) parseList(s, file string) (any, bool) {
+	v, _, bad, err := field.parseListElems(s, file)
+	return v, err == nil && bad == nil
+}
+
+// parseListElems is like parseList, but also returns the processed elements and the indices of the
+// invalid ones, or an error if the elements cannot be processed.
+func (field *Field) parseListElems(s, file string) (any, []string, []int, error) {
+	parser := field.section.parser
+	var elems []string
+	var err error
+	if isMap := reflect.ValueOf(field.defaultValue).Kind() == reflect.Map; isMap &&
+		strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+		elems, err = parser.tableValues(s[1:len(s)-1], field.expands(), file)
+	} else if field.records {
+		elems, err = parser.recordValues(s, field.expands(), file)
+	} else {
+		elems, err = parser.listValues(s, field.expands(), file)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	v, bad := field.parseElems(elems)
+	return v, elems, bad, nil
+}
+
+// AddStringList adds a new field of the given name to the section whose values are lists of
+// strings, of type []string, see [AddListOf].  Unlike for other list fields, the default value is
+// nil.
+func (section *Section) AddStringList(name string) *Field {
+	field := AddListOf[string](section, name, ParseString)
+	field.defaultValue = []string(nil)
+	return field
+}
+
+// AddBoolList adds a new field of the given name to the section whose values are lists of
+// booleans, of type []bool, see [AddListOf].  The elements are as for [Section.AddBool].
+func (section *Section) AddBoolList(name string) *Field {
+	return AddListOf[bool](section, name, section.parseBool)
+}
+
+// AddInt64List adds a new field of the given name to the section whose values are lists of
+// int64, of type []int64, see [AddListOf].  The elements are as for [Section.AddInt64].
+func (section *Section) AddInt64List(name string) *Field {
+	return AddListOf[int64](section, name, section.parseInt64)
+}
+
+// AddUint64List adds a new field of the given name to the section whose values are lists of
+// uint64, of type []uint64, see [AddListOf].  The elements are as for [Section.AddUint64].
+func (section *Section) AddUint64List(name string) *Field {
+	return AddListOf[uint64](section, name, section.parseUint64)
+}
+
+// AddFloat64List adds a new field of the given name to the section whose values are lists of
+// float64, of type []float64, see [AddListOf].  The elements are as for [Section.AddFloat64].
+func (section *Section) AddFloat64List(name string) *Field {
+	return AddListOf[float64](section, name, section.parseFloat64)
+}
+
+// AddStringMap adds a new field of the given name to the section whose values are maps from
+// strings to strings, of type map[string]string, see [AddMapOf].
+func (section *Section) AddStringMap(name string) *Field {
+	return AddMapOf[string](section, name, ParseString)
+}
+
+// AddBoolMap adds a new field of the given name to the section whose values are maps from strings
+// to booleans, of type map[string]bool, see [AddMapOf].
+func (section *Section) AddBoolMap(name string) *Field {
+	return AddMapOf[bool](section, name, section.parseBool)
+}
+
+// AddInt64Map adds a new field of the given name to the section whose values are maps from
+// strings to int64, of type map[string]int64, see [AddMapOf].
+func (section *Section) AddInt64Map(name string) *Field {
+	return AddMapOf[int64](section, name, section.parseInt64)
+}
+
+// AddUint64Map adds a new field of the given name to the section whose values are maps from
+// strings to uint64, of type map[string]uint64, see [AddMapOf].
+func (section *Section) AddUint64Map(name string) *Field {
+	return AddMapOf[uint64](section, name, section.parseUint64)
+}
+
+// AddFloat64Map adds a new field of the given name to the section whose values are maps from
+// strings to float64, of type map[string]float64, see [AddMapOf].
+func (section *Section) AddFloat64Map(name string) *Field {
+	return AddMapOf[float64](section, name, section.parseFloat64)
+}
+
+// AddStringListVar adds a new field as for AddStringList and arranges for every successful parse to
+// store the field's value in *p.  The default value is a copy of *p at the time of the call, or
+// nil if *p is nil.
+func (section *Section) AddStringListVar(name string, p *[]string) *Field {
+	return addVar(withDefault(section.AddStringList(name), *p), p)
+}
+
+// AddBoolListVar adds a new field as for AddBoolList and arranges for every successful parse to
+// store the field's value in *p.  The default value is a copy of *p at the time of the call, or
+// empty if *p is nil.
+func (section *Section) AddBoolListVar(name string, p *[]bool) *Field {
+	return addVar(withDefault(section.AddBoolList(name), *p), p)
+}
+
+// AddInt64ListVar adds a new field as for AddInt64List and arranges for every successful parse to
+// store the field's value in *p.  The default value is a copy of *p at the time of the call, or
+// empty if *p is nil.
+func (section *Section) AddInt64ListVar(name string, p *[]int64) *Field {
+	return addVar(withDefault(section.AddInt64List(name), *p), p)
+}
+
+// AddUint64ListVar adds a new field as for AddUint64List and arranges for every successful parse to
+// store the field's value in *p.  The default value is a copy of *p at the time of the call, or
+// empty if *p is nil.
+func (section *Section) AddUint64ListVar(name string, p *[]uint64) *Field {
+	return addVar(withDefault(section.AddUint64List(name), *p), p)
+}
+
+// AddFloat64ListVar adds a new field as for AddFloat64List and arranges for every successful parse
+// to store the field's value in *p.  The default value is a copy of *p at the time of the call, or
+// empty if *p is nil.
+func (section *Section) AddFloat64ListVar(name string, p *[]float64) *Field {
+	return addVar(withDefault(section.AddFloat64List(name), *p), p)
+}
+
+// AddStringMapVar adds a new field as for AddStringMap and arranges for every successful parse to
+// store the field's value in *p.  The default value is a copy of *p at the time of the call, or
+// empty if *p is nil.
+func (section *Section) AddStringMapVar(name string, p *map[string]string) *Field {
+	return addVar(withDefault(section.AddStringMap(name), *p), p)
+}
+
+// AddBoolMapVar adds a new field as for AddBoolMap and arranges for every successful parse to store
+// the field's value in *p.  The default value is a copy of *p at the time of the call, or empty if
+// *p is nil.
+func (section *Section) AddBoolMapVar(name string, p *map[string]bool) *Field {
+	return addVar(withDefault(section.AddBoolMap(name), *p), p)
+}
+
+// AddInt64MapVar adds a new field as for AddInt64Map and arranges for every successful parse to
+// store the field's value in *p.  The default value is a copy of *p at the time of the call, or
+// empty if *p is nil.
+func (section *Section) AddInt64MapVar(name string, p *map[string]int64) *Field {
+	return addVar(withDefault(section.AddInt64Map(name), *p), p)
+}
+
+// AddUint64MapVar adds a new field as for AddUint64Map and arranges for every successful parse to
+// store the field's value in *p.  The default value is a copy of *p at the time of the call, or
+// empty if *p is nil.
+func (section *Section) AddUint64MapVar(name string, p *map[string]uint64) *Field {
+	return addVar(withDefault(section.AddUint64Map(name), *p), p)
+}
+
+// AddFloat64MapVar adds a new field as for AddFloat64Map and arranges for every successful parse to
+// store the field's value in *p.  The default value is a copy of *p at the time of the call, or
+// empty if *p is nil.
+func (section *Section) AddFloat64MapVar(name string, p *map[string]float64) *Field {
+	return addVar(withDefault(section.AddFloat64Map(name), *p), p)
+}
+
+// withDefault sets the field's default value to a copy of v, unless v is nil.
+func withDefault[T any](field *Field, v T) *Field {
+	if !reflect.ValueOf(v).IsNil() {
+		field.defaultValue = cloneValue(v)
+	}
+	return field
+}
+
+// StringListVal returns a []string field's value in the input, or the default if the field was
+// not present.
+func (field *Field) StringListVal(store *Store) []string {
+	return collectionVal[[]string]("StringListVal", field, store)
+}
+
+// BoolListVal returns a []bool field's value in the input, or the default if the field was not
+// present.
+func (field *Field) BoolListVal(store *Store) []bool {
+	return collectionVal[[]bool]("BoolListVal", field, store)
+}
+
+// Int64ListVal returns a []int64 field's value in the input, or the default if the field was not
+// present.
+func (field *Field) Int64ListVal(store *Store) []int64 {
+	return collectionVal[[]int64]("Int64ListVal", field, store)
+}
+
+// Uint64ListVal returns a []uint64 field's value in the input, or the default if the field was not
+// present.
+func (field *Field) Uint64ListVal(store *Store) []uint64 {
+	return collectionVal[[]uint64]("Uint64ListVal", field, store)
+}
+
+// Float64ListVal returns a []float64 field's value in the input, or the default if the field was
+// not present.
+func (field *Field) Float64ListVal(store *Store) []float64 {
+	return collectionVal[[]float64]("Float64ListVal", field, store)
+}
+
+// StringMapVal returns a map[string]string field's value in the input, or the default if the
+// field was not present.
+func (field *Field) StringMapVal(store *Store) map[string]string {
+	return collectionVal[map[string]string]("StringMapVal", field, store)
+}
+
+// BoolMapVal returns a map[string]bool field's value in the input, or the default if the field
+// was not present.
+func (field *Field) BoolMapVal(store *Store) map[string]bool {
+	return collectionVal[map[string]bool]("BoolMapVal", field, store)
+}
+
+// Int64MapVal returns a map[string]int64 field's value in the input, or the default if the field
+// was not present.
+func (field *Field) Int64MapVal(store *Store) map[string]int64 {
+	return collectionVal[map[string]int64]("Int64MapVal", field, store)
+}
+
+// Uint64MapVal returns a map[string]uint64 field's value in the input, or the default if the field
+// was not present.
+func (field *Field) Uint64MapVal(store *Store) map[string]uint64 {
+	return collectionVal[map[string]uint64]("Uint64MapVal", field, store)
+}
+
+// Float64MapVal returns a map[string]float64 field's value in the input, or the default if the
+// field was not present.
+func (field *Field) Float64MapVal(store *Store) map[string]float64 {
+	return collectionVal[map[string]float64]("Float64MapVal", field, store)
+}
+
+func collectionVal[T any](name string, field *Field, store *Store) T {
+	v, ok := field.Value(store).(T)
+	if !ok {
+		panic(name + " accessor on differently typed field")
+	}
+	return v
+}
+
+// listValues splits the raw text of a list value into elements and processes each of them as a
+// value from the named file, expanding variable references if expand is true, and returns the
+// first expansion error.
+func (parser *Parser) listValues(s string, expand bool, file string) ([]string, error) {
+	elems := parser.splitList(s)
+	for i, e := range elems {
+		var err error
+		if elems[i], err = parser.expandedValue(e, expand, file); err != nil {
+			return nil, err
+		}
+	}
+	return elems, nil
+}
+
+// tableValues splits the raw text of an inline table, without its braces, into `key = value`
+// elements and processes each value as a value from the named file, expanding variable references
+// if expand is true, and returns the elements as `key:value`, as for map fields.  It returns an
+// error if the quotes, braces and brackets are not balanced, an element has no `=` or its key
+// contains `:`, or expansion fails.
+func (parser *Parser) tableValues(s string, expand bool, file string) ([]string, error) {
+	members, ok := parser.splitNested(s)
+	if !ok {
+		return nil, errors.New("Unbalanced inline table")
+	}
+	elems := make([]string, len(members))
+	for i, m := range members {
+		key, value, found := strings.Cut(m, "=")
+		key = strings.TrimSpace(key)
+		if !found || strings.Contains(key, ":") {
+			return nil, errors.New("Invalid element " + m + " of inline table")
+		}
+		value, err := parser.expandedValue(value, expand, file)
+		if err != nil {
+			return nil, err
+		}
+		elems[i] = key + ":" + value
+	}
+	return elems, nil
+}
+
+// splitList splits the raw text of a list value at the occurrences of ListDelim that are not within
+// quotes, returning the raw elements.  The empty (or blank) string is the empty list.
+func (parser *Parser) splitList(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return []string{}
+	}
+	var elems []string
+	var quote rune
+	escaped := false
+	start := 0
+	for i, c := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == parser.QuoteChar && parser.Escapes {
+				escaped = true
+			}
+		case c == parser.QuoteChar || c == parser.LiteralQuoteChar:
+			if c != 0 {
+				quote = c
+			}
+		case c == parser.ListDelim:
+			elems = append(elems, s[start:i])
+			start = i + len(string(c))
+		}
+	}
+	return append(elems, s[start:])
+}
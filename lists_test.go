@@ -0,0 +1,194 @@
+package ini
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestStringList(t *testing.T) {
+	p := NewParser("LiteralQuoteChar", '\'', "Escapes", true)
+	s := p.AddSection("sect")
+	a := s.AddStringList("a")
+	b := s.AddStringList("b")
+	c := s.AddStringList("c")
+	d := s.AddStringList("d")
+	store, err := p.Parse(strings.NewReader(`
+[sect]
+a = x, y ,z
+b = "Smith, J", 'Doe, J', "say \"hi, there\""
+c =
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x := ValueOf[[]string](a, store); !slices.Equal(x, []string{"x", "y", "z"}) {
+		t.Fatalf("%q", x)
+	}
+	if x := ValueOf[[]string](b, store); !slices.Equal(
+		x, []string{"Smith, J", "Doe, J", `say "hi, there"`}) {
+		t.Fatalf("%q", x)
+	}
+	if x := ValueOf[[]string](c, store); x == nil || len(x) != 0 {
+		t.Fatalf("%q", x)
+	}
+	if x := ValueOf[[]string](d, store); x != nil {
+		t.Fatalf("%q", x)
+	}
+
+	p = NewParser("ListDelim", ';', "RequireQuotes", true)
+	s = p.AddSection("sect")
+	a = s.AddStringList("a")
+	store, err = p.Parse(strings.NewReader("[sect]\na = x,y; \"p=q\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x := ValueOf[[]string](a, store); !slices.Equal(x, []string{"x,y", "p=q"}) {
+		t.Fatalf("%q", x)
+	}
+	if _, err := p.Parse(strings.NewReader("[sect]\na = x; p=q\n")); err == nil {
+		t.Fatal("Should fail")
+	}
+
+	// The `=` of inline tables need no quotes, but their keys and values do
+	m := s.AddStringMap("m")
+	store, err = p.Parse(strings.NewReader("[sect]\nm = { a = x; b = \"y#z\" }\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x := ValueOf[map[string]string](m, store); len(x) != 2 || x["a"] != "x" || x["b"] != "y#z" {
+		t.Fatal(x)
+	}
+	if _, err := p.Parse(strings.NewReader("[sect]\nm = { a = p=q }\n")); err == nil {
+		t.Fatal("Should fail")
+	}
+}
+
+func TestTypedLists(t *testing.T) {
+	p := NewParser("BoolSynonyms", true)
+	s := p.AddSection("s")
+	bl, il := s.AddBoolList("bl"), s.AddInt64List("il")
+	ul, fl := s.AddUint64List("ul"), s.AddFloat64List("fl")
+	sm, bm := s.AddStringMap("sm"), s.AddBoolMap("bm")
+	im, um, fm := s.AddInt64Map("im"), s.AddUint64Map("um"), s.AddFloat64Map("fm")
+	store, err := p.Parse(strings.NewReader(`[s]
+bl = yes, false
+il = -1, 2
+ul = 3
+fl = 1.5, -2
+sm = a: x, "b: y, z"
+bm = on:on
+im = a:-1, b:2
+um = n: 7
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(bl.BoolListVal(store), []bool{true, false}) ||
+		!slices.Equal(il.Int64ListVal(store), []int64{-1, 2}) ||
+		!slices.Equal(ul.Uint64ListVal(store), []uint64{3}) ||
+		!slices.Equal(fl.Float64ListVal(store), []float64{1.5, -2}) {
+		t.Fatal("Lists")
+	}
+	if m := sm.StringMapVal(store); len(m) != 2 || m["a"] != "x" || m["b"] != "y, z" {
+		t.Fatal(m)
+	}
+	if m := bm.BoolMapVal(store); len(m) != 1 || !m["on"] {
+		t.Fatal(m)
+	}
+	if m := im.Int64MapVal(store); len(m) != 2 || m["a"] != -1 || m["b"] != 2 {
+		t.Fatal(m)
+	}
+	if m := um.Uint64MapVal(store); len(m) != 1 || m["n"] != 7 {
+		t.Fatal(m)
+	}
+	if m := fm.Float64MapVal(store); m == nil || len(m) != 0 {
+		t.Fatal(m)
+	}
+
+	// Lists and maps are written so that they read back the same
+	var out strings.Builder
+	if err := store.Write(&out, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "sm = a:x, \"b:y, z\"\n") {
+		t.Fatalf("Got\n%s", out.String())
+	}
+	if again, err := p.Parse(strings.NewReader(out.String())); err != nil || !again.Equal(store) {
+		t.Fatalf("Got\n%s", out.String())
+	}
+
+	for _, input := range []string{
+		"il = 1, x", "ul = -1", "bl = maybe", "im = a:1, a:2", "im = a", "fm = a:x",
+	} {
+		if _, err := p.Parse(strings.NewReader("[s]\n" + input)); err == nil {
+			t.Fatal(input)
+		}
+	}
+
+	// Maps can be written as inline tables
+	store, err = p.Parse(strings.NewReader(
+		"[s]\nsm = { a = \"x, y\", b = z:w }\nim = {}\num = {n=1,m = 2}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := sm.StringMapVal(store); len(m) != 2 || m["a"] != "x, y" || m["b"] != "z:w" {
+		t.Fatal(m)
+	}
+	if m := um.Uint64MapVal(store); len(m) != 2 || m["n"] != 1 || m["m"] != 2 ||
+		len(im.Int64MapVal(store)) != 0 {
+		t.Fatal(m)
+	}
+	for _, input := range []string{"im = {a = 1, a = 2}", "im = {a}", "im = {a:b = 1}", "il = {}"} {
+		if _, err := p.Parse(strings.NewReader("[s]\n" + input)); err == nil {
+			t.Fatal(input)
+		}
+	}
+
+	// Errors point at the invalid elements, and Preview reports all of them
+	_, err = p.Parse(strings.NewReader("[s]\nil = 1, x, 3\n"))
+	if err == nil || err.Error() != "Line 2: In section s: Element 2 'x' is not valid for field il" {
+		t.Fatal(err)
+	}
+	_, errs := Preview(p, store, strings.NewReader("[s]\n\nim = a:1, a:2, b, c:3\nul = 1, -1\n"))
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	if strings.Join(msgs, "\n") != "Line 3: In section s: Element 2 'a:2' is not valid for field im\n"+
+		"Line 3: In section s: Element 3 'b' is not valid for field im\n"+
+		"Line 4: In section s: Element 2 '-1' is not valid for field ul" {
+		t.Fatal(strings.Join(msgs, "\n"))
+	}
+	s.AddInt64List("pins").Secret()
+	_, err = p.Parse(strings.NewReader("[s]\npins = 1234, x567\n"))
+	if err == nil || err.Error() != "Line 2: In section s: Element 2 '<redacted>' is not valid "+
+		"for field pins" {
+		t.Fatal(err)
+	}
+	expectPanic(t, "Int64ListVal accessor on differently typed field", func() {
+		ul.Int64ListVal(store)
+	})
+}
+
+func TestCollectionVars(t *testing.T) {
+	p := NewParser()
+	s := p.AddSection("s")
+	names := []string{"default"}
+	var limits map[string]int64
+	s.AddStringListVar("names", &names)
+	s.AddInt64MapVar("limits", &limits)
+	if _, err := p.Parse(strings.NewReader("[s]\nlimits = cpu:2\n")); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(names, []string{"default"}) || len(limits) != 1 || limits["cpu"] != 2 {
+		t.Fatal(names, limits)
+	}
+	names[0] = "changed"
+	if _, err := p.Parse(strings.NewReader("")); err != nil {
+		t.Fatal(err)
+	}
+	if names[0] != "default" || limits == nil || len(limits) != 0 {
+		t.Fatal(names, limits)
+	}
+}
@@ -0,0 +1,42 @@
+package ini
+
+import "log/slog"
+
+// AddLogLevel adds a new field of the given name to the section whose values are slog.Levels.  The
+// name must not be present in the section and must be syntactically valid (see package comments).
+// ParseLogLevel describes the accepted values.  The field has type TyUser and the default value is
+// slog.LevelInfo.  Use [ValueOf] with slog.Level to access the value.  The field is also available
+// as the registered type "loglevel", see [Section.AddTyped].
+func (section *Section) AddLogLevel(name string) *Field {
+	field := section.Add(name, TyUser, slog.LevelInfo, ParseLogLevel)
+	field.format = formatLogLevel
+	return field
+}
+
+// AddLogLevelVar adds a new log level field of the given name to the section, as for AddLogLevel,
+// and arranges for every successful parse to set v to the field's value, so that the loggers whose
+// handlers use v follow the configuration when it is reloaded.  The default value is the level of
+// v at the time of the call.
+func (section *Section) AddLogLevelVar(name string, v *slog.LevelVar) *Field {
+	field := section.Add(name, TyUser, v.Level(), ParseLogLevel)
+	field.format = formatLogLevel
+	field.dest = func(val any) {
+		v.Set(val.(slog.Level))
+	}
+	return field
+}
+
+// ParseLogLevel accepts the name of a slog level, `debug`, `info`, `warn` or `error`, ignoring
+// case, optionally followed by an offset, eg `info+2` or `error-1`, as for slog.Level's
+// UnmarshalText, returning the level as a slog.Level and a validity flag.
+func ParseLogLevel(s string) (any, bool) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return slog.LevelInfo, false
+	}
+	return level, true
+}
+
+func formatLogLevel(v any) string {
+	return v.(slog.Level).String()
+}
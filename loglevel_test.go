@@ -0,0 +1,55 @@
+package ini
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogLevel(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		want  slog.Level
+		ok    bool
+	}{
+		{"debug", slog.LevelDebug, true},
+		{"INFO", slog.LevelInfo, true},
+		{"Warn", slog.LevelWarn, true},
+		{"error", slog.LevelError, true},
+		{"info+2", slog.LevelInfo + 2, true},
+		{"ERROR-1", slog.LevelError - 1, true},
+		{"warning", 0, false},
+		{"info+", 0, false},
+		{"3", 0, false},
+	} {
+		v, ok := ParseLogLevel(tc.input)
+		if ok != tc.ok || ok && v != tc.want {
+			t.Fatal(tc.input, v, ok)
+		}
+	}
+
+	p := NewParser()
+	log := p.AddSection("log")
+	level := log.AddLogLevel("level")
+	var v slog.LevelVar
+	v.Set(slog.LevelWarn)
+	dynamic := log.AddLogLevelVar("dynamic", &v)
+	store, err := p.Parse(strings.NewReader("[log]\nlevel = debug+1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ValueOf[slog.Level](level, store) != slog.LevelDebug+1 || v.Level() != slog.LevelWarn ||
+		ValueOf[slog.Level](dynamic, store) != slog.LevelWarn {
+		t.Fatal(level.Value(store), v.Level())
+	}
+	if _, err := p.Parse(strings.NewReader("[log]\ndynamic = error\n")); err != nil {
+		t.Fatal(err)
+	}
+	if v.Level() != slog.LevelError {
+		t.Fatal(v.Level())
+	}
+	var b strings.Builder
+	if err := store.Write(&b, nil); err != nil || b.String() != "[log]\nlevel = DEBUG+1\n" {
+		t.Fatal(err, b.String())
+	}
+}
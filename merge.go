@@ -0,0 +1,113 @@
+package ini
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+)
+
+// A Layer is a named store to be merged with [Merge].
+type Layer struct {
+	Name  string // The name of the source, eg "system", "user" or a file name
+	Store *Store
+}
+
+// Merge returns a new store in which the layers' stores are merged in order of increasing priority,
+// eg system-wide, per-user and command-line configuration: a field is present if it is present in
+// any layer, and its value, origin and captured comment are from the last layer in which it is
+// present.  For a field that is present in no layer, the default computed by [Field.DefaultFunc]
+// or [Field.DefaultFromEnv] in the last layer is used.  Sections are present if they are present
+// in any layer, the undefined settings (see [Store.Unknown]) of all the layers are kept, and the
+// order of the input is the order in which sections and fields are first seen.  The layers are not
+// changed.  Use [Field.Source] to find the layer that supplied a field's value.
+//
+// Merge panics if there are no layers or the stores are from different parsers.
+func Merge(layers ...Layer) *Store {
+	if len(layers) == 0 {
+		panic("Merge requires at least one layer")
+	}
+	parser := layers[0].Store.parser
+	result := newStore(parser)
+	result.sources = make(map[*Field]string)
+	for _, layer := range layers {
+		store := layer.Store
+		if store.parser != parser {
+			panic("Store is from a different parser")
+		}
+		for _, section := range store.order {
+			values := result.ensure(section)
+			for _, name := range store.sections[section.name].order {
+				field := section.fields[name]
+				values.set(name, store.sections[section.name].values[name])
+				result.sources[field] = layer.Name
+				result.origins[field] = store.origins[field]
+				if occurrences, found := store.occurrences[field]; found {
+					if result.occurrences == nil {
+						result.occurrences = make(map[*Field][]Occurrence)
+					}
+					result.occurrences[field] = occurrences
+				}
+				if comment, found := store.comments[field]; found {
+					if result.comments == nil {
+						result.comments = make(map[*Field]string)
+					}
+					result.comments[field] = comment
+				}
+			}
+			if comment, found := store.sectComments[section]; found {
+				if result.sectComments == nil {
+					result.sectComments = make(map[*Section]string)
+				}
+				result.sectComments[section] = comment
+			}
+		}
+		result.unknown = append(result.unknown, store.unknown...)
+	}
+	last := layers[len(layers)-1].Store
+	for field, v := range last.defaults {
+		if !field.Present(result) {
+			result.defaults[field] = v
+			if origin, found := last.origins[field]; found {
+				result.origins[field] = origin
+			}
+		}
+	}
+	return result
+}
+
+// ParseDefaults parses the default configuration embedded in the program, typically with go:embed,
+// as a baseline store to be the first layer for [Merge], so that the configuration files,
+// environment and flags that are merged after it override it:
+//
+//	//go:embed defaults.ini
+//	var defaults []byte
+//	...
+//	base := parser.ParseDefaults(defaults)
+//	store := ini.Merge(ini.Layer{"defaults", base}, ini.Layer{"user", user})
+//
+// The defaults are parsed as for [Parser.ParseBytes], except that required fields and the
+// constraints between fields are not checked, since they apply to the merged configuration, and
+// the values of Var fields are not stored.  If the parser has a version (see [Parser.Version]) then
+// the defaults are migrated to the current version as for any other input.  An error in the
+// defaults is a programming error, not an input error, and ParseDefaults panics with it.
+func (parser *Parser) ParseDefaults(embedded []byte) *Store {
+	src := parser.newStringScanner(string(embedded))
+	if parser.versionPath != "" {
+		var err error
+		if src, err = parser.migrate(bytes.NewReader(embedded)); err != nil {
+			panic(fmt.Sprintf("Invalid embedded defaults: %v", err))
+		}
+	}
+	sb := parser.newStoreBuilder("", nil)
+	if err := parser.process(context.Background(), src, "", sb); err != nil {
+		panic(fmt.Sprintf("Invalid embedded defaults: %v", err))
+	}
+	return sb.store
+}
+
+// Source returns the name of the layer that supplied the field's value in a store produced by
+// [Merge], or "" if the field was not present in any layer or the store was not produced by Merge.
+// [Field.Origin] gives the location of the value within its layer.
+func (field *Field) Source(store *Store) string {
+	return store.sources[field]
+}
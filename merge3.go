@@ -0,0 +1,113 @@
+package ini
+
+import (
+	"io"
+	"strings"
+)
+
+// A Conflict is a key that was changed differently in two versions of a file, see [Merge3].  The
+// values are the texts of the settings, as they appear after the `=`.
+type Conflict struct {
+	Section  string // The section, as for [Document]
+	Key      string
+	Base     string // The value in the base version, if InBase
+	Ours     string // The value in our version, if InOurs
+	Theirs   string // The value in their version, if InTheirs
+	InBase   bool   // True if the key is set in the base version
+	InOurs   bool   // True if the key is set in our version
+	InTheirs bool   // True if the key is set in their version
+}
+
+// Merge3 merges the changes from the common ancestor base to theirs into ours, key by key, as
+// package upgrade tools do with a configuration file that the user has edited (ours) and a new
+// default file (theirs).  The inputs have the syntax of the parser, whose sections and fields are
+// not consulted.  The result is ours, with its comments and layout, updated with every key that
+// theirs added, changed or deleted and that ours left as it was in base.  Keys that were added
+// to a section by theirs are added after the section's last setting, and sections that theirs
+// added are added at the end.  Values are compared as text, without blanks at either end.
+//
+// A key that ours and theirs both changed, to different values, is a conflict: it keeps the value
+// from ours and is reported, in the order of the keys in ours and then those only in theirs.  An
+// error is returned if an input is not valid.
+func Merge3(parser *Parser, base, ours, theirs io.Reader) (*Document, []Conflict, error) {
+	var docs [3]*Document
+	for i, r := range []io.Reader{base, ours, theirs} {
+		var err error
+		if docs[i], err = parser.ParseDocument(r); err != nil {
+			return nil, nil, err
+		}
+	}
+	baseDoc, result, theirDoc := docs[0], docs[1], docs[2]
+	var conflicts []Conflict
+	seen := make(map[docKey]bool)
+	for _, k := range append(result.keys(), theirDoc.keys()...) {
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		b, inBase := baseDoc.text(k.section, k.key)
+		o, inOurs := result.text(k.section, k.key)
+		t, inTheirs := theirDoc.text(k.section, k.key)
+		switch {
+		case inOurs == inTheirs && o == t, inTheirs == inBase && t == b:
+			// Nothing to do
+		case inOurs == inBase && o == b && inTheirs:
+			result.setText(k.section, k.key, t)
+		case inOurs == inBase && o == b:
+			result.Delete(k.section, k.key)
+		default:
+			conflicts = append(conflicts, Conflict{k.section, k.key, b, o, t, inBase, inOurs,
+				inTheirs})
+		}
+	}
+	for _, name := range theirDoc.Sections() {
+		if name != "" && !baseDoc.hasSection(name) {
+			result.end(name)
+		}
+	}
+	return result, conflicts, nil
+}
+
+// A docKey identifies a key in a section of a Document.
+type docKey struct {
+	section, key string
+}
+
+// keys returns the keys that are set in the document, in the order of their first settings.
+func (doc *Document) keys() []docKey {
+	var keys []docKey
+	seen := make(map[docKey]bool)
+	current := ""
+	for _, tok := range doc.lines {
+		switch tok.Kind {
+		case TokSection:
+			current = sectionName(tok)
+		case TokSetting:
+			if k := (docKey{current, tok.Name}); !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	return keys
+}
+
+// text returns the text of the value of the last setting of key in the section, without blanks at
+// either end, and true, or "" and false if there is no such setting.
+func (doc *Document) text(section, key string) (string, bool) {
+	i := doc.find(section, key)
+	if i < 0 {
+		return "", false
+	}
+	return strings.TrimSpace(doc.lines[i].Value), true
+}
+
+// hasSection returns true if the document has a header for the section.
+func (doc *Document) hasSection(section string) bool {
+	for _, tok := range doc.lines {
+		if tok.Kind == TokSection && sectionName(tok) == section {
+			return true
+		}
+	}
+	return false
+}
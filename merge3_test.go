@@ -0,0 +1,83 @@
+package ini
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMerge3(t *testing.T) {
+	base := `[server]
+host = localhost
+port = 80
+timeout = 5
+old = x
+
+[log]
+level = info
+`
+	ours := `# Edited by the admin
+[server]
+host = example.com
+port = 80
+timeout = 10
+old = x
+mine = 1
+
+[log]
+level = info
+`
+	theirs := `[server]
+host = localhost
+port = 8080
+timeout = 30
+mine = 2
+new = y
+
+[log]
+level = info
+format = json
+
+[metrics]
+enabled = true
+[tracing]
+`
+	p := NewParser()
+	doc, conflicts, err := Merge3(p, strings.NewReader(base), strings.NewReader(ours),
+		strings.NewReader(theirs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# Edited by the admin
+[server]
+host = example.com
+port = 8080
+timeout = 10
+mine = 1
+new = y
+
+[log]
+level = info
+format = json
+
+[metrics]
+enabled = true
+
+[tracing]
+`
+	if doc.String() != want {
+		t.Fatalf("Got\n%s", doc.String())
+	}
+	wantConflicts := []Conflict{
+		{"server", "timeout", "5", "10", "30", true, true, true},
+		{"server", "mine", "", "1", "2", false, true, true},
+	}
+	if !reflect.DeepEqual(conflicts, wantConflicts) {
+		t.Fatalf("%+v", conflicts)
+	}
+
+	_, _, err = Merge3(p, strings.NewReader(base), strings.NewReader("[x\n"), strings.NewReader(""))
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+}
@@ -0,0 +1,92 @@
+package ini
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	p := NewParser(WithLenient(true))
+	s := p.AddSection("s")
+	a, b, c := s.AddString("a"), s.AddString("b"), s.AddString("c")
+	d := s.AddInt64("d").DefaultFromEnv("INI_TEST_D")
+	p.AddSection("t").AddBool("x")
+	parse := func(input string) *Store {
+		store, err := p.Parse(strings.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return store
+	}
+	system := parse("[s]\na = sys\nb = sys\n[t]\n")
+	t.Setenv("INI_TEST_D", "7")
+	user := parse("[s]\nb = user\nc = user\nzz = 1\n")
+
+	merged := Merge(Layer{"system", system}, Layer{"user", user})
+	if a.StringVal(merged) != "sys" || b.StringVal(merged) != "user" || c.StringVal(merged) != "user" {
+		t.Fatal("Values")
+	}
+	if a.Source(merged) != "system" || b.Source(merged) != "user" || d.Source(merged) != "" {
+		t.Fatal("Sources")
+	}
+	if o := b.Origin(merged); o.Kind != OriginInput || o.Line != 2 {
+		t.Fatal(o)
+	}
+	if d.Int64Val(merged) != 7 || d.Origin(merged).Kind != OriginEnv || d.Present(merged) {
+		t.Fatal("Default")
+	}
+	if !p.Section("t").Present(merged) || len(merged.Unknown()) != 1 {
+		t.Fatal("Sections")
+	}
+	if a.Source(system) != "" || a.Source(merged.WithDefaults()) != "system" {
+		t.Fatal("Unmerged")
+	}
+
+	var out strings.Builder
+	if err := merged.WriteEffective(&out, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "# user: line 2\nb = user\n") {
+		t.Fatalf("Got\n%s", out.String())
+	}
+
+	expectPanic(t, "Merge requires at least one layer", func() { Merge() })
+	expectPanic(t, "Store is from a different parser", func() {
+		Merge(Layer{"a", system}, Layer{"b", newStore(NewParser())})
+	})
+}
+
+func TestParseDefaults(t *testing.T) {
+	p := NewParser()
+	s := p.AddSection("s")
+	host, port := s.AddString("host").Required(), s.AddInt64("port")
+	defaults := p.ParseDefaults([]byte("[s]\nport = 80\n"))
+	if port.Int64Val(defaults) != 80 || host.Present(defaults) {
+		t.Fatal("Defaults")
+	}
+	user, err := p.Parse(strings.NewReader("[s]\nhost = example.com\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged := Merge(Layer{"defaults", defaults}, Layer{"user", user})
+	if host.StringVal(merged) != "example.com" || port.Int64Val(merged) != 80 ||
+		port.Source(merged) != "defaults" {
+		t.Fatal("Merged")
+	}
+	msg := "Invalid embedded defaults: Line 2: In section s: Value 'x' is not valid for field port"
+	expectPanic(t, msg, func() { p.ParseDefaults([]byte("[s]\nport = x\n")) })
+
+	// Defaults at an older version are migrated
+	p.AddSection("meta").AddInt64("version")
+	p.Version("meta.version", 1)
+	p.AddMigration(0, 1, func(doc *Document) error {
+		doc.Rename("s", "listen", "port")
+		return nil
+	})
+	defaults = p.ParseDefaults([]byte("[s]\nlisten = 8080\n"))
+	if port.Int64Val(defaults) != 8080 || defaults.GetInt64("meta.version") != 1 {
+		t.Fatal(defaults.RedactedMap())
+	}
+	msg = "Invalid embedded defaults: Configuration version 2 is newer than the supported version 1"
+	expectPanic(t, msg, func() { p.ParseDefaults([]byte("[meta]\nversion = 2\n")) })
+}
@@ -0,0 +1,104 @@
+package ini
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ParseStats describe a parse, see [Metrics].
+type ParseStats struct {
+	Duration time.Duration // The time the parse took
+	Lines    int           // The number of input lines read
+	Settings int           // The number of fields present in the store
+	Unknown  int           // The number of undefined sections and settings kept, see Lenient
+	Err      error         // The error, if the parse failed
+}
+
+// Metrics receives reports of a parser's activity, for monitoring with eg Prometheus or expvar.
+// The methods are called synchronously, possibly concurrently, and should be fast.  [Counters] is
+// a simple implementation.
+type Metrics interface {
+	// Parsed is called at the end of every parse that produces or would produce a Store, whether
+	// it succeeds or fails.
+	Parsed(stats ParseStats)
+
+	// Reloaded is called at the end of every [Holder.Reload], with its error if it failed.
+	Reloaded(err error)
+}
+
+// Counters is a [Metrics] that accumulates counts, which can be read at any time, eg with
+//
+//	expvar.Publish("config", expvar.Func(func() any { return counters.Snapshot() }))
+type Counters struct {
+	Parses       atomic.Int64 // The number of parses
+	ParseErrors  atomic.Int64 // The number of failed parses
+	Lines        atomic.Int64 // The total number of lines read
+	Unknown      atomic.Int64 // The total number of undefined sections and settings kept
+	Reloads      atomic.Int64 // The number of reloads
+	ReloadErrors atomic.Int64 // The number of failed reloads
+	LastDuration atomic.Int64 // The duration of the last parse, in nanoseconds
+}
+
+// Parsed implements [Metrics].
+func (c *Counters) Parsed(stats ParseStats) {
+	c.Parses.Add(1)
+	if stats.Err != nil {
+		c.ParseErrors.Add(1)
+	}
+	c.Lines.Add(int64(stats.Lines))
+	c.Unknown.Add(int64(stats.Unknown))
+	c.LastDuration.Store(int64(stats.Duration))
+}
+
+// Reloaded implements [Metrics].
+func (c *Counters) Reloaded(err error) {
+	c.Reloads.Add(1)
+	if err != nil {
+		c.ReloadErrors.Add(1)
+	}
+}
+
+// Snapshot returns the current counts by their field names.
+func (c *Counters) Snapshot() map[string]int64 {
+	return map[string]int64{
+		"Parses":       c.Parses.Load(),
+		"ParseErrors":  c.ParseErrors.Load(),
+		"Lines":        c.Lines.Load(),
+		"Unknown":      c.Unknown.Load(),
+		"Reloads":      c.Reloads.Load(),
+		"ReloadErrors": c.ReloadErrors.Load(),
+		"LastDuration": c.LastDuration.Load(),
+	}
+}
+
+// countingSource is a tokenSource that records the number of the last line read from src.
+type countingSource struct {
+	src   tokenSource
+	lines int
+}
+
+func (cs *countingSource) Scan() bool {
+	if !cs.src.Scan() {
+		return false
+	}
+	cs.lines = cs.src.Token().Line
+	return true
+}
+
+func (cs *countingSource) Token() Token {
+	return cs.src.Token()
+}
+
+func (cs *countingSource) Err() error {
+	return cs.src.Err()
+}
+
+// report reports a parse to the parser's Metrics.
+func (parser *Parser) report(start time.Time, lines int, store *Store, err error) {
+	stats := ParseStats{Duration: time.Since(start), Lines: lines, Err: err}
+	if store != nil {
+		stats.Settings = len(store.Fields())
+		stats.Unknown = len(store.unknown)
+	}
+	parser.Metrics.Parsed(stats)
+}
@@ -0,0 +1,57 @@
+package ini
+
+import (
+	"strings"
+	"testing"
+)
+
+type recordingMetrics struct {
+	parses  []ParseStats
+	reloads []error
+}
+
+func (rm *recordingMetrics) Parsed(stats ParseStats) { rm.parses = append(rm.parses, stats) }
+func (rm *recordingMetrics) Reloaded(err error)      { rm.reloads = append(rm.reloads, err) }
+
+func TestMetrics(t *testing.T) {
+	rm := &recordingMetrics{}
+	p := NewParser(WithMetrics(rm), WithLenient(true))
+	s := p.AddSection("s")
+	s.AddInt64("a")
+	s.AddInt64("b")
+	store, err := p.Parse(strings.NewReader("# comment\n[s]\na = 1\nb = 2\nc = 3\n\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Parse(strings.NewReader("[s]\na = x\nb = 2\n")); err == nil {
+		t.Fatal("Should fail")
+	}
+	if len(rm.parses) != 2 {
+		t.Fatal(rm.parses)
+	}
+	if st := rm.parses[0]; st.Lines != 6 || st.Settings != 2 || st.Unknown != 1 || st.Err != nil ||
+		st.Duration < 0 {
+		t.Fatal(st)
+	}
+	if st := rm.parses[1]; st.Lines != 2 || st.Err == nil {
+		t.Fatal(st)
+	}
+
+	h := NewHolder(p, store)
+	h.Reload(strings.NewReader("[s]\na = 5\n"))
+	h.Reload(strings.NewReader("[s]\na = y\n"))
+	if len(rm.reloads) != 2 || rm.reloads[0] != nil || rm.reloads[1] == nil || len(rm.parses) != 4 {
+		t.Fatal(rm.reloads)
+	}
+
+	var c Counters
+	p.Metrics = &c
+	p.Parse(strings.NewReader("[s]\na = 1\n"))
+	p.Parse(strings.NewReader("[s]\na = z\n"))
+	NewHolder(p, store).Reload(strings.NewReader(""))
+	snap := c.Snapshot()
+	if snap["Parses"] != 3 || snap["ParseErrors"] != 1 || snap["Lines"] != 4 || snap["Reloads"] != 1 ||
+		snap["ReloadErrors"] != 0 || c.LastDuration.Load() < 0 {
+		t.Fatal(snap)
+	}
+}
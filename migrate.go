@@ -0,0 +1,97 @@
+package ini
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A migration upgrades a Document from one schema version to a later one.
+type migration struct {
+	to int
+	fn func(doc *Document) error
+}
+
+// Version declares that the field at path, of the form "section.field", holds the schema version
+// of the input, and that the current version is current.  Parse then rejects input whose version
+// is greater than current, and upgrades input whose version is smaller by applying the migrations
+// added with [Parser.AddMigration] to it before parsing it.  Input without the field has version
+// 0.  Version panics if the field does not exist or current is negative.
+func (parser *Parser) Version(path string, current int) {
+	sectName, fieldName, _ := strings.Cut(path, ".")
+	if s := parser.sections[sectName]; s == nil || s.fields[fieldName] == nil {
+		panic("No field " + path)
+	}
+	if current < 0 {
+		panic("Negative version")
+	}
+	parser.versionPath = path
+	parser.version = current
+}
+
+// AddMigration adds a function that upgrades input of schema version from to version to, which
+// must be greater than from.  The function edits a [Document] holding the input, and the version
+// field is then set to to.  To upgrade input, Parse applies the migration from the input's version,
+// then the migration from the resulting version, and so on, until the current version is reached;
+// it fails if a migration is missing, leads past the current version, or a migration function
+// returns an error.  Line numbers in errors for migrated input refer to the migrated text.
+// AddMigration panics if from is negative, to is not greater than from, or there is already a
+// migration from from.
+func (parser *Parser) AddMigration(from, to int, fn func(doc *Document) error) {
+	if from < 0 || to <= from {
+		panic(fmt.Sprintf("Invalid migration from %d to %d", from, to))
+	}
+	if parser.migrations[from] != nil {
+		panic(fmt.Sprintf("Duplicate migration from %d", from))
+	}
+	if parser.migrations == nil {
+		parser.migrations = make(map[int]*migration)
+	}
+	parser.migrations[from] = &migration{to, fn}
+}
+
+// migrate checks the version of the input and returns a scanner for the input upgraded to the
+// current version.
+func (parser *Parser) migrate(r io.Reader) (*Scanner, error) {
+	doc, err := parser.ParseDocument(r)
+	if err != nil {
+		return nil, err
+	}
+	sectName, fieldName, _ := strings.Cut(parser.versionPath, ".")
+	version := 0
+	if s, found := doc.Get(sectName, fieldName); found {
+		version, err = strconv.Atoi(s)
+		if err != nil || version < 0 {
+			return nil, parseFail(doc.lines[doc.find(sectName, fieldName)].Line, sectName,
+				"Invalid version '%s'", s)
+		}
+	}
+	if version > parser.version {
+		return nil, parseFail(0, "",
+			"Configuration version %d is newer than the supported version %d", version,
+			parser.version)
+	}
+	if version == parser.version {
+		return parser.newTextScanner(doc.String()), nil
+	}
+	for version < parser.version {
+		m := parser.migrations[version]
+		if m == nil {
+			return nil, parseFail(0, "", "No migration from version %d", version)
+		}
+		if m.to > parser.version {
+			return nil, parseFail(0, "", "Migration from version %d to %d is past the current "+
+				"version %d", version, m.to, parser.version)
+		}
+		if err := m.fn(doc); err != nil {
+			return nil, parseFail(
+				0, "", "Migration from version %d to %d failed: %v", version, m.to, err).wrap(err)
+		}
+		version = m.to
+		if err := doc.Set(sectName, fieldName, strconv.Itoa(version)); err != nil {
+			return nil, err
+		}
+	}
+	return parser.newTextScanner(doc.String()), nil
+}
@@ -0,0 +1,61 @@
+package ini
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMigrations(t *testing.T) {
+	p := NewParser()
+	meta := p.AddSection("meta")
+	meta.AddInt64("version")
+	server := p.AddSection("server")
+	server.AddString("host")
+	server.AddInt64("port")
+	p.Version("meta.version", 3)
+	p.AddMigration(0, 2, func(doc *Document) error {
+		doc.RenameSection("srv", "server")
+		return nil
+	})
+	p.AddMigration(2, 3, func(doc *Document) error {
+		if doc.Rename("server", "listen", "port") {
+			return nil
+		}
+		return errors.New("no listen setting")
+	})
+
+	store, err := p.Parse(strings.NewReader("# old\n[srv]\nhost = h\nlisten = 80\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.GetInt64("meta.version") != 3 || store.GetString("server.host") != "h" ||
+		store.GetInt64("server.port") != 80 {
+		t.Fatal(store.RedactedMap())
+	}
+	store, err = p.Parse(strings.NewReader("[meta]\nversion = 3\n[server]\nport = 1\n"))
+	if err != nil || store.GetInt64("server.port") != 1 {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct{ input, msg string }{
+		{"[meta]\nversion = 4\n", "Configuration version 4 is newer than the supported version 3"},
+		{"[meta]\nversion = 1\n", "No migration from version 1"},
+		{"[meta]\nversion = x\n", "Line 2: In section meta: Invalid version 'x'"},
+		{"[meta]\nversion = 2\n", "Migration from version 2 to 3 failed: no listen setting"},
+	} {
+		if _, err := p.Parse(strings.NewReader(c.input)); err == nil || err.Error() != c.msg {
+			t.Fatalf("%q: %v", c.input, err)
+		}
+	}
+
+	p.AddMigration(1, 5, func(doc *Document) error { return nil })
+	_, err = p.Parse(strings.NewReader("[meta]\nversion = 1\n"))
+	if err == nil || err.Error() != "Migration from version 1 to 5 is past the current version 3" {
+		t.Fatal(err)
+	}
+
+	expectPanic(t, "No field meta.nope", func() { p.Version("meta.nope", 1) })
+	expectPanic(t, "Invalid migration from 3 to 3", func() { p.AddMigration(3, 3, nil) })
+	expectPanic(t, "Duplicate migration from 2", func() { p.AddMigration(2, 4, nil) })
+}
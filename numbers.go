@@ -0,0 +1,71 @@
+package ini
+
+import (
+	"reflect"
+	"strings"
+)
+
+// A NumberFormat describes the separators that the values of numeric fields use, for input written
+// in a convention other than Go's, see the parser's NumberFormat.  The zero value is Go's
+// convention, with a decimal point and no thousands separators.
+type NumberFormat struct {
+	Decimal   rune // The decimal separator, or 0 for '.'
+	Thousands rune // The separator between groups of three digits before the decimal separator, or 0
+}
+
+var (
+	// NumbersComma is the convention of much of continental Europe, eg 1.234.567,89.
+	NumbersComma = NumberFormat{Decimal: ',', Thousands: '.'}
+
+	// NumbersEnglish is the convention of English-speaking countries, eg 1,234,567.89.
+	NumbersEnglish = NumberFormat{Decimal: '.', Thousands: ','}
+)
+
+// parseNumber calls parse with s rewritten from the parser's NumberFormat to Go's convention, and
+// if the value is not valid in that format or parse rejects it, with s.
+func (parser *Parser) parseNumber(s string, parse func(s string) (any, bool)) (any, bool) {
+	if parser.NumberFormat != (NumberFormat{}) {
+		if t, ok := parser.NumberFormat.normalize(s); ok {
+			if v, ok := parse(t); ok {
+				return v, true
+			}
+		}
+	}
+	return parse(s)
+}
+
+// normalize returns s rewritten to Go's convention and true, or false if the thousands separators
+// in s do not separate groups of three digits.
+func (nf NumberFormat) normalize(s string) (string, bool) {
+	decimal := nf.Decimal
+	if decimal == 0 {
+		decimal = '.'
+	}
+	sign := ""
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		sign, s = s[:1], s[1:]
+	}
+	whole, frac, hasFrac := strings.Cut(s, string(decimal))
+	if nf.Thousands != 0 && strings.ContainsRune(whole, nf.Thousands) {
+		groups := strings.Split(whole, string(nf.Thousands))
+		for i, g := range groups {
+			if len(g) > 3 || len(g) < 3 && i > 0 || g == "" || strings.Trim(g, "0123456789") != "" {
+				return "", false
+			}
+		}
+		whole = strings.Join(groups, "")
+	}
+	if hasFrac {
+		return sign + whole + "." + frac, true
+	}
+	return sign + whole, true
+}
+
+// numberHint returns true if the invalid value of the field looks like a number that was written in
+// a convention other than Go's, which the parser's NumberFormat does not allow, so that the error
+// should say how to write numbers.
+func (field *Field) numberHint(value string) bool {
+	return !field.list && !field.secret && field.section.parser.NumberFormat == (NumberFormat{}) &&
+		isNumber(reflect.ValueOf(field.defaultValue)) && strings.ContainsRune(value, ',') &&
+		strings.Trim(value, "+-0123456789.,") == ""
+}
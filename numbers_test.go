@@ -0,0 +1,70 @@
+package ini
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNumberFormat(t *testing.T) {
+	for _, tc := range []struct {
+		format NumberFormat
+		input  string
+		want   float64
+		ok     bool
+	}{
+		{NumbersComma, "3,14", 3.14, true},
+		{NumbersComma, "-1.234.567,5", -1234567.5, true},
+		{NumbersComma, "1.234", 1234, true},
+		{NumbersComma, "3.14", 3.14, true}, // Go syntax
+		{NumbersComma, "1.23.4", 0, false},
+		{NumbersComma, "1,2,3", 0, false},
+		{NumbersEnglish, "1,234,567.89", 1234567.89, true},
+		{NumbersEnglish, "+12,345", 12345, true},
+		{NumbersEnglish, "1,2345", 0, false},
+		{NumbersEnglish, ",123", 0, false},
+		{NumberFormat{Decimal: ','}, "2,5e3", 2500, true},
+		{NumberFormat{Thousands: ' '}, "1 000 000", 1e6, true},
+		{NumberFormat{}, "3,14", 0, false},
+	} {
+		p := NewParser(WithNumberFormat(tc.format))
+		x := p.AddSection("s").AddFloat64("x")
+		store, err := p.Parse(strings.NewReader("[s]\nx = \"" + tc.input + "\"\n"))
+		if (err == nil) != tc.ok || err == nil && x.Float64Val(store) != tc.want {
+			t.Fatal(tc.format, tc.input, err)
+		}
+	}
+
+	p := NewParser(WithNumberFormat(NumbersComma), WithListDelim(';'))
+	s := p.AddSection("s")
+	i := s.AddInt64("i")
+	u := s.AddUint64("u")
+	n := s.AddInt("n")
+	l := s.AddFloat64List("l")
+	store, err := p.Parse(strings.NewReader(
+		"[s]\ni = -1.000\nu = 2.000.000\nn = 12.345\nl = 1,5; 2.500,25; 3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i.Int64Val(store) != -1000 || u.Uint64Val(store) != 2000000 ||
+		ValueOf[int](n, store) != 12345 ||
+		!slices.Equal(l.Float64ListVal(store), []float64{1.5, 2500.25, 3}) {
+		t.Fatal(store.AllSettings(false))
+	}
+	if _, err := p.Parse(strings.NewReader("[s]\ni = 1,5\n")); err == nil {
+		t.Fatal("Fractional int")
+	}
+
+	// Numbers in other conventions get a hint without a NumberFormat
+	q := NewParser()
+	q.AddSection("s").AddFloat64("ratio")
+	_, err = q.Parse(strings.NewReader("[s]\nratio = 3,14\n"))
+	if err == nil || err.Error() != "Line 2: In section s: Value '3,14' is not valid for field "+
+		"ratio: numbers are written with a decimal point and without thousands separators" {
+		t.Fatal(err)
+	}
+	_, err = q.Parse(strings.NewReader("[s]\nratio = x,y\n"))
+	if err == nil || err.Error() != "Line 2: In section s: Value 'x,y' is not valid for field ratio" {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,20 @@
+package ini
+
+// An Occurrence is a setting of a field in the input, see [Field.Occurrences].
+type Occurrence struct {
+	File  string // The name of the input file, if known
+	Line  int    // The line number in the input
+	Text  string // The value as written, before resolution, or Redacted if the field is secret
+	Value any    // The value that the setting produced
+}
+
+// Occurrences returns the settings of the field in the input that produced its value in the store,
+// in input order, eg to point at the line of a list element that the program finds unacceptable.
+// A field that is set more than once has an occurrence for each setting, though only the last
+// determines the value, and a list field whose value was built from several files by
+// [LoadWithDropins] has the occurrences whose elements the value holds.  For a store produced by
+// [Merge], the occurrences are those of the layer that supplied the value.  The result is nil if
+// the field was not present in the input.
+func (field *Field) Occurrences(store *Store) []Occurrence {
+	return append([]Occurrence(nil), store.occurrences[field]...)
+}
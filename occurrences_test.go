@@ -0,0 +1,77 @@
+package ini
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOccurrences(t *testing.T) {
+	p := NewParser()
+	net := p.AddSection("net")
+	peers := net.AddStringList("peers")
+	port := net.AddInt64("port")
+	key := net.AddString("key").Secret()
+	timeout := net.AddInt64("timeout")
+	store, err := p.Parse(strings.NewReader(`[net]
+peers = a, b
+port = 80
+key = hunter2
+port = 8080
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Occurrence{{"", 2, "a, b", []string{"a", "b"}}}
+	if got := peers.Occurrences(store); !reflect.DeepEqual(got, want) {
+		t.Fatal(got)
+	}
+	want = []Occurrence{{"", 3, "80", int64(80)}, {"", 5, "8080", int64(8080)}}
+	if got := port.Occurrences(store); !reflect.DeepEqual(got, want) {
+		t.Fatal(got)
+	}
+	if got := key.Occurrences(store); len(got) != 1 || got[0].Text != Redacted {
+		t.Fatal(got)
+	}
+	if got := timeout.Occurrences(store); got != nil {
+		t.Fatal(got)
+	}
+
+	// The occurrences follow the value through Merge and appended drop-ins
+	other, err := p.Parse(strings.NewReader("[net]\n\nport = 1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged := Merge(Layer{"base", store}, Layer{"other", other})
+	if got := port.Occurrences(merged); len(got) != 1 || got[0].Line != 3 {
+		t.Fatal(got)
+	}
+	if got := peers.Occurrences(merged); len(got) != 1 || got[0].Line != 2 {
+		t.Fatal(got)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.conf")
+	if err := os.Mkdir(path+".d", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, text := range map[string]string{
+		"app.conf":          "[net]\npeers = a\n",
+		"app.conf.d/x.conf": "[net]\n\npeers = b, c\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store, err = LoadWithDropins(p, path, &DropinOptions{AppendLists: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := peers.Occurrences(store)
+	if len(got) != 2 || got[0].File != path || got[0].Line != 2 ||
+		got[1].File != filepath.Join(dir, "app.conf.d/x.conf") || got[1].Line != 3 {
+		t.Fatal(got)
+	}
+}
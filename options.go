@@ -0,0 +1,185 @@
+package ini
+
+import (
+	"io"
+)
+
+// An Option sets one of a Parser's options.  Options can be passed to [NewParser] in place of
+// keyword / value pairs, and unlike those they are checked by the compiler, eg
+// `NewParser(WithCommentChar(';'), WithEscapes(true))`.
+type Option func(*Parser)
+
+// WithCommentChar sets the parser's CommentChar.
+func WithCommentChar(c rune) Option {
+	return func(p *Parser) { p.CommentChar = c }
+}
+
+// WithQuoteChar sets the parser's QuoteChar.
+func WithQuoteChar(c rune) Option {
+	return func(p *Parser) { p.QuoteChar = c }
+}
+
+// WithLiteralQuoteChar sets the parser's LiteralQuoteChar.
+func WithLiteralQuoteChar(c rune) Option {
+	return func(p *Parser) { p.LiteralQuoteChar = c }
+}
+
+// WithListDelim sets the parser's ListDelim.
+func WithListDelim(c rune) Option {
+	return func(p *Parser) { p.ListDelim = c }
+}
+
+// WithEscapes sets the parser's Escapes.
+func WithEscapes(b bool) Option {
+	return func(p *Parser) { p.Escapes = b }
+}
+
+// WithRequireQuotes sets the parser's RequireQuotes.
+func WithRequireQuotes(b bool) Option {
+	return func(p *Parser) { p.RequireQuotes = b }
+}
+
+// WithIntPrefixes sets the parser's IntPrefixes.
+func WithIntPrefixes(b bool) Option {
+	return func(p *Parser) { p.IntPrefixes = b }
+}
+
+// WithBoolSynonyms sets the parser's BoolSynonyms.
+func WithBoolSynonyms(b bool) Option {
+	return func(p *Parser) { p.BoolSynonyms = b }
+}
+
+// WithExpandVars sets the parser's ExpandVars.
+func WithExpandVars(b bool) Option {
+	return func(p *Parser) { p.ExpandVars = b }
+}
+
+// WithUnboundVars sets the parser's UnboundVars.
+func WithUnboundVars(u UnboundVars) Option {
+	return func(p *Parser) { p.UnboundVars = u }
+}
+
+// WithVarSyntax sets the parser's VarSyntax.
+func WithVarSyntax(s VarSyntax) Option {
+	return func(p *Parser) { p.VarSyntax = s }
+}
+
+// WithRecursiveVars sets the parser's RecursiveVars.
+func WithRecursiveVars(b bool) Option {
+	return func(p *Parser) { p.RecursiveVars = b }
+}
+
+// WithProfile sets the parser's Profile.
+func WithProfile(name string) Option {
+	return func(p *Parser) { p.Profile = name }
+}
+
+// WithFacts sets the parser's Facts.
+func WithFacts(facts map[string]string) Option {
+	return func(p *Parser) { p.Facts = facts }
+}
+
+// WithIncluder sets the parser's Includer.
+func WithIncluder(inc Includer) Option {
+	return func(p *Parser) { p.Includer = inc }
+}
+
+// WithCaptureComments sets the parser's CaptureComments.
+func WithCaptureComments(b bool) Option {
+	return func(p *Parser) { p.CaptureComments = b }
+}
+
+// WithLineHook sets the parser's LineHook.
+func WithLineHook(hook func(lineno int, line string) (string, bool)) Option {
+	return func(p *Parser) { p.LineHook = hook }
+}
+
+// WithOnOverride sets the parser's OnOverride.
+func WithOnOverride(fn func(field *Field, line int, old, new string) error) Option {
+	return func(p *Parser) { p.OnOverride = fn }
+}
+
+// WithOnUnknownSection sets the parser's OnUnknownSection.
+func WithOnUnknownSection(fn func(name string) *Section) Option {
+	return func(p *Parser) { p.OnUnknownSection = fn }
+}
+
+// WithMetrics sets the parser's Metrics.
+func WithMetrics(m Metrics) Option {
+	return func(p *Parser) { p.Metrics = m }
+}
+
+// WithLenient sets the parser's Lenient.
+func WithLenient(b bool) Option {
+	return func(p *Parser) { p.Lenient = b }
+}
+
+// WithResolver sets the parser's Resolver.
+func WithResolver(r Resolver) Option {
+	return func(p *Parser) { p.Resolver = r }
+}
+
+// WithDuplicateSections sets the parser's DuplicateSections.
+func WithDuplicateSections(d DuplicateSections) Option {
+	return func(p *Parser) { p.DuplicateSections = d }
+}
+
+// WithDecoder sets the parser's Decoder.
+func WithDecoder(decoder func(r io.Reader) io.Reader) Option {
+	return func(p *Parser) { p.Decoder = decoder }
+}
+
+// WithRequireUTF8 sets the parser's RequireUTF8.
+func WithRequireUTF8(b bool) Option {
+	return func(p *Parser) { p.RequireUTF8 = b }
+}
+
+// WithNameMapper sets the parser's NameMapper.
+func WithNameMapper(mapper func(member string) string) Option {
+	return func(p *Parser) { p.NameMapper = mapper }
+}
+
+// WithNumberFormat sets the parser's NumberFormat.
+func WithNumberFormat(nf NumberFormat) Option {
+	return func(p *Parser) { p.NumberFormat = nf }
+}
+
+// WithCronValidator sets the parser's CronValidator.
+func WithCronValidator(validate func(expr string) error) Option {
+	return func(p *Parser) { p.CronValidator = validate }
+}
+
+// WithCRBreaks sets the parser's CRBreaks.
+func WithCRBreaks(b bool) Option {
+	return func(p *Parser) { p.CRBreaks = b }
+}
+
+// WithMaxLineLen sets the parser's MaxLineLen.  The option panics if n is negative.
+func WithMaxLineLen(n int) Option {
+	checkLimit("MaxLineLen", n)
+	return func(p *Parser) { p.MaxLineLen = n }
+}
+
+// WithMaxInputSize sets the parser's MaxInputSize.  The option panics if n is negative.
+func WithMaxInputSize(n int) Option {
+	checkLimit("MaxInputSize", n)
+	return func(p *Parser) { p.MaxInputSize = n }
+}
+
+// WithMaxSections sets the parser's MaxSections.  The option panics if n is negative.
+func WithMaxSections(n int) Option {
+	checkLimit("MaxSections", n)
+	return func(p *Parser) { p.MaxSections = n }
+}
+
+// WithMaxSettings sets the parser's MaxSettings.  The option panics if n is negative.
+func WithMaxSettings(n int) Option {
+	checkLimit("MaxSettings", n)
+	return func(p *Parser) { p.MaxSettings = n }
+}
+
+func checkLimit(name string, n int) {
+	if n < 0 {
+		panic("Negative " + name)
+	}
+}
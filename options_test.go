@@ -0,0 +1,34 @@
+package ini
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFunctionalOptions(t *testing.T) {
+	p := NewParser(WithCommentChar(';'), "Escapes", true, WithListDelim('|'), WithMaxSettings(5))
+	if p.CommentChar != ';' || !p.Escapes || p.ListDelim != '|' || p.MaxSettings != 5 ||
+		p.QuoteChar != '"' {
+		t.Fatal("Options not applied")
+	}
+	s := p.AddSection("s")
+	s.AddString("a")
+	s.AddStringList("b")
+	store, err := p.Parse(strings.NewReader("[s]\n; comment\na = \"x\\ty\"\nb = p | q\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.GetString("s.a") != "x\ty" || len(ValueOf[[]string](s.Field("b"), store)) != 2 {
+		t.Fatal("Values")
+	}
+
+	// Options are applied in order
+	if p := NewParser(WithProfile("a"), "Profile", "b", WithProfile("c")); p.Profile != "c" {
+		t.Fatal(p.Profile)
+	}
+
+	expectPanic(t, "Negative MaxLineLen", func() { WithMaxLineLen(-1) })
+	expectPanic(t, "Bad options: must be Options or keyword / value pairs", func() {
+		NewParser(WithEscapes(true), "Escapes")
+	})
+}
@@ -0,0 +1,109 @@
+package ini
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// PathOptions control the normalization of the values of path fields in the input, see
+// [Field.NormalizePath].  The zero value leaves the values as ParsePath returns them.
+type PathOptions struct {
+	// Native converts `/` in the values to the platform's separator, so that files can use `/`
+	// on all platforms.
+	Native bool
+
+	// Clean cleans the values with filepath.Clean.
+	Clean bool
+
+	// RelativeToFile resolves relative paths against the directory of the file that contains the
+	// setting, instead of leaving them to be resolved against the working directory of the
+	// process.  Paths in inputs that are not files are left alone.  The result is cleaned.
+	RelativeToFile bool
+}
+
+// AddPath adds a new string field of the given name to the section for a file system path.  The
+// name must not be present in the section and must be syntactically valid (see package comments).
+// ParsePath describes the accepted values, which can also be normalized, see
+// [Field.NormalizePath].  The default value is the empty string, and defaults set otherwise are
+// neither expanded nor normalized.
+func (section *Section) AddPath(name string) *Field {
+	return asPath(section.Add(name, TyString, "", ParsePath))
+}
+
+// AddPathVar adds a new path field of the given name to the section, as for AddPath, and arranges
+// for every successful parse to store the field's value in *p.  The default value is the value of
+// *p at the time of the call.
+func (section *Section) AddPathVar(name string, p *string) *Field {
+	return addVar(asPath(section.Add(name, TyString, *p, ParsePath)), p)
+}
+
+func asPath(field *Field) *Field {
+	field.path = &PathOptions{}
+	return field
+}
+
+// NormalizePath sets the normalization of the field's values in the input, which must be a field
+// added with AddPath or AddPathVar.  Returns the field.
+func (field *Field) NormalizePath(opts PathOptions) *Field {
+	if field.path == nil {
+		panic("NormalizePath on non-path field " + field.name)
+	}
+	*field.path = opts
+	return field
+}
+
+// normalizePath normalizes a value of the path field that was read from the named file.
+func (field *Field) normalizePath(s, file string) string {
+	opts := field.path
+	if s == "" {
+		return s
+	}
+	if opts.Native {
+		s = filepath.FromSlash(s)
+	}
+	if opts.RelativeToFile && file != "" && !isURL(file) && !filepath.IsAbs(s) {
+		return filepath.Join(filepath.Dir(file), s)
+	}
+	if opts.Clean {
+		s = filepath.Clean(s)
+	}
+	return s
+}
+
+// ParsePath accepts a path, returning it with a leading `~` replaced by the current user's home
+// directory and a leading `~user` replaced by that user's home directory, as in the shell, if
+// the `~` or `~user` is the whole path or is followed by a path separator.  It rejects the path if
+// the home directory cannot be determined.
+func ParsePath(s string) (any, bool) {
+	return expandTilde(s)
+}
+
+func expandTilde(s string) (string, bool) {
+	if !strings.HasPrefix(s, "~") {
+		return s, true
+	}
+	name, rest := s[1:], ""
+	if i := strings.IndexFunc(name, isPathSeparator); i >= 0 {
+		name, rest = name[:i], name[i:]
+	}
+	var home string
+	if name == "" {
+		var err error
+		if home, err = os.UserHomeDir(); err != nil {
+			return "", false
+		}
+	} else {
+		u, err := user.Lookup(name)
+		if err != nil || u.HomeDir == "" {
+			return "", false
+		}
+		home = u.HomeDir
+	}
+	return home + rest, true
+}
+
+func isPathSeparator(c rune) bool {
+	return c < 128 && os.IsPathSeparator(uint8(c))
+}
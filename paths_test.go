@@ -0,0 +1,80 @@
+package ini
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	p := NewParser()
+	s := p.AddSection("s")
+	a, b, c := s.AddPath("a"), s.AddPath("b"), s.AddTyped("c", "path")
+	d := s.AddPath("d")
+	var e string
+	s.AddPathVar("e", &e)
+	if _, err := p.Parse(strings.NewReader("[s]\ne = ~no-such-user-ini/x\n")); err == nil {
+		t.Fatal("Expected an error for an unknown user")
+	}
+	store, err := p.Parse(strings.NewReader("[s]\na = ~\nb = ~/.cache/app\nc = /tmp/~x\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.StringVal(store) != home || b.StringVal(store) != home+"/.cache/app" ||
+		c.StringVal(store) != "/tmp/~x" || d.StringVal(store) != "" {
+		t.Fatal(a.StringVal(store), b.StringVal(store), c.StringVal(store))
+	}
+	if u, err := user.Current(); err == nil && u.HomeDir != "" {
+		v, ok := ParsePath("~" + u.Username + "/x")
+		if !ok || v != filepath.Join(u.HomeDir, "x") {
+			t.Fatal(v)
+		}
+	}
+}
+
+func TestNormalizePath(t *testing.T) {
+	dir := t.TempDir()
+	p := NewParser()
+	s := p.AddSection("s")
+	rel := s.AddPath("rel").NormalizePath(PathOptions{RelativeToFile: true})
+	clean := s.AddPath("clean").NormalizePath(PathOptions{Native: true, Clean: true})
+	raw := s.AddPath("raw")
+	input := "[s]\nrel = data/../x.db\nclean = a//b/./c/\nraw = a//b\n"
+	name := filepath.Join(dir, "app.ini")
+	if err := os.WriteFile(name, []byte(input+"[s]\n@include sub/more.ini\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	os.Mkdir(filepath.Join(dir, "sub"), 0o755)
+	os.WriteFile(filepath.Join(dir, "sub", "more.ini"), []byte("raw = /abs\n"), 0o644)
+	store, err := p.ParseFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rel.StringVal(store) != filepath.Join(dir, "x.db") ||
+		clean.StringVal(store) != filepath.Join("a", "b", "c") || raw.StringVal(store) != "/abs" {
+		t.Fatal(rel.StringVal(store), clean.StringVal(store), raw.StringVal(store))
+	}
+
+	os.WriteFile(filepath.Join(dir, "sub", "more.ini"), []byte("rel = y.db\n"), 0o644)
+	store, err = p.ParseFile(name)
+	if err != nil || rel.StringVal(store) != filepath.Join(dir, "sub", "y.db") {
+		t.Fatal(err, rel.StringVal(store))
+	}
+
+	store, err = p.Parse(strings.NewReader(input))
+	if err != nil || rel.StringVal(store) != "data/../x.db" || raw.StringVal(store) != "a//b" {
+		t.Fatal(err, rel.StringVal(store))
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Expected a panic")
+		}
+	}()
+	s.AddString("str").NormalizePath(PathOptions{Clean: true})
+}
@@ -0,0 +1,52 @@
+package ini
+
+import (
+	"path/filepath"
+	"regexp"
+)
+
+// AddGlob adds a new string field of the given name to the section for a file name pattern.  The
+// name must not be present in the section and must be syntactically valid (see package comments).
+// ParseGlobPattern describes the accepted values.  The default value is the empty string.  The
+// field is also available as the registered type "glob", see [Section.AddTyped].
+func (section *Section) AddGlob(name string) *Field {
+	return section.Add(name, TyString, "", ParseGlobPattern)
+}
+
+// ParseGlobPattern accepts any string that is a well-formed pattern for filepath.Match and
+// filepath.Glob, returning its input and a validity flag, so that a malformed pattern is reported
+// with its location in the input instead of when it is first used.
+func ParseGlobPattern(s string) (any, bool) {
+	_, err := filepath.Match(s, "")
+	return s, err == nil
+}
+
+// AddRegexp adds a new field of the given name to the section whose values are regular
+// expressions.  The name must not be present in the section and must be syntactically valid (see
+// package comments).  ParseRegexp describes the accepted values.  The field has type TyUser and the
+// default value is a nil *regexp.Regexp.  Use [ValueOf] with *regexp.Regexp to access the value.
+// The field is also available as the registered type "regexp", see [Section.AddTyped].
+func (section *Section) AddRegexp(name string) *Field {
+	field := section.Add(name, TyUser, (*regexp.Regexp)(nil), ParseRegexp)
+	field.format = formatRegexp
+	return field
+}
+
+// ParseRegexp accepts a regular expression in the syntax of the regexp package, returning the
+// compiled expression as a *regexp.Regexp and a validity flag, so that an invalid expression is
+// reported with its location in the input instead of when it is first used.  The empty string is
+// accepted as the nil *regexp.Regexp, for no expression.
+func ParseRegexp(s string) (any, bool) {
+	if s == "" {
+		return (*regexp.Regexp)(nil), true
+	}
+	re, err := regexp.Compile(s)
+	return re, err == nil
+}
+
+func formatRegexp(v any) string {
+	if re := v.(*regexp.Regexp); re != nil {
+		return re.String()
+	}
+	return ""
+}
@@ -0,0 +1,38 @@
+package ini
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestPatterns(t *testing.T) {
+	p := NewParser()
+	s := p.AddSection("filter")
+	include := s.AddGlob("include")
+	match := s.AddRegexp("match")
+	exclude := s.AddTyped("exclude", "regexp")
+	store, err := p.Parse(strings.NewReader("[filter]\ninclude = *.log\nmatch = ^err(or)?:\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	re := ValueOf[*regexp.Regexp](match, store)
+	if include.StringVal(store) != "*.log" || !re.MatchString("error: x") ||
+		re.MatchString("warning:") || ValueOf[*regexp.Regexp](exclude, store) != nil {
+		t.Fatal(store.AllSettings(true))
+	}
+	var b strings.Builder
+	if err := store.Write(&b, nil); err != nil ||
+		b.String() != "[filter]\ninclude = *.log\nmatch = ^err(or)?:\n" {
+		t.Fatal(err, b.String())
+	}
+
+	for _, input := range []string{"include = [a-", "match = (x", "exclude = a**"} {
+		_, err := p.Parse(strings.NewReader("[filter]\n" + input + "\n"))
+		name, value, _ := strings.Cut(input, " = ")
+		if err == nil || err.Error() !=
+			"Line 2: In section filter: Value '"+value+"' is not valid for field "+name {
+			t.Fatal(input, err)
+		}
+	}
+}
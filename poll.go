@@ -0,0 +1,96 @@
+package ini
+
+import (
+	"context"
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// A Poller keeps a [Holder] up to date with the configuration at a URL by fetching it at regular
+// intervals, as [LoadURL] does, and reloading the holder when it has changed, which gives remote
+// configuration the same OnChange notifications as local files.  A change is detected by the
+// server's ETag or Last-Modified header if it provides one, and otherwise by a hash of the
+// response, so an unchanged configuration is not parsed again.  Successful reloads and reloads
+// that fail to parse are reported to the parser's Metrics, like [Holder.Reload].  A Poller's
+// methods can be called concurrently.
+type Poller struct {
+	// OnError, if not nil, is called by Run with the error of every poll that fails.  The
+	// holder keeps its current store.
+	OnError func(err error)
+
+	holder   *Holder
+	url      string
+	interval time.Duration
+	opts     URLOptions
+
+	mu   sync.Mutex // Serializes polls and protects hash
+	hash [sha256.Size]byte
+}
+
+// NewPoller returns a Poller that fetches the URL every interval with the options, which may be
+// nil, and updates the holder.  The holder's current store is normally the result of loading the
+// URL with LoadURL, but need not be.
+func NewPoller(holder *Holder, url string, interval time.Duration, opts *URLOptions) *Poller {
+	if interval <= 0 {
+		panic("Non-positive poll interval")
+	}
+	p := &Poller{holder: holder, url: url, interval: interval}
+	if opts != nil {
+		p.opts = *opts
+	}
+	if p.opts.Cache == nil {
+		p.opts.Cache = &URLCache{}
+	}
+	return p
+}
+
+// Poll fetches the URL once and, if the configuration has changed, parses it and updates the
+// holder.  It returns true if the holder was updated.  If the fetch or the parse fails then the
+// holder is not updated and the error is returned.
+func (p *Poller) Poll(ctx context.Context) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ctx, cancel := p.opts.context(ctx)
+	defer cancel()
+	parser := p.holder.parser
+	f, err := fetchURL(ctx, parser, p.url, &p.opts)
+	if err != nil {
+		return false, err
+	}
+	if f.store != nil {
+		if f.store == p.holder.Store() {
+			return false, nil
+		}
+		p.holder.Update(f.store)
+		return true, nil
+	}
+	hash := sha256.Sum256(f.body)
+	if hash == p.hash {
+		f.cache(p.url, p.holder.Store(), &p.opts)
+		return false, nil
+	}
+	err = p.holder.reload(func() (*Store, error) { return f.parse(ctx, parser, p.url, &p.opts) })
+	if err != nil {
+		return false, err
+	}
+	p.hash = hash
+	return true, nil
+}
+
+// Run polls the URL every interval until the context is done, and then returns the context's
+// error.  The first poll happens one interval after the call.
+func (p *Poller) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if _, err := p.Poll(ctx); err != nil && p.OnError != nil {
+				p.OnError(err)
+			}
+		}
+	}
+}
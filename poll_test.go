@@ -0,0 +1,86 @@
+package ini
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPoller(t *testing.T) {
+	var mu sync.Mutex
+	body, etag := "[db]\nport = 1\n", ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if etag != "" {
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", etag)
+		}
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+	serve := func(b, e string) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, etag = b, e
+	}
+
+	p := NewParser()
+	counters := &Counters{}
+	p.Metrics = counters
+	port := p.AddSection("db").AddInt64("port")
+	store, err := LoadURL(context.Background(), p, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	holder := NewHolder(p, store)
+	var changes []any
+	holder.OnChange(port, func(old, new any) { changes = append(changes, new) })
+	poller := NewPoller(holder, srv.URL, time.Hour, nil)
+
+	poll := func(wantChanged bool) {
+		t.Helper()
+		changed, err := poller.Poll(context.Background())
+		if err != nil || changed != wantChanged {
+			t.Fatal(changed, err)
+		}
+	}
+	poll(true) // The poller has not seen the configuration before
+	poll(false)
+	serve("[db]\nport = 2\n", "")
+	poll(true)
+	poll(false)
+	serve("[db]\nport = 3\n", `"v3"`)
+	poll(true)
+	poll(false)
+	serve("[db]\nport = 3\n", `"v3b"`)
+	poll(false)
+	serve("[db]\nport = x\n", `"v4"`)
+	if _, err := poller.Poll(context.Background()); err == nil {
+		t.Fatal("Expected an error")
+	}
+	if port.Int64Val(holder.Store()) != 3 || len(changes) != 2 {
+		t.Fatal(port.Int64Val(holder.Store()), changes)
+	}
+	if counters.Reloads.Load() != 4 || counters.ReloadErrors.Load() != 1 {
+		t.Fatal(counters.Snapshot())
+	}
+
+	serve("[db]\nport = 4\n", "")
+	ctx, cancel := context.WithCancel(context.Background())
+	poller = NewPoller(holder, srv.URL, time.Millisecond, nil)
+	holder.OnChange(port, func(old, new any) { cancel() })
+	if err := poller.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+	if port.Int64Val(holder.Store()) != 4 {
+		t.Fatal(port.Int64Val(holder.Store()))
+	}
+}
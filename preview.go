@@ -0,0 +1,79 @@
+package ini
+
+import (
+	"context"
+	"io"
+	"strings"
+)
+
+// A Change is a difference in the value of a field between two stores.  The values are as
+// returned by [Field.Value], so a field that is not present has its default value.
+type Change struct {
+	Field    *Field
+	Old, New any
+}
+
+// A Diff is the list of changes between two stores, in declaration order.
+type Diff []Change
+
+// String renders the diff with one line per change, `section.field: old -> new`, with values as
+// rendered by [Field.FormatValue] and the values of secret fields as [Redacted].
+func (d Diff) String() string {
+	var b strings.Builder
+	for _, c := range d {
+		field := c.Field
+		old, new := field.FormatValue(c.Old), field.FormatValue(c.New)
+		b.WriteString(field.section.name + "." + field.name + ": " + field.redact(old) + " -> " +
+			field.redact(new) + "\n")
+	}
+	return b.String()
+}
+
+// Diff returns the changes from the store to other, which must have been produced by the same
+// parser: the fields whose values differ between them, as determined by [Field.Changed].
+func (store *Store) Diff(other *Store) Diff {
+	if store.parser != other.parser {
+		panic("Store is from a different parser")
+	}
+	var d Diff
+	for _, section := range store.parser.order {
+		for _, field := range section.order {
+			if field.Changed(store, other) {
+				d = append(d, Change{field, field.Value(store), field.Value(other)})
+			}
+		}
+	}
+	return d
+}
+
+// Preview parses the input from r as a candidate replacement for current, which must have been
+// produced by the parser, and reports what would change, without storing the values of Var fields
+// or reporting to the parser's Metrics.  Use it to check a configuration before reloading it.
+// Unlike Parse, Preview does not stop at the first invalid line but returns the errors of all of
+// them, and of all the invalid elements of lists, in input order, and a nil diff.  Errors that make
+// the rest of the input meaningless, such as I/O errors and mismatched conditional directives,
+// still stop the parse.
+func Preview(parser *Parser, current *Store, r io.Reader) (Diff, []error) {
+	if current.parser != parser {
+		panic("Store is from a different parser")
+	}
+	var src *Scanner
+	if parser.versionPath == "" {
+		src = parser.newScanner(r)
+	} else {
+		var err error
+		if src, err = parser.migrate(r); err != nil {
+			return nil, []error{err}
+		}
+	}
+	var errs []error
+	sb := parser.newStoreBuilder("", nil)
+	sb.errs = &errs
+	if err := parser.fill(context.Background(), src, sb); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return current.Diff(sb.store), nil
+}
@@ -0,0 +1,63 @@
+package ini
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPreview(t *testing.T) {
+	p := NewParser()
+	db := p.AddSection("db")
+	host := db.AddString("host")
+	port := db.AddInt64("port")
+	password := db.AddString("password").Secret()
+	var level string
+	p.AddSection("log").AddStringVar("level", &level)
+	current, err := p.Parse(strings.NewReader("[db]\nhost = a\nport = 1\npassword = x\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, errs := Preview(p, current,
+		strings.NewReader("[db]\nhost = a\nport = 2\npassword = y\n[log]\nlevel = debug\n"))
+	if errs != nil {
+		t.Fatal(errs)
+	}
+	if len(d) != 3 || d[0].Field != port || d[0].Old != int64(1) || d[0].New != int64(2) ||
+		d[1].Field != password {
+		t.Fatal(d)
+	}
+	want := "db.port: 1 -> 2\ndb.password: <redacted> -> <redacted>\nlog.level:  -> debug\n"
+	if d.String() != want {
+		t.Fatalf("%q", d.String())
+	}
+	if level != "" {
+		t.Fatal("Preview assigned a variable")
+	}
+	if d := current.Diff(current); d != nil || host.Changed(current, current) {
+		t.Fatal(d)
+	}
+
+	_, errs = Preview(p, current, strings.NewReader(
+		"[db]\nport = x\n?\nnope = 1\n[nope]\na = 1\n[log]\nlevel = \"\n"))
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	if strings.Join(msgs, "\n") != "Line 2: In section db: Value 'x' is not valid for field port\n"+
+		"Line 3: In section db: Invalid syntax\n"+
+		"Line 4: In section db: No field nope\n"+
+		"Line 5: Undefined section nope" {
+		t.Fatal(strings.Join(msgs, "\n"))
+	}
+
+	p.Define("db").Field("host").Required()
+	_, errs = Preview(p, current, strings.NewReader("[db]\n@include x\n"))
+	if len(errs) != 1 {
+		t.Fatal(errs)
+	}
+	_, errs = Preview(p, current, strings.NewReader("[db]\nport = x\n"))
+	if len(errs) != 2 || errs[1].Error() != "In section db: Missing required field host" {
+		t.Fatal(errs)
+	}
+}
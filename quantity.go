@@ -0,0 +1,94 @@
+package ini
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A UnitTable defines the units of a quantity for [Section.AddQuantity], eg
+//
+//	ini.UnitTable{
+//		Base:  "req/s",
+//		Units: map[string]float64{"req/s": 1, "req/min": 1.0 / 60, "req/h": 1.0 / 3600},
+//	}
+//
+// Units maps the name of every unit to its size in the base unit.  Unit names are case-sensitive,
+// and the name "" allows numbers without a unit.
+type UnitTable struct {
+	Base  string
+	Units map[string]float64
+}
+
+var (
+	// FrequencyUnits are the units of frequency from Hz to GHz, with the base unit Hz.
+	FrequencyUnits = UnitTable{
+		Base:  "Hz",
+		Units: map[string]float64{"Hz": 1, "kHz": 1e3, "MHz": 1e6, "GHz": 1e9},
+	}
+
+	// BandwidthUnits are the decimal units of bandwidth in bits and bytes per second, from bit/s to
+	// Tbit/s and from B/s to TB/s, with the base unit bit/s.
+	BandwidthUnits = UnitTable{
+		Base: "bit/s",
+		Units: map[string]float64{
+			"bit/s": 1, "kbit/s": 1e3, "Mbit/s": 1e6, "Gbit/s": 1e9, "Tbit/s": 1e12,
+			"B/s": 8, "kB/s": 8e3, "MB/s": 8e6, "GB/s": 8e9, "TB/s": 8e12,
+		},
+	}
+)
+
+// A Quantity is a value of a field added with [Section.AddQuantity].
+type Quantity struct {
+	Value float64 // The value in the base unit of the table
+	Unit  string  // The unit that the value was written with
+}
+
+// AddQuantity adds a new field of the given name to the section whose values are numbers with a
+// unit from the table, eg `100req/s` or `10 Mbit/s`, and whose values in the store are the numbers
+// converted to the table's base unit, so that the program need not handle every unit.  The name
+// must not be present in the section and must be syntactically valid (see package comments).  The
+// number is as for ParseFloat64 and can be separated from the unit by blanks.  The field has type
+// TyUser and the default value is a zero Quantity in the base unit.  Use [ValueOf] with Quantity to
+// access the value.  Values are formatted with the units they were written with.
+//
+// AddQuantity panics if the table has no units or a unit's size is not positive.
+func (section *Section) AddQuantity(name string, table UnitTable) *Field {
+	units := maps.Clone(table.Units)
+	if len(units) == 0 {
+		panic("No units for field " + name)
+	}
+	for unit, size := range units {
+		if !(size > 0) {
+			panic("Non-positive size of unit " + unit + " for field " + name)
+		}
+	}
+	// The longest names first, so that eg "ms" is tried before "s"
+	names := slices.SortedFunc(maps.Keys(units), func(a, b string) int {
+		return cmp.Or(cmp.Compare(len(b), len(a)), cmp.Compare(a, b))
+	})
+	field := section.Add(name, TyUser, Quantity{Unit: table.Base},
+		func(s string) (any, bool) {
+			for _, unit := range names {
+				if number, found := strings.CutSuffix(s, unit); found {
+					v, err := strconv.ParseFloat(strings.TrimRight(number, " \t"), 64)
+					if err == nil {
+						return Quantity{v * units[unit], unit}, true
+					}
+				}
+			}
+			return Quantity{}, false
+		})
+	field.format = func(v any) string {
+		q := v.(Quantity)
+		size, found := units[q.Unit]
+		if !found {
+			size = 1
+		}
+		// 15 digits hide the rounding errors of the conversions
+		return strconv.FormatFloat(q.Value/size, 'g', 15, 64) + q.Unit
+	}
+	return field
+}
@@ -0,0 +1,71 @@
+package ini
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuantity(t *testing.T) {
+	rates := UnitTable{
+		Base:  "req/s",
+		Units: map[string]float64{"req/s": 1, "req/min": 1.0 / 60, "req/h": 1.0 / 3600},
+	}
+	latency := UnitTable{
+		Base:  "s",
+		Units: map[string]float64{"s": 1, "ms": 1e-3, "us": 1e-6, "": 1},
+	}
+	p := NewParser()
+	s := p.AddSection("limits")
+	rate := s.AddQuantity("rate", rates)
+	bandwidth := s.AddQuantity("bandwidth", BandwidthUnits)
+	timeout := s.AddQuantity("timeout", latency)
+	clock := s.AddQuantity("clock", FrequencyUnits)
+	store, err := p.Parse(strings.NewReader(
+		"[limits]\nrate = 30req/min\nbandwidth = 10 Mbit/s\ntimeout = 50ms\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q := ValueOf[Quantity](rate, store); q.Value != 0.5 || q.Unit != "req/min" {
+		t.Fatal(q)
+	}
+	if q := ValueOf[Quantity](bandwidth, store); q.Value != 1e7 || q.Unit != "Mbit/s" {
+		t.Fatal(q)
+	}
+	if q := ValueOf[Quantity](timeout, store); q.Value != 0.05 || q.Unit != "ms" {
+		t.Fatal(q)
+	}
+	if q := ValueOf[Quantity](clock, store); q != (Quantity{0, "Hz"}) {
+		t.Fatal(q)
+	}
+	var b strings.Builder
+	if err := store.Write(&b, nil); err != nil || b.String() !=
+		"[limits]\nrate = 30req/min\nbandwidth = 10Mbit/s\ntimeout = 50ms\n" {
+		t.Fatal(err, b.String())
+	}
+
+	store, err = p.Parse(strings.NewReader("[limits]\ntimeout = 1.5\nbandwidth = 2 MB/s\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q := ValueOf[Quantity](timeout, store); q != (Quantity{1.5, ""}) {
+		t.Fatal(q)
+	}
+	if q := ValueOf[Quantity](bandwidth, store); q != (Quantity{1.6e7, "MB/s"}) {
+		t.Fatal(q)
+	}
+	for _, input := range []string{
+		"rate = 30", "rate = req/s", "bandwidth = 10 mbit/s", "clock = 1Hzz",
+	} {
+		_, err := p.Parse(strings.NewReader("[limits]\n" + input + "\n"))
+		name, value, _ := strings.Cut(input, " = ")
+		if err == nil || err.Error() !=
+			"Line 2: In section limits: Value '"+value+"' is not valid for field "+name {
+			t.Fatal(input, err)
+		}
+	}
+
+	expectPanic(t, "No units for field x", func() { s.AddQuantity("x", UnitTable{}) })
+	expectPanic(t, "Non-positive size of unit s for field y", func() {
+		s.AddQuantity("y", UnitTable{Units: map[string]float64{"s": 0}})
+	})
+}
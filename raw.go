@@ -0,0 +1,132 @@
+package ini
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// A Raw holds an input that has been read and checked for syntax but not yet resolved against the
+// parser's schema, see [Parser.ParseRaw].
+type Raw struct {
+	parser   *Parser
+	tokens   []Token
+	settings []RawSetting
+}
+
+// A RawSetting is a setting in a [Raw] input.
+type RawSetting struct {
+	Line    int    // The line number in the input
+	Section string // The name of the section, or "" for a setting before the first section
+	Name    string // The setting's name
+	Value   string // The setting's value, processed as for [Parser.ParseEvents]
+}
+
+// ParseRaw reads the input from r and checks its syntax, returning a [Raw] that holds the settings
+// without checking them against the parser's sections and fields.  The raw settings can then be
+// inspected, and the schema modified accordingly, before the input is resolved against the schema
+// with [Parser.Resolve].  This makes it possible for a value in the input, such as `type = ...`,
+// to select the schema for the rest of the input.  Profiles and conditional directives are
+// processed according to the parser's options as of the call.  Schema versions are not checked and
+// migrations are not applied.
+func (parser *Parser) ParseRaw(r io.Reader) (*Raw, error) {
+	rec := &recordingSource{src: parser.newScanner(r)}
+	raw := &Raw{parser: parser}
+	if err := parser.process(context.Background(), rec, "", (*rawBuilder)(raw)); err != nil {
+		return nil, err
+	}
+	raw.tokens = rec.tokens
+	return raw, nil
+}
+
+// Settings returns the raw input's settings in input order, excluding those in sections for
+// inactive profiles and in inactive conditional regions.
+func (raw *Raw) Settings() []RawSetting {
+	return raw.settings
+}
+
+// Get returns the value of the last setting of name in the section, and true, or "" and false if
+// there is no such setting.
+func (raw *Raw) Get(section, name string) (string, bool) {
+	for i := len(raw.settings) - 1; i >= 0; i-- {
+		if s := raw.settings[i]; s.Section == section && s.Name == name {
+			return s.Value, true
+		}
+	}
+	return "", false
+}
+
+// Resolve resolves the raw input against the parser's current schema, returning a [Store] as for
+// [Parser.Parse].  Resolve can be called repeatedly, eg with a schema that is extended after a
+// failure, and applies the parser's options as of the call.  The raw input must have been produced
+// by the same parser.
+func (parser *Parser) Resolve(raw *Raw) (*Store, error) {
+	if raw.parser != parser {
+		panic("Raw input is from a different parser")
+	}
+	return parser.build(context.Background(), &tokenSlice{tokens: raw.tokens}, "", nil, time.Now())
+}
+
+// rawBuilder is the EventHandler that collects the settings of a Raw.
+type rawBuilder Raw
+
+func (rb *rawBuilder) SectionStart(line int, name string) error {
+	return nil
+}
+
+func (rb *rawBuilder) KeyValue(line int, section, key, value string) error {
+	rb.settings = append(rb.settings, RawSetting{line, section, key, value})
+	return nil
+}
+
+func (rb *rawBuilder) Comment(line int, text string) error {
+	return nil
+}
+
+func (rb *rawBuilder) EOF(line int) error {
+	return nil
+}
+
+// recordingSource is a tokenSource that records the tokens it delivers from another source.
+type recordingSource struct {
+	src    tokenSource
+	tokens []Token
+}
+
+func (rs *recordingSource) Scan() bool {
+	if !rs.src.Scan() {
+		return false
+	}
+	rs.tokens = append(rs.tokens, rs.src.Token())
+	return true
+}
+
+func (rs *recordingSource) Token() Token {
+	return rs.src.Token()
+}
+
+func (rs *recordingSource) Err() error {
+	return rs.src.Err()
+}
+
+// tokenSlice is a tokenSource that delivers the tokens in a slice.
+type tokenSlice struct {
+	tokens []Token
+	i      int
+}
+
+func (ts *tokenSlice) Scan() bool {
+	if ts.i == len(ts.tokens) {
+		return false
+	}
+	ts.i++
+	return true
+}
+
+func (ts *tokenSlice) Token() Token {
+	return ts.tokens[ts.i-1]
+}
+
+func (ts *tokenSlice) Err() error {
+	return nil
+}
@@ -0,0 +1,53 @@
+package ini
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRaw(t *testing.T) {
+	p := NewParser("Profile", "prod")
+	plugin := p.AddSection("plugin")
+	plugin.AddString("type")
+	raw, err := p.ParseRaw(strings.NewReader(`
+[plugin]
+type = cache
+[plugin:prod]
+size = 10
+[plugin:test]
+size = 1
+[cache]
+ttl = 5s
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raw.Settings()) != 3 || raw.Settings()[1] != (RawSetting{5, "plugin", "size", "10"}) {
+		t.Fatal(raw.Settings())
+	}
+	if _, err := p.Resolve(raw); err == nil {
+		t.Fatal("Should fail before the schema is extended")
+	}
+
+	typ, found := raw.Get("plugin", "type")
+	if !found || typ != "cache" {
+		t.Fatal(typ)
+	}
+	if _, found := raw.Get("plugin", "nope"); found {
+		t.Fatal("Get")
+	}
+	plugin.AddInt64("size")
+	p.AddSection(typ).AddString("ttl")
+	store, err := p.Resolve(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.GetInt64("plugin.size") != 10 || store.GetString("cache.ttl") != "5s" {
+		t.Fatal(store.RedactedMap())
+	}
+
+	if _, err := p.ParseRaw(strings.NewReader("[plugin]\n?\n")); err == nil {
+		t.Fatal("Should fail on syntax")
+	}
+	expectPanic(t, "Raw input is from a different parser", func() { NewParser().Resolve(raw) })
+}
@@ -0,0 +1,420 @@
+package ini
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// NewRecord returns a new section that is not part of the parser's schema but describes the
+// members of the values of record fields and the elements of record list fields, see [AddRecord]
+// and [AddRecordList].  Fields are added to it as to any other section, and their valid functions,
+// default values, limits and [Field.Required] apply to the members of every record, but the fields
+// have no values of their own in stores.  The name must be syntactically valid (see package
+// comments) and identifies the record in panic messages.
+func (parser *Parser) NewRecord(name string) *Section {
+	if !isName(name) {
+		panic("Invalid record name " + name)
+	}
+	return &Section{parser: parser, name: name, fields: make(map[string]*Field)}
+}
+
+// AddRecord adds a new field of the given name to the section whose values are records, of type
+// T, where T is a struct type whose members are described by the fields of the record (see
+// [Parser.NewRecord]), for small groups of settings that belong together, eg for
+//
+//	limits = { cpu = 2, mem = 4096 }
+//
+// The name must not be present in the section and must be syntactically valid (see package
+// comments).  The value is a list of settings of the record's fields in braces, as for an element
+// of [AddRecordList], and T's members receive their values and are quoted as described there.  The
+// field has type TyUser and the default value is the T whose members have the default values of
+// their fields.  AddRecord panics as AddRecordList.
+func AddRecord[T any](section *Section, name string, record *Section) *Field {
+	t := reflect.TypeFor[T]()
+	members := record.members(section.parser, t)
+	defaultValue, _ := newRecord(t, members, nil)
+	field := section.Add(name, TyUser, defaultValue.Interface(), func(s string) (any, bool) {
+		values, ok := record.parseRecord(s)
+		if !ok {
+			return nil, false
+		}
+		v, ok := newRecord(t, members, values)
+		if !ok {
+			return nil, false
+		}
+		return v.Interface(), true
+	})
+	field.format = func(v any) string {
+		return record.formatRecord(reflect.ValueOf(v), members)
+	}
+	return field
+}
+
+// AddRecordList adds a new field of the given name to the section whose values are lists of
+// records, of type []T, where T is a struct type whose members are described by the fields of the
+// record (see [Parser.NewRecord]), eg for
+//
+//	servers = [ {host = a, port = 1}, {host = b, port = 2} ]
+//
+// The name must not be present in the section and must be syntactically valid (see package
+// comments).  The elements are separated by ListDelim and the brackets around them are optional.
+// The error for a value with invalid elements identifies the first of them as for [AddListOf].
+// The members of an element are separated by ListDelim and each sets the record field of its
+// name, which may not be set more than once and must be set if it is required.  Member values are
+// subject to variable expansion as other values are, so that a value quoted with LiteralQuoteChar
+// is not expanded.  A member value that contains ListDelim, braces, brackets, `=` or CommentChar
+// must be quoted with the parser's QuoteChar or LiteralQuoteChar, within which escapes are not
+// processed, but the value as a whole need not be quoted if RequireQuotes is true.  Blanks around
+// elements, names and values are stripped.  The empty value is the empty list.  The field has type
+// TyUser and the default value is the empty list.
+//
+// The members of T are matched to the record's fields as for [Section.Decode], and a member
+// receives the value of its field in the element or otherwise the field's default value, where a
+// member of type *T is optional and nil if the field is not set.  An element is invalid if a
+// member cannot represent the value of its field, eg if it is out of the range of the member's
+// type.  AddRecordList panics if the record is from a different parser, if T is not a struct type,
+// if a member of T has no field, or if a field's values cannot be stored in its member.
+func AddRecordList[T any](section *Section, name string, record *Section) *Field {
+	t := reflect.TypeFor[T]()
+	members := record.members(section.parser, t)
+	field := section.addList(name, []T{}, func(elems []string) (any, []int) {
+		result := make([]T, len(elems))
+		var bad []int
+		for i, e := range elems {
+			values, ok := record.parseRecord(e)
+			var v reflect.Value
+			if ok {
+				v, ok = newRecord(t, members, values)
+			}
+			if !ok {
+				bad = append(bad, i)
+				continue
+			}
+			result[i] = v.Interface().(T)
+		}
+		if bad != nil {
+			return nil, bad
+		}
+		return result, nil
+	})
+	field.records = true
+	field.format = func(v any) string {
+		rv := reflect.ValueOf(v)
+		elems := make([]string, rv.Len())
+		for i := range elems {
+			elems[i] = record.formatRecord(rv.Index(i), members)
+		}
+		return strings.Join(elems, string(section.parser.ListDelim)+" ")
+	}
+	return field
+}
+
+// A recordMember is a member of a struct that receives the value of a field of a record.
+type recordMember struct {
+	index    int
+	field    *Field
+	optional bool // The member has a pointer type and is nil if the field is not set
+}
+
+// members returns the members of the struct type t, which must be usable with the record in the
+// parser, see AddRecordList.
+func (record *Section) members(parser *Parser, t reflect.Type) []recordMember {
+	if record.parser != parser {
+		panic("Record " + record.name + " is from a different parser")
+	}
+	if t.Kind() != reflect.Struct {
+		panic("Type " + t.String() + " of record " + record.name + " is not a struct type")
+	}
+	var members []recordMember
+	for i := range t.NumField() {
+		member := t.Field(i)
+		name, ok := memberName(member)
+		if !ok {
+			continue
+		}
+		field := record.fields[name]
+		if field == nil {
+			field = lookupMember(parser, record.order, name, (*Field).Name, member)
+		}
+		if field == nil {
+			panic("No field " + name + " in record " + record.name + " for member " + member.Name)
+		}
+		m := reflect.New(member.Type).Elem()
+		optional := m.Kind() == reflect.Pointer && field.defaultValue != nil &&
+			!reflect.TypeOf(field.defaultValue).AssignableTo(m.Type())
+		if optional {
+			m = reflect.New(m.Type().Elem()).Elem()
+		}
+		if !setMember(m, field.defaultValue) {
+			panic("Values of field " + field.name + " cannot be stored in member " + member.Name +
+				" of type " + member.Type.String())
+		}
+		members = append(members, recordMember{i, field, optional})
+	}
+	return members
+}
+
+// parseRecord parses the text of a record, `{name = value, ...}`, and returns the values of the
+// record's fields that it sets, and true, or false if it is not valid.
+func (record *Section) parseRecord(s string) (map[*Field]any, bool) {
+	parser := record.parser
+	if len(s) < 2 || s[0] != '{' || s[len(s)-1] != '}' {
+		return nil, false
+	}
+	members, ok := parser.splitNested(s[1 : len(s)-1])
+	if !ok {
+		return nil, false
+	}
+	values := make(map[*Field]any, len(members))
+	for _, m := range members {
+		name, text, found := strings.Cut(m, "=")
+		field := record.fields[strings.TrimSpace(name)]
+		if !found || field == nil {
+			return nil, false
+		}
+		if _, dup := values[field]; dup {
+			return nil, false
+		}
+		text = strings.TrimSpace(text)
+		if inner, quoted := stripQuotes(text, parser.QuoteChar); quoted {
+			text = inner
+		} else if inner, quoted := stripQuotes(text, parser.LiteralQuoteChar); quoted {
+			text = inner
+		}
+		v, ok := field.valid(text)
+		if !ok || field.checkLimits(v) != nil {
+			return nil, false
+		}
+		values[field] = v
+	}
+	for _, field := range record.order {
+		if _, found := values[field]; field.required && !found {
+			return nil, false
+		}
+	}
+	return values, true
+}
+
+// newRecord returns a new value of the struct type t whose members have the values of their fields,
+// or their fields' default values, and true, or false if a member cannot represent its value.
+func newRecord(
+	t reflect.Type,
+	members []recordMember,
+	values map[*Field]any,
+) (reflect.Value, bool) {
+	v := reflect.New(t).Elem()
+	for _, member := range members {
+		m := v.Field(member.index)
+		val, found := values[member.field]
+		if member.optional {
+			if !found {
+				continue
+			}
+			m.Set(reflect.New(m.Type().Elem()))
+			m = m.Elem()
+		}
+		if !found {
+			val = cloneValue(member.field.defaultValue)
+		}
+		if !setMember(m, val) {
+			return reflect.Value{}, false
+		}
+	}
+	return v, true
+}
+
+// formatRecord renders the struct v, whose members are described by the record, as a record that
+// parseRecord reads back.  The members that are nil are omitted.
+func (record *Section) formatRecord(v reflect.Value, members []recordMember) string {
+	parser := record.parser
+	var elems []string
+	for _, member := range members {
+		m := v.Field(member.index)
+		if member.optional {
+			if m.IsNil() {
+				continue
+			}
+			m = m.Elem()
+		}
+		field := member.field
+		if (m.Kind() == reflect.Slice || m.Kind() == reflect.Map) && m.IsNil() &&
+			reflect.DeepEqual(m.Interface(), field.defaultValue) {
+			continue // A nil default value, which no text reads back as
+		}
+		if field.defaultValue != nil && m.Type() != reflect.TypeOf(field.defaultValue) &&
+			isNumber(m) {
+			m = m.Convert(reflect.TypeOf(field.defaultValue)) // As setMember converted it
+		}
+		var s string
+		if field.format != nil {
+			s = field.format(m.Interface())
+		} else {
+			s = renderValue(m.Interface())
+		}
+		elems = append(elems, field.name+" = "+parser.quoteMember(s))
+	}
+	return "{" + strings.Join(elems, string(parser.ListDelim)+" ") + "}"
+}
+
+// quoteMember quotes the text of a member of a record, if necessary and possible, so that
+// parseRecord reads it back.
+func (parser *Parser) quoteMember(s string) string {
+	needed := s == "" || strings.TrimSpace(s) != s || strings.ContainsAny(s, "{}[]=") ||
+		strings.ContainsRune(s, parser.ListDelim) || strings.ContainsRune(s, parser.CommentChar)
+	for _, q := range []rune{parser.QuoteChar, parser.LiteralQuoteChar} {
+		if q != 0 && strings.ContainsRune(s, q) {
+			needed = true
+		}
+	}
+	if !needed {
+		return s
+	}
+	for _, q := range []rune{parser.QuoteChar, parser.LiteralQuoteChar} {
+		if q != 0 && !strings.ContainsRune(s, q) {
+			return string(q) + s + string(q)
+		}
+	}
+	return s
+}
+
+// recordValues splits the raw text of a value of a record list field, optionally in brackets, into
+// its elements and processes each of them as by expandedMembers.  It returns an error if the
+// quotes, braces and brackets are not balanced or expansion fails.
+func (parser *Parser) recordValues(s string, expand bool, file string) ([]string, error) {
+	elems, ok := parser.splitNested(s)
+	if ok && len(elems) == 1 && strings.HasPrefix(elems[0], "[") {
+		elems, ok = parser.splitNested(strings.TrimSuffix(strings.TrimPrefix(elems[0], "["), "]"))
+	}
+	if !ok {
+		return nil, errors.New("Unbalanced list of records")
+	}
+	for i, e := range elems {
+		var err error
+		if elems[i], err = parser.expandedMembers(e, expand, file); err != nil {
+			return nil, err
+		}
+	}
+	if elems == nil {
+		elems = []string{}
+	}
+	return elems, nil
+}
+
+// expandedMembers processes the raw text of a record or inline table, `{name = value, ...}`, as a
+// value from the named file, expanding variable references in the values of its members if expand
+// is true, except in values quoted with LiteralQuoteChar, and leaving their quotes in place.  Other
+// text is processed as by expandedValue.
+func (parser *Parser) expandedMembers(s string, expand bool, file string) (string, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '{' || s[len(s)-1] != '}' {
+		return parser.expandedValue(s, expand, file)
+	}
+	members, ok := parser.splitNested(s[1 : len(s)-1])
+	if !ok {
+		return parser.expandedValue(s, expand, file)
+	}
+	if !expand {
+		return s, nil
+	}
+	changed := false
+	for i, m := range members {
+		name, text, found := strings.Cut(m, "=")
+		if !found {
+			text = m
+		}
+		if _, literal := stripQuotes(strings.TrimSpace(text), parser.LiteralQuoteChar); literal {
+			continue
+		}
+		expanded, err := parser.expandVars(text, file)
+		if err != nil {
+			return "", err
+		}
+		if expanded != text {
+			members[i], changed = strings.TrimSpace(expanded), true
+			if found {
+				members[i] = strings.TrimSpace(name) + " = " + members[i]
+			}
+		}
+	}
+	if !changed {
+		return s, nil
+	}
+	return "{" + strings.Join(members, string(parser.ListDelim)+" ") + "}", nil
+}
+
+// structure returns the parts of the raw text of a record or inline table, `{name = value, ...}`,
+// which are its names and values, or of a list of records, optionally in brackets, which are its
+// elements, and true, or returns false if s is neither.  See ambiguous.
+func (parser *Parser) structure(s string) ([]string, bool) {
+	elems, ok := parser.splitNested(s)
+	switch {
+	case !ok || len(elems) == 0:
+		return nil, false
+	case len(elems) > 1:
+		for _, e := range elems {
+			if !strings.HasPrefix(e, "{") {
+				return nil, false
+			}
+		}
+		return elems, true
+	}
+	s = elems[0]
+	last := len(s) - 1
+	switch {
+	case len(s) >= 2 && s[0] == '[' && s[last] == ']':
+		elems, _ = parser.splitNested(s[1:last])
+		return elems, true
+	case len(s) >= 2 && s[0] == '{' && s[last] == '}':
+		members, _ := parser.splitNested(s[1:last])
+		var parts []string
+		for _, m := range members {
+			name, value, _ := strings.Cut(m, "=")
+			parts = append(parts, name, value)
+		}
+		return parts, true
+	}
+	return nil, false
+}
+
+// splitNested splits s at the occurrences of ListDelim that are not within quotes, braces or
+// brackets, returning the elements with blanks stripped, and true, or false if the quotes, braces
+// and brackets are not balanced.  The empty (or blank) string has no elements.
+func (parser *Parser) splitNested(s string) ([]string, bool) {
+	if strings.TrimSpace(s) == "" {
+		return nil, true
+	}
+	var elems []string
+	var quote rune
+	var open []rune // The closing characters of the open braces and brackets
+	start := 0
+	for i, c := range s {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == parser.QuoteChar || c == parser.LiteralQuoteChar:
+			if c != 0 {
+				quote = c
+			}
+		case c == '{':
+			open = append(open, '}')
+		case c == '[':
+			open = append(open, ']')
+		case c == '}' || c == ']':
+			if len(open) == 0 || open[len(open)-1] != c {
+				return nil, false
+			}
+			open = open[:len(open)-1]
+		case c == parser.ListDelim && len(open) == 0:
+			elems = append(elems, strings.TrimSpace(s[start:i]))
+			start = i + utf8.RuneLen(c)
+		}
+	}
+	if quote != 0 || len(open) != 0 {
+		return nil, false
+	}
+	return append(elems, strings.TrimSpace(s[start:])), true
+}
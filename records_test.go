@@ -0,0 +1,195 @@
+package ini
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRecordList(t *testing.T) {
+	type Server struct {
+		Host   string
+		Port   int
+		Backup *TimeOfDay
+		Tags   []string
+	}
+	p := NewParser()
+	server := p.NewRecord("server")
+	server.AddString("host").Required()
+	server.Add("port", TyInt64, int64(80), ParseInt64)
+	server.AddTimeOfDay("backup")
+	server.AddStringList("tags")
+	cluster := p.AddSection("cluster")
+	servers := AddRecordList[Server](cluster, "servers", server)
+	store, err := p.Parse(strings.NewReader(`[cluster]
+servers = [ {host = a, port = 1, backup = 3:30}, { host = "b, c", tags = "x, y" }, {host=d} ]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Server{
+		{"a", 1, &TimeOfDay{3, 30}, nil},
+		{"b, c", 80, nil, []string{"x", "y"}},
+		{"d", 80, nil, nil},
+	}
+	got, _ := servers.Value(store).([]Server)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("%+v", got)
+	}
+
+	// Values are written so that they read back the same
+	var out strings.Builder
+	if err := store.Write(&out, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `servers = {host = a, port = 1, backup = 03:30}, `+
+		`{host = "b, c", port = 80, tags = "x, y"}`) {
+		t.Fatalf("Got\n%s", out.String())
+	}
+	if again, err := p.Parse(strings.NewReader(out.String())); err != nil || !again.Equal(store) {
+		t.Fatalf("Got\n%s", out.String())
+	}
+
+	for input, n := range map[string]int{"": 0, "[]": 0, "{host = a}": 1, "[{host = a}]": 1} {
+		store, err := p.Parse(strings.NewReader("[cluster]\nservers = " + input + "\n"))
+		if err != nil || len(servers.Value(store).([]Server)) != n {
+			t.Fatal(input, err)
+		}
+	}
+	for _, input := range []string{
+		"{port = 1}", "{host = a, host = b}", "{host = a, nope = 1}", "{host = a, port = x}",
+		"{host = a", "{host = a},", "[{host = a}", "host = a", "{host = a}}", "{host = \"a}",
+	} {
+		if _, err := p.Parse(strings.NewReader("[cluster]\nservers = " + input + "\n")); err == nil {
+			t.Fatal(input)
+		}
+	}
+
+	// Invalid elements are reported as for other lists, and an element is invalid if a member
+	// cannot represent the value of its field
+	_, err = p.Parse(strings.NewReader("[cluster]\nservers = {host = a}, {port = x}\n"))
+	if err == nil || err.Error() !=
+		"Line 2: In section cluster: Element 2 '{port = x}' is not valid for field servers" {
+		t.Fatal(err)
+	}
+	type Small struct {
+		Host string
+		Port uint8
+	}
+	small := AddRecordList[Small](cluster, "small", server)
+	_, err = p.Parse(strings.NewReader("[cluster]\nsmall = {host = a, port = 300}\n"))
+	if err == nil || err.Error() !=
+		"Line 2: In section cluster: Element 1 '{host = a, port = 300}' is not valid for field small" {
+		t.Fatal(err)
+	}
+	store, err = p.Parse(strings.NewReader("[cluster]\nsmall = {host = a, port = 255}\n"))
+	if err != nil || small.Value(store).([]Small)[0].Port != 255 {
+		t.Fatal(err)
+	}
+
+	// The syntax of records needs no quotes when quotes are required, but member values do
+	p.RequireQuotes = true
+	store, err = p.Parse(strings.NewReader(
+		"[cluster]\nservers = {host = \"a#b\", port = 1}, {host = c}\n"))
+	if err != nil || servers.Value(store).([]Server)[0].Host != "a#b" {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := store.Write(&out, nil); err != nil {
+		t.Fatal(err)
+	}
+	if again, err := p.Parse(strings.NewReader(out.String())); err != nil || !again.Equal(store) {
+		t.Fatalf("Got\n%s", out.String())
+	}
+	if _, err := p.Parse(strings.NewReader("[cluster]\nservers = {host = a=b}\n")); err == nil ||
+		!strings.Contains(err.Error(), "must be quoted") {
+		t.Fatal(err)
+	}
+	p.RequireQuotes = false
+
+	expectPanic(t, "No field Nope in record server for member Nope", func() {
+		AddRecordList[struct{ Nope int }](cluster, "x", server)
+	})
+	expectPanic(t, "Values of field host cannot be stored in member Host of type int", func() {
+		AddRecordList[struct{ Host int }](cluster, "x", server)
+	})
+	expectPanic(t, "Record server is from a different parser", func() {
+		AddRecordList[Server](NewParser().AddSection("s"), "x", server)
+	})
+}
+
+func TestRecord(t *testing.T) {
+	type Limits struct {
+		CPU    uint64
+		Memory int64 `ini:"mem"`
+		Note   *string
+	}
+	p := NewParser()
+	record := p.NewRecord("limits")
+	record.AddUint64("cpu")
+	record.Add("mem", TyInt64, int64(512), ParseInt64)
+	record.AddString("note")
+	s := p.AddSection("s")
+	limits := AddRecord[Limits](s, "limits", record)
+	if d, ok := limits.Default().(Limits); !ok || d != (Limits{0, 512, nil}) {
+		t.Fatal(limits.Default())
+	}
+	store, err := p.Parse(strings.NewReader("[s]\nlimits = { cpu = 2, note = \"a, b\" }\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := limits.Value(store).(Limits)
+	if got.CPU != 2 || got.Memory != 512 || got.Note == nil || *got.Note != "a, b" {
+		t.Fatalf("%+v", got)
+	}
+	if s := limits.FormatValue(got); s != `{cpu = 2, mem = 512, note = "a, b"}` {
+		t.Fatal(s)
+	}
+	for _, input := range []string{
+		"", "cpu = 2", "{cpu = x}", "{cpu = 1}, {cpu = 2}", "{nope = 1}", "{cpu = -1}",
+	} {
+		if _, err := p.Parse(strings.NewReader("[s]\nlimits = " + input + "\n")); err == nil {
+			t.Fatal(input)
+		}
+	}
+
+	// The syntax of records needs no quotes when quotes are required, but member values do
+	p.RequireQuotes = true
+	store, err = p.Parse(strings.NewReader("[s]\nlimits = {cpu = 1, note = \"a#b\"}\n"))
+	if err != nil || *limits.Value(store).(Limits).Note != "a#b" {
+		t.Fatal(err)
+	}
+	if s := limits.FormatValue(limits.Value(store)); s != `{cpu = 1, mem = 512, note = "a#b"}` {
+		t.Fatal(s)
+	}
+	if _, err := p.Parse(strings.NewReader("[s]\nlimits = {note = a#b}\n")); err == nil {
+		t.Fatal("Should fail")
+	}
+	p.RequireQuotes = false
+
+	// Member values quoted with LiteralQuoteChar are not expanded, in records, record lists and
+	// inline tables alike
+	t.Setenv("INI_TEST_NOTE", "expanded")
+	p.ExpandVars, p.LiteralQuoteChar = true, '\''
+	list := AddRecordList[Limits](s, "list", record)
+	table := s.AddStringMap("table")
+	store, err = p.Parse(strings.NewReader("[s]\nlimits = {note = '$INI_TEST_NOTE'}\n" +
+		"list = {note = '$INI_TEST_NOTE'}, {note = $INI_TEST_NOTE}\n" +
+		"table = {a = '$INI_TEST_NOTE', b = \"$INI_TEST_NOTE\"}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := list.Value(store).([]Limits)
+	if *limits.Value(store).(Limits).Note != "$INI_TEST_NOTE" || *l[0].Note != "$INI_TEST_NOTE" ||
+		*l[1].Note != "expanded" {
+		t.Fatal(store.AllSettings(false))
+	}
+	if m := table.StringMapVal(store); m["a"] != "$INI_TEST_NOTE" || m["b"] != "expanded" {
+		t.Fatal(m)
+	}
+	p.UnboundVars = UnboundError
+	if _, err := p.Parse(strings.NewReader(
+		"[s]\nlist = {note = '$INI_TEST_NONE'}\ntable = {a = '$INI_TEST_NONE'}\n")); err != nil {
+		t.Fatal(err)
+	}
+}
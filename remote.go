@@ -0,0 +1,215 @@
+package ini
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// DefaultURLTimeout is the time limit for a fetch by [LoadURL] if URLOptions.Timeout is zero.
+const DefaultURLTimeout = 30 * time.Second
+
+// URLOptions control [LoadURL].  The zero value gives the default behavior.
+type URLOptions struct {
+	// Client is the client that makes the request (default http.DefaultClient).
+	Client *http.Client
+
+	// Header holds additional request headers, eg for authorization (default none).
+	Header http.Header
+
+	// Timeout is the time limit for the whole fetch, including reading the response (default
+	// DefaultURLTimeout).  A negative value means no limit other than the context's.
+	Timeout time.Duration
+
+	// MaxSize is the maximum size in bytes of the response body (default 0, meaning no limit).
+	// Larger responses are an error.
+	MaxSize int64
+
+	// Cache, if not nil, makes the request conditional on the response having changed since it
+	// was cached (default nil).  See [URLCache].
+	Cache *URLCache
+}
+
+// A URLCache holds the stores that [LoadURL] parsed from the responses of servers that provided an
+// ETag or Last-Modified header, by URL.  When a URL is loaded again, the request is made
+// conditional with If-None-Match or If-Modified-Since, and if the server responds that the
+// configuration has not been modified then LoadURL returns the cached store without parsing
+// anything.  The zero value is an empty cache, and its methods can be called concurrently.
+type URLCache struct {
+	mu      sync.Mutex
+	entries map[string]urlEntry
+}
+
+type urlEntry struct {
+	etag         string
+	lastModified string
+	store        *Store
+}
+
+func (cache *URLCache) lookup(parser *Parser, rawURL string) (urlEntry, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	e, found := cache.entries[rawURL]
+	return e, found && e.store.parser == parser
+}
+
+func (cache *URLCache) add(rawURL string, e urlEntry) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.entries == nil {
+		cache.entries = make(map[string]urlEntry)
+	}
+	cache.entries[rawURL] = e
+}
+
+// LoadURL fetches the configuration at the http or https URL and parses it with the parser, as
+// for [Parser.ParseFile] with the URL as the file name.  Responses other than 200 OK, or 304 Not
+// Modified for a cached URL, are errors.  `@include` directives in the configuration require the
+// parser to have an [Includer].  If opts is nil then default options are used.
+func LoadURL(ctx context.Context, parser *Parser, rawURL string, opts *URLOptions) (*Store, error) {
+	if opts == nil {
+		opts = &URLOptions{}
+	}
+	ctx, cancel := opts.context(ctx)
+	defer cancel()
+	f, err := fetchURL(ctx, parser, rawURL, opts)
+	if err != nil || f.store != nil {
+		return f.store, err
+	}
+	return f.parse(ctx, parser, rawURL, opts)
+}
+
+// A fetched is the result of a successful fetch: the cached store if the response was 304 Not
+// Modified, otherwise the body and validators of the response.
+type fetched struct {
+	store        *Store
+	body         []byte
+	etag         string
+	lastModified string
+}
+
+// context returns the context for a fetch with the options' timeout.
+func (opts *URLOptions) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = DefaultURLTimeout
+	}
+	if timeout < 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// fetchURL fetches the URL for LoadURL.
+func fetchURL(
+	ctx context.Context,
+	parser *Parser,
+	rawURL string,
+	opts *URLOptions,
+) (fetched, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fetched{}, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fetched{}, fmt.Errorf("Unsupported URL scheme in %s", rawURL)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return fetched{}, err
+	}
+	for k, vs := range opts.Header {
+		req.Header[k] = append([]string(nil), vs...)
+	}
+	var cached urlEntry
+	var isCached bool
+	if opts.Cache != nil {
+		if cached, isCached = opts.Cache.lookup(parser, rawURL); isCached {
+			if cached.etag != "" {
+				req.Header.Set("If-None-Match", cached.etag)
+			}
+			if cached.lastModified != "" {
+				req.Header.Set("If-Modified-Since", cached.lastModified)
+			}
+		}
+	}
+	client := opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fetched{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && isCached {
+		return fetched{store: cached.store}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fetched{}, fmt.Errorf("Fetching %s: %s", rawURL, resp.Status)
+	}
+	body, err := readBody(resp, opts.MaxSize)
+	if err != nil {
+		return fetched{}, fmt.Errorf("Fetching %s: %w", rawURL, err)
+	}
+	return fetched{
+		body:         body,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}, nil
+}
+
+// parse parses the fetched body and caches the result if the options have a cache.
+func (f fetched) parse(
+	ctx context.Context,
+	parser *Parser,
+	rawURL string,
+	opts *URLOptions,
+) (*Store, error) {
+	store, err := parser.parse(ctx, bytes.NewReader(f.body), rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	f.cache(rawURL, store, opts)
+	return store, nil
+}
+
+// cache records the store for the fetched response if the options have a cache and the response
+// has validators.
+func (f fetched) cache(rawURL string, store *Store, opts *URLOptions) {
+	if opts.Cache != nil && (f.etag != "" || f.lastModified != "") {
+		opts.Cache.add(rawURL, urlEntry{f.etag, f.lastModified, store})
+	}
+}
+
+// readBody reads the body of the response, failing if it is larger than maxSize bytes unless
+// maxSize is 0.
+func readBody(resp *http.Response, maxSize int64) ([]byte, error) {
+	if maxSize <= 0 {
+		return io.ReadAll(resp.Body)
+	}
+	tooLarge := fmt.Errorf("Response too large, the limit is %d bytes", maxSize)
+	if resp.ContentLength > maxSize {
+		return nil, tooLarge
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > maxSize {
+		return nil, tooLarge
+	}
+	return body, nil
+}
+
+// isURL returns true if the name is a URL with a scheme, rather than a file name.  One-letter
+// schemes are taken to be Windows drive letters.
+func isURL(name string) bool {
+	u, err := url.Parse(name)
+	return err == nil && len(u.Scheme) > 1
+}
@@ -0,0 +1,95 @@
+package ini
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadURL(t *testing.T) {
+	var notModified int
+	body := "[db]\nport = 5432\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer x" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/app.ini":
+			etag := `"` + strconv.Itoa(len(body)) + `"`
+			if r.Header.Get("If-None-Match") == etag {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", etag)
+			w.Write([]byte(body))
+		case "/bad.ini":
+			w.Write([]byte("[db]\nport = x\n"))
+		case "/slow.ini":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	p := NewParser()
+	port := p.AddSection("db").AddInt64("port")
+	cache := &URLCache{}
+	opts := &URLOptions{Header: http.Header{"Authorization": {"Bearer x"}}, Cache: cache}
+	ctx := context.Background()
+	store, err := LoadURL(ctx, p, srv.URL+"/app.ini", opts)
+	if err != nil || port.Int64Val(store) != 5432 {
+		t.Fatal(err)
+	}
+	if o := port.Origin(store); o.File != srv.URL+"/app.ini" || o.Line != 2 {
+		t.Fatal(o)
+	}
+	again, err := LoadURL(ctx, p, srv.URL+"/app.ini", opts)
+	if err != nil || again != store || notModified != 1 {
+		t.Fatal(err, notModified)
+	}
+	body = "[db]\nport = 1\n"
+	changed, err := LoadURL(ctx, p, srv.URL+"/app.ini", opts)
+	if err != nil || changed == store || port.Int64Val(changed) != 1 {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadURL(ctx, p, srv.URL+"/app.ini", nil); err == nil ||
+		err.Error() != "Fetching "+srv.URL+"/app.ini: 401 Unauthorized" {
+		t.Fatal(err)
+	}
+	var pe *ParseError
+	if _, err := LoadURL(ctx, p, srv.URL+"/bad.ini", opts); !errors.As(err, &pe) || pe.Line != 2 {
+		t.Fatal(err)
+	}
+	small := *opts
+	small.MaxSize = 10
+	small.Cache = nil
+	if _, err := LoadURL(ctx, p, srv.URL+"/app.ini", &small); err == nil ||
+		!strings.Contains(err.Error(), "Response too large, the limit is 10 bytes") {
+		t.Fatal(err)
+	}
+	quick := *opts
+	quick.Timeout = 10 * time.Millisecond
+	_, err = LoadURL(ctx, p, srv.URL+"/slow.ini", &quick)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal(err)
+	}
+	if _, err := LoadURL(ctx, p, "file:///etc/passwd", nil); err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	// Includes are not read from the local file system
+	body = "@include app.ini\n"
+	if _, err := LoadURL(ctx, p, srv.URL+"/app.ini", opts); err == nil ||
+		!strings.Contains(err.Error(), "requires an Includer") {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,359 @@
+package ini
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// byteOrderMark is the UTF-8 encoding of U+FEFF, which some editors put at the start of files.
+const byteOrderMark = "\uFEFF"
+
+// ErrLineTooLong is the error reported by a [Scanner] for a line longer than its MaxLineLen.
+var ErrLineTooLong = errors.New("line too long")
+
+// A TokenKind classifies a line of input.
+type TokenKind int
+
+const (
+	TokBlank     TokenKind = iota + 1 // The line is blank
+	TokComment                        // The line is a comment
+	TokSection                        // The line is a section header
+	TokSetting                        // The line is a name=value setting
+	TokDirective                      // The line is a directive, such as `@if`
+	TokInvalid                        // The line is none of the above
+)
+
+// A Token is a classified line of input.
+type Token struct {
+	Kind    TokenKind // The line's classification
+	Line    int       // The 1-based line number
+	Text    string    // The text of the line, without the line break
+	Name    string    // The name of the section, setting or directive
+	Profile string    // The profile name for a TokSection of the form `[name:profile]`, or ""
+	Value   string    // The text after the `=`, the comment char, or the directive name
+}
+
+// A Scanner splits its input into lines and classifies them as tokens, without reference to any
+// schema and without interpreting values.  It is the layer below [Parser.ParseEvents] and is useful
+// for tools, such as formatters, that need to see every line of the input.  The Value of a setting
+// is exactly the text following the `=`, and the Value of a comment is exactly the text following
+// the comment character.
+//
+// Set the option fields before the first call to Scan, then call Scan until it returns false and
+// process each Token in turn.  The syntax is as for [Parser], with section and setting names
+// that are syntactically valid.
+type Scanner struct {
+	// CommentChar is the character that starts comment lines (default '#').
+	CommentChar rune
+
+	// MaxLineLen is the maximum length in bytes of an input line, not counting the line break
+	// (default 0, meaning no limit).  A longer line stops the scan with ErrLineTooLong.
+	MaxLineLen int
+
+	// CRBreaks controls whether a lone carriage return, as used by classic Mac OS, ends a line
+	// (default true).  "\n" and "\r\n" always end lines.  If false, a lone "\r" is part of the line.
+	CRBreaks bool
+
+	// LineHook, if not nil, is called with the number and text of every line before it is
+	// classified (default nil).  It returns the text to classify in place of the line, and true, or
+	// false to drop the line, which then produces no token.  Line numbers are not affected.
+	LineHook func(lineno int, line string) (string, bool)
+
+	r     io.Reader
+	text  *string // If not nil, the input, which is read in place instead of from r
+	lines *lineReader
+	line  int
+	tok   Token
+}
+
+// NewScanner returns a new Scanner with default settings that reads from r.
+func NewScanner(r io.Reader) *Scanner {
+	return &Scanner{CommentChar: '#', CRBreaks: true, r: r}
+}
+
+// Scan advances to the next line, returning true if there is one and false at the end of the input
+// or on error.
+func (s *Scanner) Scan() bool {
+	if s.lines == nil {
+		if s.text != nil {
+			s.lines = &lineReader{text: *s.text, maxLen: s.MaxLineLen, crBreaks: s.CRBreaks}
+		} else {
+			s.lines = newLineReader(s.r, s.MaxLineLen, s.CRBreaks)
+		}
+	}
+	var l string
+	for {
+		var more bool
+		l, more = s.lines.next()
+		if !more {
+			return false
+		}
+		s.line++
+		if s.line == 1 {
+			l = strings.TrimPrefix(l, byteOrderMark)
+		}
+		if s.LineHook == nil {
+			break
+		}
+		var keep bool
+		if l, keep = s.LineHook(s.line, l); keep {
+			break
+		}
+	}
+	s.tok = classify(l, s.CommentChar)
+	s.tok.Line = s.line
+	return true
+}
+
+// classify classifies a line.  This is the hot path of all parsing, so it scans bytes by hand
+// rather than matching regular expressions.
+func classify(l string, commentChar rune) Token {
+	tok := Token{Kind: TokInvalid, Text: l}
+	i := skipBlanks(l, 0)
+	if i == len(l) {
+		tok.Kind = TokBlank
+		return tok
+	}
+	if c, size := utf8.DecodeRuneInString(l[i:]); c == commentChar {
+		tok.Kind = TokComment
+		tok.Value = l[i+size:]
+		return tok
+	}
+	if l[i] == '@' {
+		end := skipName(l, i+1)
+		if end > i+1 && (end == len(l) || skipBlanks(l, end) > end) {
+			tok.Kind = TokDirective
+			tok.Name = l[i+1 : end]
+			tok.Value = l[end:]
+		}
+		return tok
+	}
+	if l[i] == '[' {
+		start := skipBlanks(l, i+1)
+		end := skipName(l, start)
+		j := skipBlanks(l, end)
+		var profile string
+		if j < len(l) && l[j] == ':' {
+			pstart := skipBlanks(l, j+1)
+			pend := skipName(l, pstart)
+			if pend == pstart {
+				return tok
+			}
+			profile = l[pstart:pend]
+			j = skipBlanks(l, pend)
+		}
+		if end > start && j < len(l) && l[j] == ']' && skipBlanks(l, j+1) == len(l) {
+			tok.Kind = TokSection
+			tok.Name = l[start:end]
+			tok.Profile = profile
+		}
+		return tok
+	}
+	end := skipName(l, i)
+	j := skipBlanks(l, end)
+	if end > i && j < len(l) && l[j] == '=' {
+		tok.Kind = TokSetting
+		tok.Name = l[i:end]
+		tok.Value = l[j+1:]
+	}
+	return tok
+}
+
+// skipBlanks returns the index of the first non-blank in l at or after i, or len(l).  Blanks are
+// Unicode white space, though ASCII is the fast path.
+func skipBlanks(l string, i int) int {
+	for i < len(l) {
+		if c := l[i]; c < utf8.RuneSelf {
+			if !isBlank(c) {
+				break
+			}
+			i++
+		} else {
+			r, size := utf8.DecodeRuneInString(l[i:])
+			if !unicode.IsSpace(r) {
+				break
+			}
+			i += size
+		}
+	}
+	return i
+}
+
+// skipName returns the index of the first non-name character in l at or after i, or len(l).
+func skipName(l string, i int) int {
+	for i < len(l) && isNameChar(l[i]) {
+		i++
+	}
+	return i
+}
+
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\f' || c == '\r'
+}
+
+// isName returns true if s is a syntactically valid section or field name.  This, with isNameChar,
+// is the only definition of the name syntax: the Scanner recognizes exactly these names in the
+// input, and names are never embedded in regular expressions, so no name character has any
+// special meaning.
+func isName(s string) bool {
+	return s != "" && skipName(s, 0) == len(s)
+}
+
+func isNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '-' || c == '_' || c == '$'
+}
+
+// Token returns the token for the line most recently read by Scan.
+func (s *Scanner) Token() Token {
+	return s.tok
+}
+
+// Err returns the first error other than io.EOF encountered by the Scanner, if any.
+func (s *Scanner) Err() error {
+	if s.lines == nil {
+		return nil
+	}
+	return s.lines.err
+}
+
+var errInputTooLarge = errors.New("input too large")
+
+// lineReader splits its input into lines of any length, up to maxLen bytes if maxLen > 0.  The line
+// breaks, "\n" or "\r\n", and also "\r" if crBreaks is true, are not part of the lines.  The input
+// is read through s, or if s is nil it is text, whose lines are returned without copying.
+type lineReader struct {
+	s        *bufio.Scanner
+	text     string
+	crBreaks bool // For text, see above
+	maxLen   int
+	err      error // The error that stopped the reading, other than io.EOF
+}
+
+func newLineReader(r io.Reader, maxLen int, crBreaks bool) *lineReader {
+	tr := &trackingReader{r: r}
+	lr := &lineReader{s: bufio.NewScanner(tr), maxLen: maxLen}
+	limit := math.MaxInt
+	if maxLen > 0 {
+		limit = maxLen + 2
+	}
+	lr.s.Buffer(make([]byte, 4096), limit)
+	lr.s.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		var i int
+		if crBreaks {
+			i = bytes.IndexAny(data, "\r\n")
+		} else {
+			i = bytes.IndexByte(data, '\n')
+		}
+		switch {
+		case i < 0 && atEOF && len(data) > 0 && tr.err != nil:
+			return 0, nil, tr.err // The line was cut short by the error, don't return it
+		case i < 0 && atEOF && len(data) > 0:
+			return len(data), data, nil
+		case i < 0:
+			return 0, nil, nil
+		case data[i] == '\n':
+			return i + 1, bytes.TrimSuffix(data[:i], []byte{'\r'}), nil
+		case i+1 < len(data) && data[i+1] == '\n':
+			return i + 2, data[:i], nil
+		case i+1 < len(data) || atEOF:
+			return i + 1, data[:i], nil
+		default:
+			return 0, nil, nil // Need to know whether "\r" is followed by "\n"
+		}
+	})
+	return lr
+}
+
+// next returns the next line and true, or "" and false at the end of the input or on error.
+func (lr *lineReader) next() (string, bool) {
+	if lr.err != nil {
+		return "", false
+	}
+	if lr.s == nil {
+		return lr.nextText()
+	}
+	if !lr.s.Scan() {
+		lr.err = lr.s.Err()
+		if lr.err == bufio.ErrTooLong {
+			lr.err = ErrLineTooLong
+		}
+		return "", false
+	}
+	line := lr.s.Bytes()
+	if lr.maxLen > 0 && len(line) > lr.maxLen {
+		lr.err = ErrLineTooLong
+		return "", false
+	}
+	return string(line), true
+}
+
+// nextText is next for text input: the line is a substring of the text.
+func (lr *lineReader) nextText() (string, bool) {
+	if lr.text == "" {
+		return "", false
+	}
+	var i int
+	if lr.crBreaks {
+		i = strings.IndexAny(lr.text, "\r\n")
+	} else {
+		i = strings.IndexByte(lr.text, '\n')
+	}
+	var line string
+	switch {
+	case i < 0:
+		line, lr.text = lr.text, ""
+	case lr.text[i] == '\n':
+		line, lr.text = strings.TrimSuffix(lr.text[:i], "\r"), lr.text[i+1:]
+	case i+1 < len(lr.text) && lr.text[i+1] == '\n':
+		line, lr.text = lr.text[:i], lr.text[i+2:]
+	default:
+		line, lr.text = lr.text[:i], lr.text[i+1:]
+	}
+	if lr.maxLen > 0 && len(line) > lr.maxLen {
+		lr.err = ErrLineTooLong
+		return "", false
+	}
+	return line, true
+}
+
+// trackingReader reads from r and records the last error other than io.EOF.
+type trackingReader struct {
+	r   io.Reader
+	err error
+}
+
+func (tr *trackingReader) Read(p []byte) (int, error) {
+	k, err := tr.r.Read(p)
+	if err != nil && err != io.EOF {
+		tr.err = err
+	}
+	return k, err
+}
+
+// limitedReader reads from r until more than n bytes have been read, and then fails.
+type limitedReader struct {
+	r io.Reader
+	n int
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	if lr.n < 0 {
+		return 0, errInputTooLarge
+	}
+	if len(p) > lr.n+1 {
+		p = p[:lr.n+1]
+	}
+	k, err := lr.r.Read(p)
+	lr.n -= k
+	if lr.n < 0 {
+		return 0, errInputTooLarge
+	}
+	return k, err
+}
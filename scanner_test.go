@@ -0,0 +1,181 @@
+package ini
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestScanner(t *testing.T) {
+	s := NewScanner(strings.NewReader("; note\r\n\n [ sect ] \nx = \"a b\" \n?!\n# not a comment"))
+	s.CommentChar = ';'
+	expect := []Token{
+		{Kind: TokComment, Line: 1, Text: "; note", Value: " note"},
+		{Kind: TokBlank, Line: 2, Text: ""},
+		{Kind: TokSection, Line: 3, Text: " [ sect ] ", Name: "sect"},
+		{Kind: TokSetting, Line: 4, Text: `x = "a b" `, Name: "x", Value: ` "a b" `},
+		{Kind: TokInvalid, Line: 5, Text: "?!"},
+		{Kind: TokInvalid, Line: 6, Text: "# not a comment"},
+	}
+	for _, e := range expect {
+		if !s.Scan() {
+			t.Fatal("Premature end", s.Err())
+		}
+		if tok := s.Token(); tok != e {
+			t.Fatalf("Expected %#v got %#v", e, tok)
+		}
+	}
+	if s.Scan() || s.Err() != nil {
+		t.Fatal("Expected end", s.Err())
+	}
+
+	s = NewScanner(strings.NewReader("abcdef\n"))
+	s.MaxLineLen = 5
+	if s.Scan() || !errors.Is(s.Err(), ErrLineTooLong) {
+		t.Fatal("Expected error", s.Err())
+	}
+}
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		line  string
+		kind  TokenKind
+		name  string
+		value string
+	}{
+		{" \t\r", TokBlank, "", ""},
+		{"\t#x", TokComment, "", "x"},
+		{"[a-b_$]", TokSection, "a-b_$", ""},
+		{"\t[\ta\t]\t", TokSection, "a", ""},
+		{"[a b]", TokInvalid, "", ""},
+		{"[]", TokInvalid, "", ""},
+		{"[a]x", TokInvalid, "", ""},
+		{"[a", TokInvalid, "", ""},
+		{"[ a : p ]", TokSection, "a", ""},
+		{"[a:]", TokInvalid, "", ""},
+		{"[:p]", TokInvalid, "", ""},
+		{"[a:p:q]", TokInvalid, "", ""},
+		{"a=", TokSetting, "a", ""},
+		{" a \t= b=c ", TokSetting, "a", " b=c "},
+		{"=x", TokInvalid, "", ""},
+		{"a b=x", TokInvalid, "", ""},
+		{"a.b=x", TokInvalid, "", ""},
+		{" @if os == linux", TokDirective, "if", " os == linux"},
+		{"@endif", TokDirective, "endif", ""},
+		{"@", TokInvalid, "", ""},
+		{"@if=x", TokInvalid, "", ""},
+	}
+	for _, c := range cases {
+		tok := classify(c.line, '#')
+		if tok.Kind != c.kind || tok.Name != c.name || tok.Value != c.value || tok.Text != c.line {
+			t.Fatalf("%q: %#v", c.line, tok)
+		}
+	}
+	if tok := classify("[ a : p ]", '#'); tok.Profile != "p" {
+		t.Fatalf("%#v", tok)
+	}
+}
+
+func TestUnicode(t *testing.T) {
+	p := NewParser("CommentChar", '§', "QuoteChar", '¦')
+	s := p.AddSection("sect")
+	s.AddString("s")
+	s.AddString("t")
+	store, err := p.Parse(strings.NewReader(
+		"\uFEFF§ comment\n [ sect ]\u3000\ns = ¦quoted¦\u00a0\nt=\u00a0 x \u2003\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x := s.Field("s").StringVal(store); x != "quoted" {
+		t.Fatalf("%q", x)
+	}
+	if x := s.Field("t").StringVal(store); x != "x" {
+		t.Fatalf("%q", x)
+	}
+
+	// A byte order mark is only special at the start of the input
+	_, err = p.Parse(strings.NewReader("[sect]\n\uFEFFs=1\n"))
+	if err == nil {
+		t.Fatal("Should fail")
+	}
+}
+
+func TestLineBreaks(t *testing.T) {
+	p := NewParser()
+	s := p.AddSection("sect")
+	s.AddString("a")
+	s.AddString("b")
+	s.AddString("c")
+	for _, input := range []string{
+		"[sect]\na=1\nb=2\nc=3",
+		"[sect]\r\na=1\r\nb=2\r\nc=3\r\n",
+		"[sect]\ra=1\rb=2\rc=3\r",
+		"[sect]\r\na=1\rb=2\nc=3\r",
+	} {
+		store, err := p.Parse(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("%q: %v", input, err)
+		}
+		if store.GetString("sect.a") != "1" || store.GetString("sect.b") != "2" ||
+			store.GetString("sect.c") != "3" {
+			t.Fatalf("%q: %v", input, store.RedactedMap())
+		}
+	}
+
+	// "\r\n" split across reads
+	store, err := p.Parse(iotest.OneByteReader(strings.NewReader("[sect]\r\na=1\r\n\r\nb=2")))
+	if err != nil || store.GetString("sect.b") != "2" {
+		t.Fatal(err)
+	}
+
+	p.CRBreaks = false
+	store, err = p.Parse(strings.NewReader("[sect]\r\na=1\rb=2\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x := store.GetString("sect.a"); x != "1\rb=2" {
+		t.Fatalf("%q", x)
+	}
+}
+
+func TestScannerReadError(t *testing.T) {
+	// A line that is cut short by an error is not returned
+	boom := errors.New("boom")
+	s := NewScanner(io.MultiReader(strings.NewReader("[sect]\nx = 1"), iotest.ErrReader(boom)))
+	if !s.Scan() || s.Token().Kind != TokSection {
+		t.Fatal("First line")
+	}
+	if s.Scan() || s.Err() != boom {
+		t.Fatal(s.Token(), s.Err())
+	}
+}
+
+func TestLineHook(t *testing.T) {
+	var seen []int
+	p := NewParser("LineHook", func(lineno int, line string) (string, bool) {
+		seen = append(seen, lineno)
+		if strings.HasPrefix(line, "!") {
+			return "", false
+		}
+		return strings.ReplaceAll(line, "SECRET", "x"), true
+	})
+	s := p.AddSection("sect")
+	s.AddString("a")
+	_, err := p.Parse(strings.NewReader("[sect]\n!garbage\n!more\na = SECRET\nb = 1\n"))
+	if err == nil || err.Error() != "Line 5: In section sect: No field b" {
+		t.Fatal(err)
+	}
+	store, err := p.Parse(strings.NewReader("[sect]\n!garbage\n!more\na = SECRET\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.GetString("sect.a") != "x" || len(seen) != 9 {
+		t.Fatal(store.GetString("sect.a"), seen)
+	}
+	doc, err := p.ParseDocument(strings.NewReader("!kept\n"))
+	if err == nil || doc != nil {
+		t.Fatal("Document should not be filtered")
+	}
+}
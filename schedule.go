@@ -0,0 +1,182 @@
+package ini
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A TimeOfDay is a wall clock time with a resolution of one minute.  Hour is 0 to 23, or 24 for the
+// end of a [TimeWindow] that lasts until midnight.
+type TimeOfDay struct {
+	Hour, Minute int
+}
+
+// String renders the time as `HH:MM`.
+func (t TimeOfDay) String() string {
+	return fmt.Sprintf("%02d:%02d", t.Hour, t.Minute)
+}
+
+func (t TimeOfDay) minutes() int {
+	return t.Hour*60 + t.Minute
+}
+
+// AddTimeOfDay adds a new field of the given name to the section whose values are times of day.
+// The name must not be present in the section and must be syntactically valid (see package
+// comments).  ParseTimeOfDay describes the accepted values.  The field has type TyUser and the
+// default value is midnight, the zero TimeOfDay.  Use [ValueOf] with TimeOfDay to access the value.
+// The field is also available as the registered type "timeofday", see [Section.AddTyped].
+func (section *Section) AddTimeOfDay(name string) *Field {
+	return section.Add(name, TyUser, TimeOfDay{}, ParseTimeOfDay)
+}
+
+// ParseTimeOfDay accepts a time of day on the 24-hour clock written as `HH:MM`, eg `09:30`, where
+// the hour can also be a single digit, returning the time as a TimeOfDay and a validity flag.
+func ParseTimeOfDay(s string) (any, bool) {
+	t, ok := parseTimeOfDay(s, false)
+	return t, ok
+}
+
+// parseTimeOfDay parses s as for ParseTimeOfDay, also accepting `24:00` if end is true.
+func parseTimeOfDay(s string, end bool) (TimeOfDay, bool) {
+	hs, ms, found := strings.Cut(s, ":")
+	if !found || len(hs) < 1 || len(hs) > 2 || len(ms) != 2 {
+		return TimeOfDay{}, false
+	}
+	h, err1 := strconv.ParseUint(hs, 10, 8)
+	m, err2 := strconv.ParseUint(ms, 10, 8)
+	t := TimeOfDay{int(h), int(m)}
+	if err1 != nil || err2 != nil || m > 59 || h > 23 && !(end && t == TimeOfDay{24, 0}) {
+		return TimeOfDay{}, false
+	}
+	return t, true
+}
+
+// AddWeekday adds a new field of the given name to the section whose values are days of the week.
+// The name must not be present in the section and must be syntactically valid (see package
+// comments).  ParseWeekday describes the accepted values.  The field has type TyUser and the
+// default value is time.Sunday, the zero time.Weekday.  Use [ValueOf] with time.Weekday to access
+// the value.  The field is also available as the registered type "weekday", see
+// [Section.AddTyped].
+func (section *Section) AddWeekday(name string) *Field {
+	return section.Add(name, TyUser, time.Sunday, ParseWeekday)
+}
+
+// ParseWeekday accepts the English name of a day of the week, eg `Monday`, or its first three
+// letters, eg `Mon`, ignoring case, returning the day as a time.Weekday and a validity flag.
+func ParseWeekday(s string) (any, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(s, d.String()) || strings.EqualFold(s, d.String()[:3]) {
+			return d, true
+		}
+	}
+	return time.Sunday, false
+}
+
+// A TimeWindow is a period of the day on some days of the week, eg a maintenance window.  A window
+// whose End is not after its Start extends past midnight into the next day, and Days are the days
+// on which it starts.
+type TimeWindow struct {
+	Days       [7]bool // Indexed by time.Weekday
+	Start, End TimeOfDay
+}
+
+// String renders the window as [ParseTimeWindow] accepts it, with the days in order from Monday
+// and consecutive days as ranges, eg `Mon-Fri 09:00-17:00`, or without days if the window is on
+// every day.  A window on no days, such as the zero TimeWindow, is rendered as the empty string.
+func (w TimeWindow) String() string {
+	if w.Days == [7]bool{} {
+		return ""
+	}
+	times := w.Start.String() + "-" + w.End.String()
+	if w.Days == [7]bool{true, true, true, true, true, true, true} {
+		return times
+	}
+	var runs []string
+	for i := 0; i < 7; {
+		if !w.Days[(i+1)%7] {
+			i++
+			continue
+		}
+		j := i
+		for j+1 < 7 && w.Days[(j+2)%7] {
+			j++
+		}
+		run := time.Weekday((i + 1) % 7).String()[:3]
+		if j > i {
+			run += "-" + time.Weekday((j + 1) % 7).String()[:3]
+		}
+		runs = append(runs, run)
+		i = j + 1
+	}
+	return strings.Join(runs, ",") + " " + times
+}
+
+// Contains returns true if the time t, in its location, is in the window.  The window includes its
+// start but not its end.
+func (w TimeWindow) Contains(t time.Time) bool {
+	now := t.Hour()*60 + t.Minute()
+	start, end := w.Start.minutes(), w.End.minutes()
+	day := t.Weekday()
+	if start < end {
+		return w.Days[day] && now >= start && now < end
+	}
+	return w.Days[day] && now >= start || w.Days[(day+6)%7] && now < end
+}
+
+// AddTimeWindow adds a new field of the given name to the section whose values are time windows.
+// The name must not be present in the section and must be syntactically valid (see package
+// comments).  ParseTimeWindow describes the accepted values.  The field has type TyUser and the
+// default value is the zero TimeWindow, which is on no days.  Use [ValueOf] with TimeWindow to
+// access the value.  The field is also available as the registered type "timewindow", see
+// [Section.AddTyped].
+func (section *Section) AddTimeWindow(name string) *Field {
+	return section.Add(name, TyUser, TimeWindow{}, ParseTimeWindow)
+}
+
+// ParseTimeWindow accepts a time window written as days followed by times, eg `Mon-Fri 09:00-17:00`
+// or `Sat,Sun 22:00-06:00`, returning the window as a TimeWindow and a validity flag.  The days are
+// a comma-separated list of days and ranges of days as for ParseWeekday, where a range can wrap
+// around the end of the week, eg `Fri-Mon`, and they can be omitted for a window on every day.  The
+// times are a start and an end time as for ParseTimeOfDay, where the end can also be `24:00`.  The
+// start and end must differ, and if the end is before the start the window ends on the next day.
+// The empty (or blank) string is the zero TimeWindow, which is on no days.
+func ParseTimeWindow(s string) (any, bool) {
+	var w TimeWindow
+	fields := strings.Fields(s)
+	switch len(fields) {
+	case 0:
+		return w, true
+	case 1:
+		w.Days = [7]bool{true, true, true, true, true, true, true}
+	case 2:
+		for _, item := range strings.Split(fields[0], ",") {
+			first, last, isRange := strings.Cut(item, "-")
+			if !isRange {
+				last = first
+			}
+			from, ok1 := ParseWeekday(first)
+			to, ok2 := ParseWeekday(last)
+			if !ok1 || !ok2 {
+				return TimeWindow{}, false
+			}
+			for d := from.(time.Weekday); ; d = (d + 1) % 7 {
+				w.Days[d] = true
+				if d == to {
+					break
+				}
+			}
+		}
+	default:
+		return TimeWindow{}, false
+	}
+	start, end, found := strings.Cut(fields[len(fields)-1], "-")
+	var ok1, ok2 bool
+	w.Start, ok1 = parseTimeOfDay(start, false)
+	w.End, ok2 = parseTimeOfDay(end, true)
+	if !found || !ok1 || !ok2 || w.Start == w.End {
+		return TimeWindow{}, false
+	}
+	return w, true
+}
@@ -0,0 +1,116 @@
+package ini
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSchedule(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		want  TimeOfDay
+		ok    bool
+	}{
+		{"09:30", TimeOfDay{9, 30}, true},
+		{"9:05", TimeOfDay{9, 5}, true},
+		{"23:59", TimeOfDay{23, 59}, true},
+		{"24:00", TimeOfDay{}, false},
+		{"12:60", TimeOfDay{}, false},
+		{"12:5", TimeOfDay{}, false},
+		{"+1:00", TimeOfDay{}, false},
+		{"1200", TimeOfDay{}, false},
+	} {
+		v, ok := ParseTimeOfDay(tc.input)
+		if ok != tc.ok || ok && v != tc.want {
+			t.Fatal(tc.input, v, ok)
+		}
+	}
+	if d, ok := ParseWeekday("TUE"); !ok || d != time.Tuesday {
+		t.Fatal(d)
+	}
+	if d, ok := ParseWeekday("wednesday"); !ok || d != time.Wednesday {
+		t.Fatal(d)
+	}
+	if _, ok := ParseWeekday("Tues"); ok {
+		t.Fatal("Tues")
+	}
+
+	for _, tc := range []struct {
+		input, want string
+	}{
+		{"Mon-Fri 09:00-17:00", "Mon-Fri 09:00-17:00"},
+		{"sat,SUN  22:00-6:00", "Sat-Sun 22:00-06:00"},
+		{"Fri-Mon 00:00-24:00", "Mon,Fri-Sun 00:00-24:00"},
+		{"Wed,Mon,Tue 01:00-02:00", "Mon-Wed 01:00-02:00"},
+		{"02:00-04:00", "02:00-04:00"},
+		{"Mon-Sun 02:00-04:00", "02:00-04:00"},
+		{"Mon 02:00-02:00", ""},
+		{"Mon 02:00", ""},
+		{"Mon-Xyz 02:00-03:00", ""},
+		{"Mon 24:00-03:00", ""},
+		{"Mon Tue 02:00-03:00", ""},
+	} {
+		v, ok := ParseTimeWindow(tc.input)
+		if ok != (tc.want != "") || ok && v.(TimeWindow).String() != tc.want {
+			t.Fatal(tc.input, v, ok)
+		}
+	}
+	if v, ok := ParseTimeWindow(" "); !ok || v != (TimeWindow{}) || (TimeWindow{}).String() != "" {
+		t.Fatal(v, ok)
+	}
+
+	p := NewParser()
+	s := p.AddSection("maintenance")
+	day := s.AddWeekday("day")
+	at := s.AddTimeOfDay("at")
+	window := s.AddTyped("window", "timewindow")
+	store, err := p.Parse(strings.NewReader(
+		"[maintenance]\nday = sun\nat = 3:15\nwindow = Fri,Sat 22:00-02:00\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := ValueOf[TimeWindow](window, store)
+	if ValueOf[time.Weekday](day, store) != time.Sunday ||
+		ValueOf[TimeOfDay](at, store) != (TimeOfDay{3, 15}) {
+		t.Fatal(store.AllSettings(false))
+	}
+	// 2024-06-07 is a Friday
+	for _, tc := range []struct {
+		time string
+		in   bool
+	}{
+		{"2024-06-07 22:00", true},
+		{"2024-06-07 21:59", false},
+		{"2024-06-08 01:59", true},
+		{"2024-06-09 01:00", true},
+		{"2024-06-09 02:00", false},
+		{"2024-06-09 23:00", false},
+		{"2024-06-07 01:00", false},
+	} {
+		tm, _ := time.Parse("2006-01-02 15:04", tc.time)
+		if w.Contains(tm) != tc.in {
+			t.Fatal(tc.time)
+		}
+	}
+	var b strings.Builder
+	if err := store.Write(&b, nil); err != nil || b.String() !=
+		"[maintenance]\nday = Sunday\nat = 03:15\nwindow = Fri-Sat 22:00-02:00\n" {
+		t.Fatal(err, b.String())
+	}
+
+	// The default window can be written and read back
+	store, err = p.Parse(strings.NewReader(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Reset()
+	if err := store.Write(&b, &WriteOptions{Defaults: true}); err != nil ||
+		!strings.Contains(b.String(), "window =\n") {
+		t.Fatal(err, b.String())
+	}
+	if again, err := p.Parse(strings.NewReader(b.String())); err != nil ||
+		ValueOf[TimeWindow](window, again) != (TimeWindow{}) {
+		t.Fatal(err)
+	}
+}
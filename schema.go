@@ -0,0 +1,218 @@
+package ini
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"unicode/utf8"
+)
+
+// schemaJSON is the form of a schema file, see LoadSchema.
+type schemaJSON struct {
+	Options  schemaOptions   `json:"options"`
+	Sections []schemaSection `json:"sections"`
+}
+
+type schemaOptions struct {
+	CommentChar      *string `json:"CommentChar"`
+	QuoteChar        *string `json:"QuoteChar"`
+	LiteralQuoteChar *string `json:"LiteralQuoteChar"`
+	ListDelim        *string `json:"ListDelim"`
+	Escapes          *bool   `json:"Escapes"`
+	RequireQuotes    *bool   `json:"RequireQuotes"`
+	IntPrefixes      *bool   `json:"IntPrefixes"`
+	BoolSynonyms     *bool   `json:"BoolSynonyms"`
+	ExpandVars       *bool   `json:"ExpandVars"`
+	CRBreaks         *bool   `json:"CRBreaks"`
+}
+
+type schemaSection struct {
+	Name     string        `json:"name"`
+	Required bool          `json:"required"`
+	Fields   []schemaField `json:"fields"`
+}
+
+type schemaField struct {
+	Name     string          `json:"name"`
+	Type     string          `json:"type"`
+	Default  json.RawMessage `json:"default"`
+	Help     string          `json:"help"`
+	Required bool            `json:"required"`
+	Secret   bool            `json:"secret"`
+	Env      string          `json:"env"`
+	Min      *float64        `json:"min"`
+	Max      *float64        `json:"max"`
+}
+
+// LoadSchema reads a JSON description of a schema from r and returns a new parser with its options,
+// sections and fields, so that tools can check the configuration files of other programs.  For
+// example:
+//
+//	{
+//	  "options": {"CommentChar": ";", "BoolSynonyms": true},
+//	  "sections": [
+//	    {"name": "net", "required": true, "fields": [
+//	      {"name": "host", "type": "string", "default": "localhost", "required": true,
+//	       "help": "The server's host name"},
+//	      {"name": "port", "type": "int64", "default": 8080, "min": 1, "max": 65535},
+//	      {"name": "password", "type": "string", "secret": true, "env": "NET_PASSWORD"},
+//	      {"name": "peers", "type": "[]string"}
+//	    ]}
+//	  ]
+//	}
+//
+// The options are the parser's rune and bool options, with runes given as one-character strings,
+// or "" for none.  The type of a field is the name of a registered type (see [RegisterType]), or
+// "[]T" or "map[string]T" for a list or map field of one of the types bool, string, int64, uint64
+// and float64, as added by eg [Section.AddStringList] or [Section.AddInt64Map].  The other
+// properties of a field are optional: the default is written as a value in the input would be,
+// though JSON numbers and booleans are also accepted, "env" is as for [Field.DefaultFromEnv], and
+// "min" and "max" are as for [Field.Range] and apply to numeric fields only.  A section can also
+// be "required", as for [Section.Required].
+//
+// LoadSchema returns an error if the description is not valid.
+func LoadSchema(r io.Reader) (*Parser, error) {
+	var s schemaJSON
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("Invalid schema: %w", err)
+	}
+	options, err := s.Options.options()
+	if err != nil {
+		return nil, err
+	}
+	parser := NewParser(options...)
+	for _, ss := range s.Sections {
+		if !isName(ss.Name) || parser.sections[ss.Name] != nil {
+			return nil, fmt.Errorf("Invalid schema: bad or duplicate section name '%s'", ss.Name)
+		}
+		section := parser.AddSection(ss.Name)
+		if ss.Required {
+			section.Required()
+		}
+		for _, sf := range ss.Fields {
+			if err := sf.add(section); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return parser, nil
+}
+
+func (so *schemaOptions) options() ([]any, error) {
+	var options []any
+	runes := []struct {
+		name  string
+		value *string
+		with  func(rune) Option
+	}{
+		{"CommentChar", so.CommentChar, WithCommentChar},
+		{"QuoteChar", so.QuoteChar, WithQuoteChar},
+		{"LiteralQuoteChar", so.LiteralQuoteChar, WithLiteralQuoteChar},
+		{"ListDelim", so.ListDelim, WithListDelim},
+	}
+	for _, o := range runes {
+		if o.value == nil {
+			continue
+		}
+		c, size := utf8.DecodeRuneInString(*o.value)
+		if *o.value == "" && (o.name == "QuoteChar" || o.name == "LiteralQuoteChar") {
+			c = 0
+		} else if c == utf8.RuneError || size != len(*o.value) {
+			return nil, fmt.Errorf("Invalid schema: bad %s '%s'", o.name, *o.value)
+		}
+		options = append(options, o.with(c))
+	}
+	bools := []struct {
+		value *bool
+		with  func(bool) Option
+	}{
+		{so.Escapes, WithEscapes},
+		{so.RequireQuotes, WithRequireQuotes},
+		{so.IntPrefixes, WithIntPrefixes},
+		{so.BoolSynonyms, WithBoolSynonyms},
+		{so.ExpandVars, WithExpandVars},
+		{so.CRBreaks, WithCRBreaks},
+	}
+	for _, o := range bools {
+		if o.value != nil {
+			options = append(options, o.with(*o.value))
+		}
+	}
+	return options, nil
+}
+
+// collectionTypes maps the names of the list and map types in a schema to their Add methods.
+var collectionTypes = map[string]func(section *Section, name string) *Field{
+	"[]string":           (*Section).AddStringList,
+	"[]bool":             (*Section).AddBoolList,
+	"[]int64":            (*Section).AddInt64List,
+	"[]uint64":           (*Section).AddUint64List,
+	"[]float64":          (*Section).AddFloat64List,
+	"map[string]string":  (*Section).AddStringMap,
+	"map[string]bool":    (*Section).AddBoolMap,
+	"map[string]int64":   (*Section).AddInt64Map,
+	"map[string]uint64":  (*Section).AddUint64Map,
+	"map[string]float64": (*Section).AddFloat64Map,
+}
+
+func (sf *schemaField) add(section *Section) error {
+	path := section.name + "." + sf.Name
+	if !isName(sf.Name) || section.fields[sf.Name] != nil {
+		return fmt.Errorf("Invalid schema: bad or duplicate field name '%s'", path)
+	}
+	var field *Field
+	if add := collectionTypes[sf.Type]; add != nil {
+		field = add(section, sf.Name)
+	} else {
+		typesMu.RLock()
+		known := types[sf.Type] != nil
+		typesMu.RUnlock()
+		if !known {
+			return fmt.Errorf("Invalid schema: unknown type '%s' for field %s", sf.Type, path)
+		}
+		field = section.AddTyped(sf.Name, sf.Type)
+	}
+	if sf.Default != nil {
+		var text string
+		if err := json.Unmarshal(sf.Default, &text); err != nil {
+			text = string(sf.Default)
+		}
+		v, valid := field.valid(text)
+		if !valid {
+			return fmt.Errorf("Invalid schema: default value %s of field %s is not valid",
+				sf.Default, path)
+		}
+		field.defaultValue = v
+	}
+	if sf.Min != nil || sf.Max != nil {
+		if !isNumber(reflect.ValueOf(field.defaultValue)) {
+			return fmt.Errorf("Invalid schema: range for non-numeric field %s", path)
+		}
+		lo, hi := math.Inf(-1), math.Inf(1)
+		if sf.Min != nil {
+			lo = *sf.Min
+		}
+		if sf.Max != nil {
+			hi = *sf.Max
+		}
+		if lo > hi {
+			return fmt.Errorf("Invalid schema: empty range for field %s", path)
+		}
+		field.Range(lo, hi)
+	}
+	field.Help(sf.Help)
+	if sf.Required {
+		field.Required()
+	}
+	if sf.Secret {
+		field.Secret()
+	}
+	if sf.Env != "" {
+		field.DefaultFromEnv(sf.Env)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package ini
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadSchema(t *testing.T) {
+	schema := `{
+  "options": {"CommentChar": ";", "BoolSynonyms": true, "QuoteChar": ""},
+  "sections": [
+    {"name": "net", "required": true, "fields": [
+      {"name": "host", "type": "string", "default": "localhost", "required": true,
+       "help": "The server's host name"},
+      {"name": "port", "type": "int64", "default": 8080, "min": 1, "max": 65535},
+      {"name": "verbose", "type": "bool", "default": "yes"},
+      {"name": "password", "type": "string", "secret": true, "env": "INI_TEST_PASSWORD"},
+      {"name": "peers", "type": "[]string", "default": "a, b"}
+    ]}
+  ]
+}`
+	p, err := LoadSchema(strings.NewReader(schema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.CommentChar != ';' || !p.BoolSynonyms || p.QuoteChar != 0 {
+		t.Fatal("Options")
+	}
+	net := p.Section("net")
+	host, port := net.Field("host"), net.Field("port")
+	if !net.IsRequired() || !host.IsRequired() || host.HelpText() != "The server's host name" ||
+		!net.Field("password").IsSecret() || port.Default() != int64(8080) ||
+		net.Field("verbose").Default() != true ||
+		len(net.Field("peers").Default().([]string)) != 2 {
+		t.Fatal("Fields")
+	}
+	store, err := p.Parse(strings.NewReader("[net]\n; comment\nhost = \"x\"\n"))
+	if err != nil || host.StringVal(store) != `"x"` || port.Int64Val(store) != 8080 {
+		t.Fatal(err)
+	}
+	if _, err := p.Parse(strings.NewReader("[net]\nhost = x\nport = 70000\n")); err == nil {
+		t.Fatal("Range")
+	}
+	if _, err := p.Parse(strings.NewReader("[net]\n")); err == nil {
+		t.Fatal("Required")
+	}
+
+	for _, test := range []struct{ schema, msg string }{
+		{`{"sections": [{"name": "a b"}]}`, "Invalid schema: bad or duplicate section name 'a b'"},
+		{`{"sections": [{"name": "s"}, {"name": "s"}]}`,
+			"Invalid schema: bad or duplicate section name 's'"},
+		{`{"sections": [{"name": "s", "fields": [{"name": "f", "type": "nope"}]}]}`,
+			"Invalid schema: unknown type 'nope' for field s.f"},
+		{`{"sections": [{"name": "s", "fields": [{"name": "f", "type": "int64", "default": "x"}]}]}`,
+			`Invalid schema: default value "x" of field s.f is not valid`},
+		{`{"sections": [{"name": "s", "fields": [{"name": "f", "type": "string", "min": 1}]}]}`,
+			"Invalid schema: range for non-numeric field s.f"},
+		{`{"options": {"ListDelim": "ab"}}`, "Invalid schema: bad ListDelim 'ab'"},
+	} {
+		if _, err := LoadSchema(strings.NewReader(test.schema)); err == nil ||
+			err.Error() != test.msg {
+			t.Fatal(test.schema, err)
+		}
+	}
+	if _, err := LoadSchema(strings.NewReader(`{"sectons": []}`)); err == nil {
+		t.Fatal("Unknown keys should be rejected")
+	}
+}
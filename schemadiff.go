@@ -0,0 +1,111 @@
+package ini
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// A SchemaChangeKind classifies a difference between two schemas.
+type SchemaChangeKind int
+
+const (
+	SectionAdded   SchemaChangeKind = iota + 1 // The section is only in the new schema
+	SectionRemoved                             // The section is only in the old schema
+	FieldAdded                                 // The field is only in the new schema
+	FieldRemoved                               // The field is only in the old schema
+	FieldRetyped                               // The field's type differs
+	DefaultChanged                             // The field's static default value differs
+)
+
+// A SchemaChange is a difference between two schemas, see [SchemaDiff].
+type SchemaChange struct {
+	Kind    SchemaChangeKind
+	Section string
+	Field   string // The field's name, or "" for SectionAdded and SectionRemoved
+	Old     string // For FieldRetyped and DefaultChanged, the old type or default value
+	New     string // For FieldRetyped and DefaultChanged, the new type or default value
+}
+
+// String renders the change as one line, eg `Field net.port: default changed from 80 to 8080`.
+func (c SchemaChange) String() string {
+	switch c.Kind {
+	case SectionAdded:
+		return "Section " + c.Section + ": added"
+	case SectionRemoved:
+		return "Section " + c.Section + ": removed"
+	}
+	name := "Field " + c.Section + "." + c.Field + ": "
+	switch c.Kind {
+	case FieldAdded:
+		return name + "added"
+	case FieldRemoved:
+		return name + "removed"
+	case FieldRetyped:
+		return name + "type changed from " + c.Old + " to " + c.New
+	default:
+		return name + "default changed from " + c.Old + " to " + c.New
+	}
+}
+
+// SchemaDiff returns the differences between the schemas of the parsers old and new, eg for
+// release notes or to generate migrations: the sections and fields that were added or removed,
+// the fields whose types changed, and the fields whose static default values changed.  The fields
+// of an added or removed section are not reported separately, and a field whose type changed is
+// not also reported for its default value.
+//
+// The changes for the sections of old come first, in old's declaration order and with the added
+// fields of a section after its other changes, followed by the added sections in new's order.
+// Types are named as by [Field.TypeName] for registered types and by their Go types otherwise,
+// and default values are rendered as by [Field.FormatValue], with the values of fields that are
+// secret in either schema as [Redacted].
+func SchemaDiff(old, new *Parser) []SchemaChange {
+	var changes []SchemaChange
+	for _, section := range old.order {
+		newSection := new.sections[section.name]
+		if newSection == nil {
+			changes = append(changes, SchemaChange{Kind: SectionRemoved, Section: section.name})
+			continue
+		}
+		for _, field := range section.order {
+			change := SchemaChange{Section: section.name, Field: field.name}
+			newField := newSection.fields[field.name]
+			switch {
+			case newField == nil:
+				change.Kind = FieldRemoved
+			case field.typeDesc() != newField.typeDesc():
+				change.Kind = FieldRetyped
+				change.Old, change.New = field.typeDesc(), newField.typeDesc()
+			case !reflect.DeepEqual(field.defaultValue, newField.defaultValue):
+				change.Kind = DefaultChanged
+				change.Old = field.FormatValue(field.defaultValue)
+				change.New = newField.FormatValue(newField.defaultValue)
+				if field.secret || newField.secret {
+					change.Old, change.New = Redacted, Redacted
+				}
+			default:
+				continue
+			}
+			changes = append(changes, change)
+		}
+		for _, field := range newSection.order {
+			if section.fields[field.name] == nil {
+				changes = append(changes,
+					SchemaChange{Kind: FieldAdded, Section: section.name, Field: field.name})
+			}
+		}
+	}
+	for _, section := range new.order {
+		if old.sections[section.name] == nil {
+			changes = append(changes, SchemaChange{Kind: SectionAdded, Section: section.name})
+		}
+	}
+	return changes
+}
+
+// typeDesc returns the name of the field's type, for SchemaDiff.
+func (field *Field) typeDesc() string {
+	if field.typeName != "" {
+		return field.typeName
+	}
+	return fmt.Sprintf("%T", field.defaultValue)
+}
@@ -0,0 +1,45 @@
+package ini
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSchemaDiff(t *testing.T) {
+	old := NewParser()
+	old.Define("net").
+		String("host").Default("localhost").
+		Int64("port").Default(80).
+		Int64("timeout").
+		String("password").Default("a").Secret().
+		Bool("legacy")
+	old.AddSection("gone").AddString("x")
+
+	new := NewParser()
+	new.Define("net").
+		String("host").Default("localhost").
+		Int64("port").Default(8080).
+		Float64("timeout").
+		String("password").Default("b").Secret().
+		StringList("peers")
+	new.AddSection("log").AddString("level")
+
+	var b strings.Builder
+	for _, c := range SchemaDiff(old, new) {
+		b.WriteString(c.String() + "\n")
+	}
+	want := `Field net.port: default changed from 80 to 8080
+Field net.timeout: type changed from int64 to float64
+Field net.password: default changed from <redacted> to <redacted>
+Field net.legacy: removed
+Field net.peers: added
+Section gone: removed
+Section log: added
+`
+	if b.String() != want {
+		t.Fatalf("Got\n%s", b.String())
+	}
+	if d := SchemaDiff(old, old); d != nil {
+		t.Fatal(d)
+	}
+}
@@ -0,0 +1,139 @@
+package ini
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// storeJSON is the serialized form of a Store, see MarshalStore.  Values are held as text that the
+// fields' valid functions accept.
+type storeJSON struct {
+	Schema   string                       `json:"schema"`
+	Values   map[string]map[string]string `json:"values"`
+	Defaults map[string]string            `json:"defaults,omitempty"`
+}
+
+// MarshalStore serializes the store as JSON, for caching a parsed configuration or sending it to
+// another process, where [Parser.UnmarshalStore] rehydrates it against the same schema.  Values are
+// serialized as text rendered by the fields' formatters (see [Field.FormatValue]), so the values
+// of fields of user-defined types must survive that round trip.  Values computed by
+// [Field.DefaultFunc] and [Field.DefaultFromEnv] are included.  Note that the values of secret
+// fields are not redacted, which is why a Store does not implement json.Marshaler: encoding a
+// store, or a struct that contains one, with encoding/json does not reveal its values.  The data
+// can be stored with encoding/gob or any other mechanism.
+func (store *Store) MarshalStore() ([]byte, error) {
+	s := storeJSON{
+		Schema: store.parser.Fingerprint(),
+		Values: make(map[string]map[string]string),
+	}
+	for sectName, values := range store.sections {
+		section := store.parser.sections[sectName]
+		m := make(map[string]string, len(values.values))
+		for name, v := range values.values {
+			m[name] = section.fields[name].text(v)
+		}
+		s.Values[sectName] = m
+	}
+	for field, v := range store.defaults {
+		if s.Defaults == nil {
+			s.Defaults = make(map[string]string)
+		}
+		s.Defaults[field.section.name+"."+field.name] = field.text(v)
+	}
+	return json.Marshal(s)
+}
+
+// UnmarshalStore rehydrates a store serialized by [Store.MarshalStore] for a parser with the same
+// schema, checking every value against its field as Parse does and storing the values of Var
+// fields.  It fails with an error if the schema differs from the one the store was created with,
+// as determined by their hashes, or if the data are not valid.  The data do not record the order
+// of the input, so the sections and fields of the store are in alphabetical order, see
+// [Store.Fields].
+func (parser *Parser) UnmarshalStore(data []byte) (*Store, error) {
+	var s storeJSON
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("Invalid store data: %w", err)
+	}
+	if s.Schema != parser.Fingerprint() {
+		return nil, fmt.Errorf("Store data are for a different schema")
+	}
+	store := newStore(parser)
+	for _, sectName := range slices.Sorted(maps.Keys(s.Values)) {
+		m := s.Values[sectName]
+		section := parser.sections[sectName]
+		if section == nil {
+			return nil, fmt.Errorf("Invalid store data: no section %s", sectName)
+		}
+		values := store.ensure(section)
+		for _, name := range slices.Sorted(maps.Keys(m)) {
+			field, v, err := parser.unmarshalValue(section, name, m[name])
+			if err != nil {
+				return nil, err
+			}
+			values.set(field.name, v)
+		}
+	}
+	for _, path := range slices.Sorted(maps.Keys(s.Defaults)) {
+		text := s.Defaults[path]
+		sectName, name, _ := strings.Cut(path, ".")
+		section := parser.sections[sectName]
+		if section == nil {
+			return nil, fmt.Errorf("Invalid store data: no section %s", sectName)
+		}
+		field, v, err := parser.unmarshalValue(section, name, text)
+		if err != nil {
+			return nil, err
+		}
+		store.defaults[field] = v
+	}
+	parser.assignVars(store)
+	return store, nil
+}
+
+func (parser *Parser) unmarshalValue(section *Section, name, text string) (*Field, any, error) {
+	field := section.fields[name]
+	if field == nil {
+		return nil, nil, fmt.Errorf("Invalid store data: no field %s.%s", section.name, name)
+	}
+	v, valid := field.valid(text)
+	if !valid {
+		return nil, nil, fmt.Errorf(
+			"Invalid store data: value '%s' is not valid for field %s.%s",
+			field.redact(text), section.name, name)
+	}
+	return field, v, nil
+}
+
+// text renders a value of the field as text that its valid function accepts.
+func (field *Field) text(v any) string {
+	if field.list {
+		return field.FormatValue(v)
+	}
+	if field.format != nil {
+		return field.format(v)
+	}
+	return renderValue(v)
+}
+
+// Fingerprint returns a hash of the parser's schema as a hex string: its sections and fields, with
+// the fields' types and static default values.  The hash does not depend on the order in which
+// sections and fields were added, or on the parser's options, so it is stable across program runs
+// and can be compared between components to detect schema drift or to invalidate cached stores.
+func (parser *Parser) Fingerprint() string {
+	h := sha256.New()
+	for _, sectName := range slices.Sorted(maps.Keys(parser.sections)) {
+		section := parser.sections[sectName]
+		fmt.Fprintf(h, "[%s]\n", sectName)
+		for _, name := range slices.Sorted(maps.Keys(section.fields)) {
+			field := section.fields[name]
+			fmt.Fprintf(h, "%s %d %q %t %q %T %q\n", name, field.ty, field.typeName, field.list,
+				field.defaultEnv, field.defaultValue, renderValue(field.defaultValue))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,130 @@
+package ini
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStoreJSON(t *testing.T) {
+	schema := func() (*Parser, *int64) {
+		var port int64
+		p := NewParser()
+		s := p.AddSection("sect")
+		s.AddString("name")
+		s.AddBool("flag")
+		s.AddFloat64("ratio")
+		s.AddInt64Var("port", &port)
+		s.AddStringList("tags")
+		s.AddTyped("addr", "test-addr")
+		s.AddInt64("computed").DefaultFunc(func() any { return int64(42) })
+		s.Add("timeout", TyUser, time.Duration(0), func(s string) (any, bool) {
+			d, err := time.ParseDuration(s)
+			return d, err == nil
+		})
+		p.AddSection("empty")
+		return p, &port
+	}
+	p, _ := schema()
+	store, err := p.Parse(strings.NewReader(`
+[sect]
+name = " spaced, out "
+flag = false
+ratio = 0.1
+port = 8080
+tags = a, "b, c"
+addr = ::1
+timeout = 1m30s
+[empty]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := store.MarshalStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain, err := json.Marshal(struct{ S *Store }{store}); err != nil ||
+		bytes.Contains(plain, []byte("8080")) {
+		t.Fatal(string(plain), err)
+	}
+
+	// Through gob, as a cache would
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(data); err != nil {
+		t.Fatal(err)
+	}
+	var cached []byte
+	if err := gob.NewDecoder(&buf).Decode(&cached); err != nil {
+		t.Fatal(err)
+	}
+
+	q, port := schema()
+	restored, err := q.UnmarshalStore(cached)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *port != 8080 {
+		t.Fatal("Var")
+	}
+	s := q.Section("sect")
+	if x := s.Field("name").StringVal(restored); x != " spaced, out " {
+		t.Fatalf("%q", x)
+	}
+	if s.Field("flag").BoolVal(restored) || s.Field("ratio").Float64Val(restored) != 0.1 ||
+		s.Field("computed").Int64Val(restored) != 42 || s.Field("computed").Present(restored) ||
+		ValueOf[time.Duration](s.Field("timeout"), restored) != 90*time.Second ||
+		ValueOf[netip.Addr](s.Field("addr"), restored) != netip.MustParseAddr("::1") ||
+		!slices.Equal(ValueOf[[]string](s.Field("tags"), restored), []string{"a", "b, c"}) ||
+		!restored.lookupSect(q.Section("empty")) {
+		t.Fatal(restored.RedactedMap())
+	}
+
+	q.Section("sect").AddString("extra")
+	if _, err := q.UnmarshalStore(data); err == nil {
+		t.Fatal("Should fail on schema change")
+	}
+	if _, err := p.UnmarshalStore([]byte("{")); err == nil {
+		t.Fatal("Should fail on bad data")
+	}
+	bad := bytes.Replace(data, []byte(`"flag":"false"`), []byte(`"flag":"maybe"`), 1)
+	if _, err := p.UnmarshalStore(bad); err == nil ||
+		err.Error() != "Invalid store data: value 'maybe' is not valid for field sect.flag" {
+		t.Fatal(err)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	schema := func(reverse bool, def int64) *Parser {
+		p := NewParser()
+		names := []string{"a", "b"}
+		if reverse {
+			names = []string{"b", "a"}
+		}
+		for _, n := range names {
+			s := p.AddSection(n)
+			s.AddString("x")
+			s.Add("y", TyInt64, def, ParseInt64)
+		}
+		return p
+	}
+	fp := schema(false, 0).Fingerprint()
+	if len(fp) != 64 || schema(true, 0).Fingerprint() != fp {
+		t.Fatal("Unstable")
+	}
+	if schema(false, 1).Fingerprint() == fp {
+		t.Fatal("Default ignored")
+	}
+	p := schema(false, 0)
+	p.Section("a").AddStringList("z")
+	q := schema(false, 0)
+	q.Section("a").AddString("z")
+	if p.Fingerprint() == fp || q.Fingerprint() == fp || p.Fingerprint() == q.Fingerprint() {
+		t.Fatal("Field ignored")
+	}
+}
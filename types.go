@@ -0,0 +1,132 @@
+package ini
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// A registeredType is an entry in the type registry.  If add is not nil it is used to add fields of
+// the type, so that builtin types honor the parser's options, and parse and format may be nil.
+type registeredType struct {
+	parse  func(s string) (any, bool)
+	format func(v any) string
+	add    func(section *Section, name string) *Field
+}
+
+var (
+	typesMu sync.RWMutex
+	types   = make(map[string]*registeredType)
+)
+
+func init() {
+	registerBuiltin("bool", (*Section).AddBool, ParseBool, formatAny)
+	registerBuiltin("string", (*Section).AddString, ParseString, formatAny)
+	registerBuiltin("path", (*Section).AddPath, ParsePath, formatAny)
+	registerBuiltin("int64", (*Section).AddInt64, ParseInt64, formatAny)
+	registerBuiltin("uint64", (*Section).AddUint64, ParseUint64, formatAny)
+	registerBuiltin("float64", (*Section).AddFloat64, ParseFloat64, formatFloat64)
+	registerBuiltin("int", (*Section).AddInt, parseDecimal[int], formatAny)
+	registerBuiltin("int8", (*Section).AddInt8, parseDecimal[int8], formatAny)
+	registerBuiltin("int16", (*Section).AddInt16, parseDecimal[int16], formatAny)
+	registerBuiltin("int32", (*Section).AddInt32, parseDecimal[int32], formatAny)
+	registerBuiltin("uint", (*Section).AddUint, parseDecimal[uint], formatAny)
+	registerBuiltin("uint8", (*Section).AddUint8, parseDecimal[uint8], formatAny)
+	registerBuiltin("uint16", (*Section).AddUint16, parseDecimal[uint16], formatAny)
+	registerBuiltin("uint32", (*Section).AddUint32, parseDecimal[uint32], formatAny)
+	registerBuiltin("color", (*Section).AddColor, ParseColor, formatColor)
+	registerBuiltin("loglevel", (*Section).AddLogLevel, ParseLogLevel, formatLogLevel)
+	registerBuiltin("glob", (*Section).AddGlob, ParseGlobPattern, formatAny)
+	registerBuiltin("regexp", (*Section).AddRegexp, ParseRegexp, formatRegexp)
+	registerBuiltin("cron", (*Section).AddCron, ParseCron, formatAny)
+	registerBuiltin("timeofday", (*Section).AddTimeOfDay, ParseTimeOfDay, formatAny)
+	registerBuiltin("weekday", (*Section).AddWeekday, ParseWeekday, formatAny)
+	registerBuiltin("timewindow", (*Section).AddTimeWindow, ParseTimeWindow, formatAny)
+	registerBuiltin("email", (*Section).AddEmail, ParseEmail, formatAny)
+	registerBuiltin("hostname", (*Section).AddHostname, ParseHostname, formatAny)
+	for name, encoding := range map[string]BytesEncoding{
+		"base64": Base64, "base64url": Base64URL, "hex": Hex,
+	} {
+		registerBuiltin(name, func(section *Section, name string) *Field {
+			return section.AddBytes(name, encoding)
+		}, nil, nil)
+	}
+}
+
+func registerBuiltin(
+	name string,
+	add func(section *Section, name string) *Field,
+	parse func(s string) (any, bool),
+	format func(v any) string,
+) {
+	types[name] = &registeredType{parse: parse, format: format, add: add}
+}
+
+func parseDecimal[T Integer](s string) (any, bool) {
+	return parseIntOf[T](s, 10)
+}
+
+func formatAny(v any) string {
+	return fmt.Sprint(v)
+}
+
+func formatFloat64(v any) string {
+	return strconv.FormatFloat(v.(float64), 'g', -1, 64)
+}
+
+// RegisterType registers a user-defined type under the given name, so that fields of the type can
+// be added to any section with [Section.AddTyped].  The parse function accepts the string
+// representations of the type's values, as the valid function of [Section.Add], and the format
+// function is its inverse: it renders a value as text that parse accepts.  The builtin types are
+// preregistered under their Go names: "bool", "string", "int64", "uint64", "float64", "int",
+// "int8", "int16", "int32", "uint", "uint8", "uint16", and "uint32", and "path", "color",
+// "loglevel", "glob", "regexp", "cron", "timeofday", "weekday", "timewindow", "email", "hostname",
+// "base64", "base64url" and "hex" are registered for the fields added with [Section.AddPath],
+// [Section.AddColor], [Section.AddLogLevel], [Section.AddGlob], [Section.AddRegexp],
+// [Section.AddCron], [Section.AddTimeOfDay], [Section.AddWeekday], [Section.AddTimeWindow],
+// [Section.AddEmail], [Section.AddHostname] and [Section.AddBytes].
+//
+// RegisterType panics if the name is already registered or either function is nil.  It is
+// normally called from an init function.
+func RegisterType(name string, parse func(s string) (any, bool), format func(v any) string) {
+	if parse == nil || format == nil {
+		panic("RegisterType requires parse and format functions")
+	}
+	typesMu.Lock()
+	defer typesMu.Unlock()
+	if types[name] != nil {
+		panic("Duplicate type " + name)
+	}
+	types[name] = &registeredType{parse: parse, format: format}
+}
+
+// AddTyped adds a new field of the given name to the section whose values are of the registered
+// type typeName, see [RegisterType].  The name must not be present in the section and must be
+// syntactically valid (see package comments).  For builtin types the field is as if added with the
+// type's `Add<Type>` method, eg AddInt64 for "int64".  For user-defined types the field has type
+// TyUser and the default value is nil.  AddTyped panics if the type is not registered.
+func (section *Section) AddTyped(name string, typeName string) *Field {
+	typesMu.RLock()
+	rt := types[typeName]
+	typesMu.RUnlock()
+	if rt == nil {
+		panic("Unknown type " + typeName)
+	}
+	var field *Field
+	if rt.add != nil {
+		field = rt.add(section, name)
+	} else {
+		field = section.Add(name, TyUser, nil, rt.parse)
+	}
+	field.typeName = typeName
+	if rt.format != nil {
+		field.format = rt.format
+	}
+	return field
+}
+
+// TypeName returns the name of the field's registered type if it was added with
+// [Section.AddTyped], otherwise "".
+func (field *Field) TypeName() string {
+	return field.typeName
+}
@@ -0,0 +1,51 @@
+package ini
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func init() {
+	RegisterType(
+		"test-addr",
+		func(s string) (any, bool) {
+			a, err := netip.ParseAddr(s)
+			return a, err == nil
+		},
+		func(v any) string {
+			return v.(netip.Addr).String()
+		})
+}
+
+func TestRegisterType(t *testing.T) {
+	p := NewParser("IntPrefixes", true)
+	s := p.AddSection("sect")
+	addr := s.AddTyped("addr", "test-addr")
+	port := s.AddTyped("port", "uint16")
+	n := s.AddTyped("n", "int64")
+	if addr.Type() != TyUser || addr.TypeName() != "test-addr" || n.Type() != TyInt64 ||
+		n.TypeName() != "int64" || s.AddString("plain").TypeName() != "" {
+		t.Fatal("Types")
+	}
+	if addr.format(netip.MustParseAddr("::1")) != "::1" || port.format(uint16(80)) != "80" {
+		t.Fatal("Format")
+	}
+	store, err := p.Parse(strings.NewReader("[sect]\naddr = 10.0.0.1\nport = 0x50\nn = 1_000\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ValueOf[netip.Addr](addr, store) != netip.MustParseAddr("10.0.0.1") ||
+		ValueOf[uint16](port, store) != 80 || n.Int64Val(store) != 1000 {
+		t.Fatal("Values")
+	}
+	if _, err := p.Parse(strings.NewReader("[sect]\naddr = nowhere\n")); err == nil {
+		t.Fatal("Should fail")
+	}
+
+	expectPanic(t, "Unknown type nonesuch", func() { s.AddTyped("x", "nonesuch") })
+	expectPanic(t, "Duplicate type int64", func() { RegisterType("int64", ParseInt64, formatAny) })
+	expectPanic(t, "RegisterType requires parse and format functions", func() {
+		RegisterType("x", nil, formatAny)
+	})
+}
@@ -0,0 +1,120 @@
+package ini
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A VarSyntax selects the syntax of variable references in values, see [Parser.VarSyntax].
+type VarSyntax int
+
+const (
+	ShellVars   VarSyntax = iota // `$NAME` and `${NAME}`, with `$$` for a literal `$`
+	PercentVars                  // `%NAME%`, as in Windows batch files, with `%%` for a literal `%`
+)
+
+// An UnboundVars value selects the treatment of references to variables that are not bound, see
+// [Parser.UnboundVars].
+type UnboundVars int
+
+const (
+	UnboundEmpty   UnboundVars = iota // The reference is replaced by the empty string
+	UnboundLiteral                    // The reference is left in the value as it is
+	UnboundError                      // The reference is a parse error
+)
+
+var (
+	varRe        = regexp.MustCompile(`\$\$|\$[a-zA-Z0-9_]+|\$\{[^}]*\}`)
+	percentVarRe = regexp.MustCompile(`%%|%[a-zA-Z_][a-zA-Z0-9_()]*%`)
+)
+
+// maxVarDepth is the maximum nesting of recursive variable expansion.
+const maxVarDepth = 8
+
+// varPattern returns the pattern of variable references for the parser's VarSyntax, with the
+// metacharacter that starts them.
+func (parser *Parser) varPattern() (*regexp.Regexp, string) {
+	if parser.VarSyntax == PercentVars {
+		return percentVarRe, "%"
+	}
+	return varRe, "$"
+}
+
+// expandVars replaces the variable references in s, which is from the named file, by the values of
+// the variables.  If UnboundVars is UnboundError then it also returns an error for the first
+// reference to an unbound variable, which is replaced by the empty string.
+func (parser *Parser) expandVars(s, file string) (string, error) {
+	re, meta := parser.varPattern()
+	var err error
+	var expand func(s string, depth int) string
+	expand = func(s string, depth int) string {
+		return re.ReplaceAllStringFunc(s, func(m string) string {
+			if m == meta+meta {
+				return meta
+			}
+			var name string
+			switch {
+			case meta == "%":
+				name = m[1 : len(m)-1]
+			case m[1] == '{':
+				name = m[2 : len(m)-1]
+			default:
+				name = m[1:]
+			}
+			value, found := builtinVar(name, file)
+			if !found {
+				value, found = os.LookupEnv(name)
+			}
+			if !found {
+				switch parser.UnboundVars {
+				case UnboundLiteral:
+					return m
+				case UnboundError:
+					if err == nil {
+						err = fmt.Errorf("Unbound variable %s", name)
+					}
+				}
+			}
+			if parser.RecursiveVars && depth < maxVarDepth {
+				value = expand(value, depth+1)
+			}
+			return value
+		})
+	}
+	s = expand(s, 1)
+	return s, err
+}
+
+// builtinVar returns the value of the built-in variable of the given name for the named file, and
+// true, or false if there is no such variable or it has no value.
+func builtinVar(name, file string) (string, bool) {
+	switch name {
+	case "__FILE__":
+		return file, file != ""
+	case "__DIR__":
+		switch {
+		case file == "":
+			return "", false
+		case isURL(file):
+			return file[:strings.LastIndex(file, "/")], true
+		default:
+			return filepath.Dir(file), true
+		}
+	case "__HOSTNAME__":
+		host, err := os.Hostname()
+		return host, err == nil
+	case "__PID__":
+		return strconv.Itoa(os.Getpid()), true
+	}
+	return "", false
+}
+
+// escapeVars protects the metacharacters of variable references in s from expansion.
+func (parser *Parser) escapeVars(s string) string {
+	_, meta := parser.varPattern()
+	return strings.ReplaceAll(s, meta, meta+meta)
+}
@@ -0,0 +1,120 @@
+package ini
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestVarSyntax(t *testing.T) {
+	t.Setenv("INI_TEST_A", "a")
+	t.Setenv("INI_TEST_B", "[$INI_TEST_A %INI_TEST_A%]")
+	t.Setenv("INI_TEST_LOOP", "<$INI_TEST_LOOP>")
+	get := func(p *Parser, value string) string {
+		t.Helper()
+		f := p.AddSection("s").AddString("x")
+		store, err := p.Parse(strings.NewReader("[s]\nx = " + value + "\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f.StringVal(store)
+	}
+	percent := WithVarSyntax(PercentVars)
+	for _, tc := range []struct {
+		options []any
+		value   string
+		want    string
+	}{
+		{nil, "$INI_TEST_B", "[$INI_TEST_A %INI_TEST_A%]"},
+		{[]any{WithRecursiveVars(true)}, "$INI_TEST_B", "[a %INI_TEST_A%]"},
+		{[]any{percent}, "%INI_TEST_A%-$INI_TEST_A-100%%-50%", "a-$INI_TEST_A-100%-50%"},
+		{[]any{percent, WithRecursiveVars(true)}, "%INI_TEST_B%", "[$INI_TEST_A a]"},
+		{[]any{WithRecursiveVars(true)}, "$INI_TEST_LOOP", "<<<<<<<<$INI_TEST_LOOP>>>>>>>>"},
+	} {
+		p := NewParser(append(tc.options, WithExpandVars(true))...)
+		if got := get(p, tc.value); got != tc.want {
+			t.Fatalf("%s: got %q, want %q", tc.value, got, tc.want)
+		}
+	}
+
+	p := NewParser(percent, WithExpandVars(true))
+	f := p.AddSection("s").AddString("x")
+	store, err := p.Parse(strings.NewReader("[s]\nx = %%a%% 5%\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	if err := store.Write(&out, nil); err != nil || out.String() != "[s]\nx = %%a%% 5%%\n" {
+		t.Fatal(err, out.String())
+	}
+	if f.StringVal(store) != "%a% 5%" {
+		t.Fatal(f.StringVal(store))
+	}
+}
+
+func TestUnboundVars(t *testing.T) {
+	t.Setenv("INI_TEST_A", "a")
+	input := "[s]\nx = $INI_TEST_A${INI_TEST_NOPE}\nl = a, $INI_TEST_A, '$INI_TEST_NOPE'\n"
+	parse := func(u UnboundVars) (string, []string, error) {
+		p := NewParser(WithExpandVars(true), WithUnboundVars(u), WithLiteralQuoteChar('\''))
+		s := p.AddSection("s")
+		x, l := s.AddString("x"), s.AddStringList("l")
+		store, err := p.Parse(strings.NewReader(input))
+		if err != nil {
+			return "", nil, err
+		}
+		return x.StringVal(store), l.StringListVal(store), nil
+	}
+	if x, l, err := parse(UnboundEmpty); err != nil || x != "a" || len(l) != 3 {
+		t.Fatal(x, l, err)
+	}
+	if x, _, err := parse(UnboundLiteral); err != nil || x != "a${INI_TEST_NOPE}" {
+		t.Fatal(x, err)
+	}
+	if _, _, err := parse(UnboundError); err == nil ||
+		err.Error() != "Line 2: In section s: Unbound variable INI_TEST_NOPE" {
+		t.Fatal(err)
+	}
+	input = "[s]\nl = a, $INI_TEST_A, $INI_TEST_NOPE\n"
+	if _, _, err := parse(UnboundError); err == nil ||
+		err.Error() != "Line 2: In section s: Unbound variable INI_TEST_NOPE" {
+		t.Fatal(err)
+	}
+	input = "[s]\nx = $$INI_TEST_NOPE\nl = a, $INI_TEST_A, '$INI_TEST_NOPE'\n"
+	x, l, err := parse(UnboundError)
+	if err != nil || x != "$INI_TEST_NOPE" || l[2] != "$INI_TEST_NOPE" {
+		t.Fatal(x, l, err)
+	}
+}
+
+func TestBuiltinVars(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.ini")
+	input := "[s]\nx = ${__FILE__}\nd = $__DIR__\np = $__PID__\nh = ${__HOSTNAME__}\n" +
+		"l = $__DIR__/a, b\n"
+	if err := os.WriteFile(name, []byte(input), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p := NewParser(WithExpandVars(true), WithUnboundVars(UnboundError))
+	s := p.AddSection("s")
+	x, d, pid, h, l := s.AddString("x"), s.AddString("d"), s.AddString("p"), s.AddString("h"),
+		s.AddStringList("l")
+	store, err := p.ParseFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, _ := os.Hostname()
+	dir := filepath.Dir(name)
+	if x.StringVal(store) != name || d.StringVal(store) != dir ||
+		pid.StringVal(store) != strconv.Itoa(os.Getpid()) || h.StringVal(store) != host {
+		t.Fatal(x.StringVal(store), d.StringVal(store), pid.StringVal(store), h.StringVal(store))
+	}
+	if elems := l.StringListVal(store); len(elems) != 2 || elems[0] != dir+"/a" {
+		t.Fatal(elems)
+	}
+	if _, err := p.Parse(strings.NewReader("[s]\nx = $__FILE__\n")); err == nil ||
+		!strings.Contains(err.Error(), "Unbound variable __FILE__") {
+		t.Fatal(err)
+	}
+}
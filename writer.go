@@ -0,0 +1,248 @@
+package ini
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// WriteOptions control the output of [Store.Write].  The zero value gives the default behavior.
+type WriteOptions struct {
+	// Defaults, if true, also writes the fields that were not present in the input, with their
+	// default values, as for [Store.WithDefaults].
+	Defaults bool
+
+	// Help, if true, makes the output self-documenting: each section header is preceded by a
+	// banner comment with the section's name, and each field by comments that give its help text
+	// (see [Field.Help]), its default value, and its allowed values if they are known from
+	// [AddChoice] or [Field.Range].  With Defaults, the store of an empty input gives a template
+	// for a configuration file.  Default values of secret fields are written as [Redacted].
+	Help bool
+
+	// SectionOrder, if not nil, orders the sections for output, as for slices.SortStableFunc, so
+	// that sections that compare equal stay in declaration order.  If nil then sections are
+	// written in declaration order.  [SectionsByName] gives alphabetical order.
+	SectionOrder func(a, b *Section) int
+
+	// FieldOrder, if not nil, orders the fields of each section for output, as for SectionOrder.
+	// [FieldsByName] gives alphabetical order.
+	FieldOrder func(a, b *Field) int
+
+	// Pinned names sections that are written first, in the given order, before the sections
+	// ordered by SectionOrder.  Write panics if a name is not a defined section.
+	Pinned []string
+}
+
+// SectionsByName compares sections by name, for [WriteOptions.SectionOrder].
+func SectionsByName(a, b *Section) int {
+	return strings.Compare(a.name, b.name)
+}
+
+// FieldsByName compares fields by name, for [WriteOptions.FieldOrder].
+func FieldsByName(a, b *Field) int {
+	return strings.Compare(a.name, b.name)
+}
+
+// sections returns the parser's sections in the order given by the options.
+func (opts *WriteOptions) sections(parser *Parser) []*Section {
+	var sections []*Section
+	pinned := make(map[*Section]bool)
+	for _, name := range opts.Pinned {
+		section := parser.sections[name]
+		if section == nil {
+			panic("No section " + name)
+		}
+		if !pinned[section] {
+			sections = append(sections, section)
+			pinned[section] = true
+		}
+	}
+	rest := slices.DeleteFunc(slices.Clone(parser.order), func(s *Section) bool {
+		return pinned[s]
+	})
+	if opts.SectionOrder != nil {
+		slices.SortStableFunc(rest, opts.SectionOrder)
+	}
+	return append(sections, rest...)
+}
+
+// fields returns the section's fields in the order given by the options.
+func (opts *WriteOptions) fields(section *Section) []*Field {
+	if opts.FieldOrder == nil {
+		return section.order
+	}
+	fields := slices.Clone(section.order)
+	slices.SortStableFunc(fields, opts.FieldOrder)
+	return fields
+}
+
+// Write writes the store to w as ini text from which the store's parser produces an equal store
+// (see [Store.Equal]): the sections and fields that were present in the input, with their values,
+// quoted and escaped as required by the parser's options.  Sections and fields are written in the
+// order they were added to the parser, unless the options say otherwise, and the values of secret
+// fields are written in the clear.  If opts is nil then default options are used.
+//
+// If the parser's Lenient is true then the undefined sections and settings of the store (see
+// [Store.Unknown]) are also written: the settings before the first section header first, the
+// undefined settings of each section after its fields, and the undefined sections last.
+//
+// Every value is checked before anything is written, and Write fails if a value cannot be
+// represented, eg a string containing a newline when the parser's Escapes is false, or a value
+// of a user-defined type whose formatter does not produce text that parses back to the value.
+// The check does not apply the parser's resolvers or LineHook, so the guarantee only holds for
+// parsers whose resolvers leave the written values alone and that have no LineHook.
+func (store *Store) Write(w io.Writer, opts *WriteOptions) error {
+	if opts == nil {
+		opts = &WriteOptions{}
+	}
+	if opts.Defaults {
+		store = store.WithDefaults()
+	}
+	parser := store.parser
+	var lines []string
+	// unknown appends the lines of the undefined settings of the section
+	unknown := func(sectName string) error {
+		for _, rs := range store.unknown {
+			if rs.Section == sectName && rs.Name != "" {
+				line, err := parser.rawSettingLine(rs)
+				if err != nil {
+					return err
+				}
+				lines = append(lines, line)
+			}
+		}
+		return nil
+	}
+	if err := unknown(""); err != nil {
+		return err
+	}
+	comment := string(parser.CommentChar)
+	header := func(name string) {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		if opts.Help {
+			rule := comment + " " + strings.Repeat("-", 70)
+			lines = append(lines, rule, comment+" "+name, rule)
+		}
+		lines = append(lines, "["+name+"]")
+	}
+	for _, section := range opts.sections(parser) {
+		if !store.lookupSect(section) {
+			continue
+		}
+		header(section.name)
+		for _, field := range opts.fields(section) {
+			if !field.Present(store) {
+				continue
+			}
+			line, err := field.settingLine(field.Value(store))
+			if err != nil {
+				return err
+			}
+			if opts.Help {
+				lines = append(lines, "")
+				for _, l := range field.helpLines(store) {
+					lines = append(lines, comment+" "+l)
+				}
+			}
+			lines = append(lines, line)
+		}
+		if err := unknown(section.name); err != nil {
+			return err
+		}
+	}
+	for _, rs := range store.unknown {
+		if rs.Name == "" {
+			header(rs.Section)
+			if err := unknown(rs.Section); err != nil {
+				return err
+			}
+		}
+	}
+	out := bufio.NewWriter(w)
+	for _, l := range lines {
+		out.WriteString(l + "\n")
+	}
+	return out.Flush()
+}
+
+// helpLines returns the lines of the help comment for the field, without the comment characters,
+// see WriteOptions.Help.
+func (field *Field) helpLines(store *Store) []string {
+	var lines []string
+	if field.help != "" {
+		lines = strings.Split(field.help, "\n")
+	}
+	def := field.FormatValue(field.defaultIn(store))
+	if field.secret {
+		def = Redacted
+	}
+	if def == "" {
+		def = "(empty)"
+	}
+	lines = append(lines, "Default: "+def)
+	switch {
+	case len(field.choices) > 0:
+		lines = append(lines, "Allowed values: "+strings.Join(field.choices, ", "))
+	case field.bounds != nil:
+		lines = append(lines, fmt.Sprintf("Allowed values: %g to %g", field.bounds[0],
+			field.bounds[1]))
+	}
+	return lines
+}
+
+// settingLine returns the line that sets the field to v, and checks that the parser reads v back
+// from it.
+func (field *Field) settingLine(v any) (string, error) {
+	parser := field.section.parser
+	line, value, ok := parser.checkedLine(field.name, field.FormatValue(v), field.list,
+		field.expands())
+	if ok {
+		back, valid := field.valid(value)
+		ok = valid && reflect.DeepEqual(back, v)
+	}
+	if !ok {
+		return "", fmt.Errorf("The value of %s.%s cannot be represented", field.section.name,
+			field.name)
+	}
+	return line, nil
+}
+
+// rawSettingLine returns the line for an undefined setting, and checks that the parser reads its
+// value back from it.
+func (parser *Parser) rawSettingLine(rs RawSetting) (string, error) {
+	expand := parser.expands(rs.Section, rs.Name)
+	line, value, ok := parser.checkedLine(rs.Name, parser.quote(rs.Value, false, expand), false,
+		expand)
+	if !ok || value != rs.Value {
+		path := rs.Name
+		if rs.Section != "" {
+			path = rs.Section + "." + path
+		}
+		return "", fmt.Errorf("The value of %s cannot be represented", path)
+	}
+	return line, nil
+}
+
+// checkedLine returns the line that sets name to text, and the value the parser gets from it, and
+// true, or false if the line is not read back as such a setting.  Variable references in the
+// value are expanded if expand is true.
+func (parser *Parser) checkedLine(name, text string, list, expand bool) (string, string, bool) {
+	line := name + " ="
+	if text != "" {
+		line += " " + text
+	}
+	if strings.Contains(line, "\n") || parser.CRBreaks && strings.Contains(line, "\r") {
+		return "", "", false
+	}
+	tok := classify(line, parser.CommentChar)
+	if tok.Kind != TokSetting || tok.Name != name {
+		return "", "", false
+	}
+	value, ambiguous, err := parser.settingValue(tok.Value, list, expand, "")
+	return line, value, !ambiguous && err == nil
+}
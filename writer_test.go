@@ -0,0 +1,204 @@
+package ini
+
+import (
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	p := NewParser()
+	s := p.AddSection("sect")
+	s.AddString("a")
+	s.AddInt64("b")
+	s.AddStringList("c").Secret()
+	p.AddSection("empty")
+	p.AddSection("absent").AddBool("x")
+	store, err := p.Parse(strings.NewReader("[sect]\na = \" x \"\nc = p, \"q, r\"\n[empty]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	if err := store.Write(&out, nil); err != nil {
+		t.Fatal(err)
+	}
+	expect := `[sect]
+a = " x "
+c = p, "q, r"
+
+[empty]
+`
+	if out.String() != expect {
+		t.Fatalf("Got\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := store.Write(&out, &WriteOptions{Defaults: true}); err != nil {
+		t.Fatal(err)
+	}
+	again, err := p.Parse(strings.NewReader(out.String()))
+	if err != nil || !again.Equal(store.WithDefaults()) {
+		t.Fatalf("Got\n%s", out.String())
+	}
+
+	// A newline can't be written without escapes, and nothing is written on failure
+	store = store.WithDefaults()
+	store.sections["sect"].values["a"] = "two\nlines"
+	out.Reset()
+	if err := store.Write(&out, nil); err == nil || out.Len() != 0 ||
+		err.Error() != "The value of sect.a cannot be represented" {
+		t.Fatal(err)
+	}
+}
+
+// TestWriteRoundTrip checks that Parse reads back what Write writes for random values under random
+// parser options, and that Write refuses nothing when the parser can quote and escape everything.
+func TestWriteHelp(t *testing.T) {
+	p := NewParser(WithCommentChar(';'))
+	b := p.Define("net").
+		String("host").Default("localhost").Help("The server's host name\nor address").
+		Int64("port").Default(8080).Range(1, 65535).Range(1024, 70000).
+		String("password").Default("hunter2").Secret().
+		String("empty")
+	b.Add(AddChoice(b.Section(), "mode", map[string]int{"fast": 1, "safe": 2})).Default(2)
+	store, err := p.ParseString("[net]\nport = 8081\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	if err := store.Write(&out, &WriteOptions{Defaults: true, Help: true}); err != nil {
+		t.Fatal(err)
+	}
+	rule := "; " + strings.Repeat("-", 70)
+	expect := rule + `
+; net
+` + rule + `
+[net]
+
+; The server's host name
+; or address
+; Default: localhost
+host = localhost
+
+; Default: 8080
+; Allowed values: 1024 to 65535
+port = 8081
+
+; Default: <redacted>
+password = hunter2
+
+; Default: (empty)
+empty =
+
+; Default: safe
+; Allowed values: fast, safe
+mode = safe
+`
+	if out.String() != expect {
+		t.Fatalf("Got\n%s", out.String())
+	}
+	again, err := p.ParseString(out.String())
+	if err != nil || !again.Equal(store.WithDefaults()) {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteOrder(t *testing.T) {
+	p := NewParser()
+	for _, name := range []string{"b", "meta", "a", "c"} {
+		s := p.AddSection(name)
+		s.AddString("y")
+		s.AddString("x")
+	}
+	store, err := p.ParseString("[a]\nx = 1\ny = 2\n[b]\ny = 3\n[c]\nx = 4\n[meta]\nx = 5\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(opts *WriteOptions) string {
+		var out strings.Builder
+		if err := store.Write(&out, opts); err != nil {
+			t.Fatal(err)
+		}
+		return strings.ReplaceAll(out.String(), "\n", " ")
+	}
+	for _, tc := range []struct {
+		opts *WriteOptions
+		want string
+	}{
+		{nil, "[b] y = 3  [meta] x = 5  [a] y = 2 x = 1  [c] x = 4 "},
+		{&WriteOptions{SectionOrder: SectionsByName, FieldOrder: FieldsByName},
+			"[a] x = 1 y = 2  [b] y = 3  [c] x = 4  [meta] x = 5 "},
+		{&WriteOptions{SectionOrder: SectionsByName, Pinned: []string{"meta", "c", "meta"}},
+			"[meta] x = 5  [c] x = 4  [a] y = 2 x = 1  [b] y = 3 "},
+		{&WriteOptions{SectionOrder: func(a, b *Section) int { return len(a.Name()) - len(b.Name()) }},
+			"[b] y = 3  [a] y = 2 x = 1  [c] x = 4  [meta] x = 5 "},
+	} {
+		if got := write(tc.opts); got != tc.want {
+			t.Fatalf("Got %q", got)
+		}
+	}
+	expectPanic(t, "No section nope", func() { write(&WriteOptions{Pinned: []string{"nope"}}) })
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	alphabet := []string{
+		"a", "b", "Z", "0", " ", "\t", `"`, "'", "#", ";", "=", ",", "$", "{", "}", `\`, "[", "]",
+		"@", "\n", "\r", " ", "é", "　",
+	}
+	randString := func() string {
+		var b strings.Builder
+		for range rng.IntN(8) {
+			b.WriteString(alphabet[rng.IntN(len(alphabet))])
+		}
+		return b.String()
+	}
+	for i := range 2000 {
+		opts := []any{
+			"CommentChar", []rune{'#', ';'}[rng.IntN(2)],
+			"QuoteChar", []rune{'"', '\'', 0}[rng.IntN(3)],
+			"Escapes", rng.IntN(2) == 0,
+			"RequireQuotes", rng.IntN(2) == 0,
+			"ExpandVars", rng.IntN(2) == 0,
+			"CRBreaks", rng.IntN(2) == 0,
+		}
+		if rng.IntN(2) == 0 {
+			opts = append(opts, "LiteralQuoteChar", '`')
+		}
+		p := NewParser(opts...)
+		s := p.AddSection("s")
+		str := s.AddString("str")
+		list := s.AddStringList("list")
+		f := s.AddFloat64("f")
+		store := newStore(p)
+		values := store.ensure(s)
+		values.values["str"] = randString()
+		elems := make([]string, rng.IntN(4))
+		for j := range elems {
+			elems[j] = randString()
+		}
+		values.values["list"] = elems
+		values.values["f"] = rng.NormFloat64()
+
+		var out strings.Builder
+		err := store.Write(&out, nil)
+		if err != nil {
+			// Without quotes and escapes, some values have no representation, and with CRBreaks
+			// neither does a carriage return
+			capable := p.QuoteChar != 0 && p.Escapes && !p.CRBreaks
+			if capable || err.Error() != "The value of s.str cannot be represented" &&
+				err.Error() != "The value of s.list cannot be represented" {
+				t.Fatalf("%d %v %q %q: %v", i, opts, str.StringVal(store), elems, err)
+			}
+			continue
+		}
+		again, err := p.Parse(strings.NewReader(out.String()))
+		if err != nil {
+			t.Fatalf("%d %v %q: %v", i, opts, out.String(), err)
+		}
+		if !again.Equal(store) {
+			t.Fatalf("%d %v %q: %q %q %v", i, opts, out.String(), str.StringVal(again),
+				ValueOf[[]string](list, again), f.Float64Val(again))
+		}
+	}
+}